@@ -2,4 +2,8 @@ module github.com/Paul-frank/todo-api
 
 go 1.20
 
-require github.com/mattn/go-sqlite3 v1.14.19
+require (
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.19
+	golang.org/x/crypto v0.17.0
+)
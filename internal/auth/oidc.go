@@ -0,0 +1,367 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSCacheTTL = time.Hour        // Standard für OIDCConfig.CacheTTL
+	minJWKSRefresh      = time.Minute      // frühestes erneutes Laden bei unbekannter kid
+	oidcClockSkew       = time.Minute      // erlaubte Zeitabweichung zum Identity Provider
+	oidcHTTPTimeout     = 10 * time.Second // Timeout des Standard HTTP Clients
+)
+
+// Einstellungen für die Anmeldung über einen OpenID Connect Provider
+type OIDCConfig struct {
+	Issuer     string        // Issuer URL des Providers, muss dem iss Claim der Tokens entsprechen
+	Audience   string        // erwarteter aud Claim, z.B. die Client ID
+	JWKSURL    string        // optional, sonst aus {Issuer}/.well-known/openid-configuration
+	CacheTTL   time.Duration // Gültigkeit der geladenen Schlüssel, Standard 1 Stunde
+	HTTPClient *http.Client  // optional, z.B. der Client eines httptest Servers
+}
+
+// Angemeldete Identität aus einem Token des Providers
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string // name, ersatzweise preferred_username
+}
+
+// Prüft ID- und Access Tokens (JWT) eines OpenID Connect Providers anhand seiner öffentlichen Schlüssel (JWKS).
+// Discovery und Schlüssel werden beim ersten Token geladen und für CacheTTL zwischengespeichert.
+type OIDCVerifier struct {
+	config OIDCConfig
+
+	mu        sync.Mutex
+	jwksURL   string                      // aus config oder Discovery
+	keys      map[string]crypto.PublicKey // Schlüssel nach kid
+	fetchedAt time.Time
+}
+
+func NewOIDCVerifier(config OIDCConfig) *OIDCVerifier {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if config.CacheTTL == 0 {
+		config.CacheTTL = defaultJWKSCacheTTL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: oidcHTTPTimeout}
+	}
+	return &OIDCVerifier{config: config, jwksURL: config.JWKSURL}
+}
+
+// Issuer URL des Providers
+func (v *OIDCVerifier) Issuer() string {
+	return v.config.Issuer
+}
+
+type oidcClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	NotBefore         int64    `json:"nbf"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// aud kann ein einzelner Wert oder eine Liste sein
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Prüft Signatur, Issuer, Audience und Gültigkeitszeitraum eines Tokens. Fehler des Tokens sind ErrInvalidToken bzw.
+// ErrTokenExpired, jeder andere Fehler bedeutet, dass Discovery oder Schlüssel nicht geladen werden konnten.
+func (v *OIDCVerifier) Verify(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, ErrInvalidToken
+	}
+
+	keys, err := v.keysFor(header.KeyID)
+	if err != nil {
+		return Identity{}, err
+	}
+	verified := false
+	for _, key := range keys {
+		if verifySignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return Identity{}, ErrInvalidToken
+	}
+
+	var claims oidcClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, ErrInvalidToken
+	}
+	if strings.TrimSuffix(claims.Issuer, "/") != v.config.Issuer || claims.Subject == "" || !claims.Audience.contains(v.config.Audience) {
+		return Identity{}, ErrInvalidToken
+	}
+	now := time.Now()
+	if claims.NotBefore != 0 && now.Add(oidcClockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return Identity{}, ErrInvalidToken
+	}
+	if claims.ExpiresAt == 0 || !now.Add(-oidcClockSkew).Before(time.Unix(claims.ExpiresAt, 0)) {
+		return Identity{}, ErrTokenExpired
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	return Identity{
+		Issuer:        v.config.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          name,
+	}, nil
+}
+
+// Schlüssel zur kid aus dem Cache. Abgelaufene Schlüssel werden neu geladen, ebenso bei einer unbekannten kid
+// (Schlüsselwechsel beim Provider), dann aber höchstens einmal je minJWKSRefresh.
+// Ohne kid kommen alle Schlüssel in Frage.
+func (v *OIDCVerifier) keysFor(kid string) ([]crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := time.Since(v.fetchedAt)
+	_, known := v.keys[kid]
+	if v.keys == nil || age > v.config.CacheTTL || (kid != "" && !known && age > minJWKSRefresh) {
+		if err := v.refresh(); err != nil {
+			return nil, err
+		}
+	}
+
+	if kid == "" {
+		keys := make([]crypto.PublicKey, 0, len(v.keys))
+		for _, key := range v.keys {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+	if key, ok := v.keys[kid]; ok {
+		return []crypto.PublicKey{key}, nil
+	}
+	return nil, nil
+}
+
+// Lädt Discovery (falls nötig) und JWKS, Aufrufer hält v.mu
+func (v *OIDCVerifier) refresh() error {
+	if v.jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(v.config.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return err
+		}
+		if strings.TrimSuffix(discovery.Issuer, "/") != v.config.Issuer || discovery.JWKSURI == "" {
+			return errors.New("discovery passt nicht zum konfigurierten issuer")
+		}
+		v.jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(v.jwksURL, &set); err != nil {
+		return err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // unbekannte Schlüsseltypen überspringen
+		}
+		keys[jwk.KeyID] = key
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
+
+func (v *OIDCVerifier) getJSON(url string, dest interface{}) error {
+	resp, err := v.config.HTTPClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// Öffentlicher Schlüssel im JWK Format (RFC 7517)
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Curve   string `json:"crv"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("ungültiger rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("nicht unterstützte kurve " + k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("punkt liegt nicht auf der kurve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("ungültiger ed25519 schlüssel")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errors.New("nicht unterstützter schlüsseltyp " + k.KeyType)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("ungültiger jwk wert")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// Prüft eine JWS Signatur, das Verfahren muss zum Schlüsseltyp passen ("none" und HMAC werden nie akzeptiert)
+func verifySignature(algorithm string, key crypto.PublicKey, input, signature []byte) bool {
+	var hash crypto.Hash
+	switch algorithm {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	case AlgorithmEdDSA:
+		edKey, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(edKey, input, signature)
+	default:
+		return false
+	}
+
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(algorithm, "RS") && rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// Signatur besteht aus r und s fester Länge
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(algorithm, "ES") || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+	return false
+}
+
+// Issuer eines Tokens ohne Prüfung der Signatur, nur um den zuständigen Verifier zu wählen
+func UnverifiedIssuer(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if decodeSegment(parts[1], &claims) != nil {
+		return ""
+	}
+	return strings.TrimSuffix(claims.Issuer, "/")
+}
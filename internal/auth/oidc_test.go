@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth/oidctest"
+)
+
+func newTestVerifier(issuer *oidctest.Issuer) *OIDCVerifier {
+	return NewOIDCVerifier(OIDCConfig{Issuer: issuer.URL, Audience: oidctest.Audience, HTTPClient: issuer.Client})
+}
+
+// Schlüssel gelten als vor age geladen, als wäre die Zeit seit dem letzten Abruf vergangen
+func (v *OIDCVerifier) age(age time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.fetchedAt = time.Now().Add(-age)
+}
+
+func expectRequests(t *testing.T, issuer *oidctest.Issuer, wantDiscovery, wantJWKS int) {
+	t.Helper()
+	discovery, jwks := issuer.Requests()
+	if discovery != wantDiscovery || jwks != wantJWKS {
+		t.Fatalf("%d Discovery und %d JWKS Abrufe, erwartet %d und %d", discovery, jwks, wantDiscovery, wantJWKS)
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier := newTestVerifier(issuer)
+
+	// Discovery und JWKS werden erst beim ersten Token geladen und danach aus dem Cache verwendet
+	expectRequests(t, issuer, 0, 0)
+	claims := issuer.Claims("anna")
+	claims["email"] = "anna@example.com"
+	claims["email_verified"] = true
+	claims["preferred_username"] = "anna"
+	identity, err := verifier.Verify(issuer.Token(t, "k1", claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Issuer: issuer.URL, Subject: "anna", Email: "anna@example.com", EmailVerified: true, Name: "anna"}
+	if identity != want {
+		t.Fatalf("Verify = %+v, erwartet %+v", identity, want)
+	}
+	expectRequests(t, issuer, 1, 1)
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		wantErr error
+	}{
+		{"gültig", nil, nil},
+		{"aud als Liste", map[string]interface{}{"aud": []string{"andere", oidctest.Audience}}, nil},
+		{"falsche aud", map[string]interface{}{"aud": "andere"}, ErrInvalidToken},
+		{"falscher iss", map[string]interface{}{"iss": "https://idp.example.com"}, ErrInvalidToken},
+		{"ohne sub", map[string]interface{}{"sub": ""}, ErrInvalidToken},
+		{"noch nicht gültig", map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()}, ErrInvalidToken},
+		{"abgelaufen", map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}, ErrTokenExpired},
+		{"ohne exp", map[string]interface{}{"exp": 0}, ErrTokenExpired},
+	}
+	for _, tt := range tests {
+		claims := issuer.Claims("anna")
+		for name, value := range tt.claims {
+			claims[name] = value
+		}
+		if _, err := verifier.Verify(issuer.Token(t, "k1", claims)); err != tt.wantErr {
+			t.Errorf("%s: Verify = %v, erwartet %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// Manipulierte Signatur und fremde Formate
+	token := issuer.Token(t, "k1", issuer.Claims("anna"))
+	for name, tampered := range map[string]string{
+		"Signatur":  token[:len(token)-4] + "AAAA",
+		"kein JWT":  "abc",
+		"leer":      "",
+		"ohne Teil": token[:len(token)-1] + ".",
+	} {
+		if _, err := verifier.Verify(tampered); err != ErrInvalidToken {
+			t.Errorf("%s: Verify = %v, erwartet ErrInvalidToken", name, err)
+		}
+	}
+	expectRequests(t, issuer, 1, 1)
+}
+
+// Bei einer unbekannten kid werden die Schlüssel neu geladen, aber höchstens einmal je minJWKSRefresh
+func TestOIDCKeyRotation(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	verifier := newTestVerifier(issuer)
+	if _, err := verifier.Verify(issuer.Token(t, "k1", issuer.Claims("anna"))); err != nil {
+		t.Fatal(err)
+	}
+	expectRequests(t, issuer, 1, 1)
+
+	// Kurz nach dem Laden bleibt eine unbekannte kid unbekannt, auch wenn der Provider sie inzwischen veröffentlicht
+	issuer.AddKey(t, "k2", true)
+	rotated := issuer.Token(t, "k2", issuer.Claims("anna"))
+	if _, err := verifier.Verify(rotated); err != ErrInvalidToken {
+		t.Fatalf("Verify mit neuer kid = %v, erwartet ErrInvalidToken", err)
+	}
+	expectRequests(t, issuer, 1, 1)
+
+	// Nach minJWKSRefresh wird neu geladen, Discovery nicht erneut
+	verifier.age(2 * minJWKSRefresh)
+	if _, err := verifier.Verify(rotated); err != nil {
+		t.Fatalf("Verify nach Schlüsselwechsel = %v", err)
+	}
+	expectRequests(t, issuer, 1, 2)
+
+	// Viele Tokens mit unbekannter kid lösen keinen weiteren Abruf aus
+	issuer.AddKey(t, "k3", false)
+	for n := 0; n < 5; n++ {
+		if _, err := verifier.Verify(issuer.Token(t, "k3", issuer.Claims("anna"))); err != ErrInvalidToken {
+			t.Fatalf("Verify mit unveröffentlichter kid = %v, erwartet ErrInvalidToken", err)
+		}
+	}
+	expectRequests(t, issuer, 1, 2)
+
+	// Nach Ablauf des Caches werden die Schlüssel auch für bekannte kids neu geladen
+	verifier.age(defaultJWKSCacheTTL + time.Minute)
+	if _, err := verifier.Verify(issuer.Token(t, "k1", issuer.Claims("anna"))); err != nil {
+		t.Fatal(err)
+	}
+	expectRequests(t, issuer, 1, 3)
+}
+
+// Ist der Provider nicht erreichbar, ist der Fehler weder ErrInvalidToken noch ErrTokenExpired
+func TestOIDCProviderUnavailable(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	token := issuer.Token(t, "k1", issuer.Claims("anna"))
+	verifier := NewOIDCVerifier(OIDCConfig{Issuer: issuer.URL, Audience: oidctest.Audience, JWKSURL: issuer.URL + "/fehlt", HTTPClient: issuer.Client})
+
+	_, err := verifier.Verify(token)
+	if err == nil || err == ErrInvalidToken || err == ErrTokenExpired {
+		t.Fatalf("Verify = %v, erwartet Fehler beim Laden der Schlüssel", err)
+	}
+	expectRequests(t, issuer, 0, 0)
+}
@@ -0,0 +1,121 @@
+// Package oidctest stellt einen OpenID Connect Provider für Tests bereit: Discovery und JWKS über einen
+// httptest Server, Tokens werden mit Ed25519 (EdDSA) signiert.
+package oidctest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Erwarteter aud Claim der Tokens, die Issuer.Token ausstellt
+const Audience = "todo-api-test"
+
+// Ein Identity Provider mit Discovery unter {URL}/.well-known/openid-configuration und JWKS unter {URL}/jwks
+type Issuer struct {
+	URL    string // Issuer URL, entspricht dem iss Claim der Tokens
+	Client *http.Client
+
+	mu                sync.Mutex
+	keys              map[string]ed25519.PrivateKey // Schlüssel nach kid
+	published         map[string]bool               // im JWKS veröffentlichte kids
+	discoveryRequests int
+	jwksRequests      int
+}
+
+// Startet den Provider mit einem veröffentlichten Schlüssel "k1", beendet wird er mit dem Test
+func NewIssuer(t *testing.T) *Issuer {
+	t.Helper()
+	issuer := &Issuer{keys: map[string]ed25519.PrivateKey{}, published: map[string]bool{}}
+	issuer.AddKey(t, "k1", true)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		issuer.discoveryRequests++
+		issuer.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.URL, "jwks_uri": issuer.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+		issuer.jwksRequests++
+		keys := []map[string]string{}
+		for kid, key := range issuer.keys {
+			if issuer.published[kid] {
+				keys = append(keys, map[string]string{
+					"kty": "OKP",
+					"crv": "Ed25519",
+					"use": "sig",
+					"kid": kid,
+					"x":   base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+	issuer.Client = server.Client()
+	return issuer
+}
+
+// Erzeugt einen Schlüssel, mit published=false signiert er Tokens, erscheint aber nicht im JWKS
+func (i *Issuer) AddKey(t *testing.T, kid string, published bool) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys[kid] = key
+	i.published[kid] = published
+}
+
+// Anzahl der Abrufe von Discovery und JWKS
+func (i *Issuer) Requests() (discovery, jwks int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.discoveryRequests, i.jwksRequests
+}
+
+// Gültige Claims für subject: iss, sub, aud und exp in einer Stunde, weitere Claims können ergänzt oder überschrieben werden
+func (i *Issuer) Claims(subject string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss": i.URL,
+		"sub": subject,
+		"aud": Audience,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// Signiert claims mit dem Schlüssel kid
+func (i *Issuer) Token(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	i.mu.Lock()
+	key, ok := i.keys[kid]
+	i.mu.Unlock()
+	if !ok {
+		t.Fatalf("oidctest: unbekannter Schlüssel %q", kid)
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "EdDSA", "typ": "JWT", "kid": kid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, []byte(input)))
+}
@@ -0,0 +1,28 @@
+package auth
+
+import "context"
+
+// Der angemeldete Benutzer eines Requests
+type Principal struct {
+	UserID int
+	KeyID  int      // API Key, mit dem sich der Benutzer angemeldet hat
+	Scopes []string // Berechtigungen dieses Keys
+}
+
+// Prüft ob der Key die Berechtigung scope besitzt
+func (p Principal) HasScope(scope string) bool {
+	return HasScope(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// Legt den angemeldeten Benutzer im Context ab
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// Liefert den angemeldeten Benutzer aus dem Context, ok ist false ohne Anmeldung
+func FromContext(ctx context.Context) (principal Principal, ok bool) {
+	principal, ok = ctx.Value(contextKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,54 @@
+package auth
+
+import "strings"
+
+// Berechtigungen eines API Keys
+const (
+	ScopeRead  = "read"  // ToDos, Tags und Profil lesen
+	ScopeWrite = "write" // ToDos und Tags anlegen, ändern und abschließen
+	ScopeShare = "share" // ToDos mit anderen Benutzern teilen
+	ScopeAdmin = "admin" // alle Berechtigungen inkl. Löschen, Profil und API Keys
+)
+
+// Alle Scopes in der Reihenfolge, in der sie ausgegeben und gespeichert werden
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeShare, ScopeAdmin}
+
+// Prüft und sortiert eine Liste von Scopes, doppelte Einträge werden entfernt.
+// ok ist false bei einem unbekannten Scope.
+func NormalizeScopes(scopes []string) (normalized []string, ok bool) {
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !validScope(scope) {
+			return nil, false
+		}
+		seen[scope] = true
+	}
+
+	normalized = []string{}
+	for _, scope := range Scopes {
+		if seen[scope] {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, true
+}
+
+// Prüft ob scope in granted enthalten ist, admin umfasst alle Scopes
+func HasScope(granted []string, scope string) bool {
+	for _, g := range granted {
+		if g == scope || g == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func validScope(scope string) bool {
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Platzhalter-Hash für unbekannte Benutzer, damit die Prüfung gleich lange dauert wie bei einem echten Benutzer
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// Erzeugt einen neuen zufälligen Secret Key (256 Bit, base64url)
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Schlüssel zum Auffinden des Benutzers zu einem Secret Key (SHA-256, hex). Der bcrypt Hash ist gesalzen und
+// kann daher nicht gesucht werden, der Lookup grenzt die Kandidaten ein, geprüft wird weiterhin mit VerifySecret.
+func LookupKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Erstellt einen gesalzenen Hash (bcrypt) für einen Secret Key
+func HashSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// Prüft einen Secret Key gegen den gespeicherten Wert. Ältere Datenbanken enthalten noch Klartext, dieser
+// wird in konstanter Zeit verglichen und rehash ist true, damit der Aufrufer ihn durch einen Hash ersetzt.
+// Ein leerer gespeicherter Wert (unbekannter Benutzer) schlägt immer fehl.
+func VerifySecret(stored, secret string) (ok, rehash bool) {
+	if stored == "" {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+		})
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(secret))
+		return false, false
+	}
+
+	if !isHash(stored) {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(secret)) == 1
+		return ok, ok
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(secret)) == nil, false
+}
+
+// Erkennt ob ein gespeicherter Wert bereits ein bcrypt Hash ist
+func isHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
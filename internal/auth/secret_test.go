@@ -0,0 +1,43 @@
+package auth
+
+import "testing"
+
+func TestVerifySecret(t *testing.T) {
+	hash, err := HashSecret("geheim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "geheim" || !isHash(hash) {
+		t.Fatalf("HashSecret = %q, erwartet bcrypt Hash", hash)
+	}
+
+	tests := []struct {
+		name       string
+		stored     string
+		secret     string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{"Hash", hash, "geheim", true, false},
+		{"Hash falscher Key", hash, "falsch", false, false},
+		{"Klartext", "geheim", "geheim", true, true},
+		{"Klartext falscher Key", "geheim", "falsch", false, false},
+		{"Präfix des Klartexts", "geheim", "geh", false, false},
+		{"unbekannter Benutzer", "", "", false, false},
+	}
+	for _, tt := range tests {
+		ok, rehash := VerifySecret(tt.stored, tt.secret)
+		if ok != tt.wantOK || rehash != tt.wantRehash {
+			t.Errorf("%s: VerifySecret = %v, %v, erwartet %v, %v", tt.name, ok, rehash, tt.wantOK, tt.wantRehash)
+		}
+	}
+}
+
+func TestLookupKey(t *testing.T) {
+	if LookupKey("a") != LookupKey("a") || LookupKey("a") == LookupKey("b") {
+		t.Fatal("LookupKey ist nicht eindeutig")
+	}
+	if len(LookupKey("a")) != 64 {
+		t.Fatalf("LookupKey = %q, erwartet SHA-256 hex", LookupKey("a"))
+	}
+}
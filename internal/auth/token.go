@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signaturverfahren der Access Tokens
+const (
+	AlgorithmHS256 = "HS256" // HMAC-SHA256 mit gemeinsamem Schlüssel
+	AlgorithmEdDSA = "EdDSA" // Ed25519, geprüft wird nur mit dem öffentlichen Schlüssel
+)
+
+const (
+	tokenIssuer     = "todo-api" // Wert des iss Claims
+	minHMACKeyBytes = 32         // Mindestlänge eines HS256 Schlüssels
+)
+
+var (
+	ErrInvalidToken = errors.New("ungültiger token")
+	ErrTokenExpired = errors.New("token abgelaufen")
+)
+
+// Header und Claims eines Access Tokens
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid,omitempty"` // nur bei Tokens eines OIDC Providers
+}
+
+type tokenClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`    // UserID
+	KeyID     int    `json:"key_id"` // API Key, mit dem der Token ausgestellt wurde
+	Scope     string `json:"scope"`  // Scopes durch Leerzeichen getrennt
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Stellt signierte Access Tokens (JWT) aus und prüft sie ohne Zugriff auf die Datenbank
+type TokenIssuer struct {
+	algorithm  string
+	hmacKey    []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// Tokens mit HS256, key muss mindestens 32 Byte lang sein
+func NewHS256Issuer(key []byte) (*TokenIssuer, error) {
+	if len(key) < minHMACKeyBytes {
+		return nil, errors.New("HS256 Schlüssel muss mindestens " + strconv.Itoa(minHMACKeyBytes) + " Byte lang sein")
+	}
+	return &TokenIssuer{algorithm: AlgorithmHS256, hmacKey: key}, nil
+}
+
+// Tokens mit EdDSA (Ed25519)
+func NewEdDSAIssuer(key ed25519.PrivateKey) *TokenIssuer {
+	return &TokenIssuer{algorithm: AlgorithmEdDSA, privateKey: key, publicKey: key.Public().(ed25519.PublicKey)}
+}
+
+// Tokens mit einem zufälligen HS256 Schlüssel, gültig nur bis zum Neustart des Prozesses
+func NewRandomIssuer() (*TokenIssuer, error) {
+	key := make([]byte, minHMACKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return NewHS256Issuer(key)
+}
+
+// Lädt den Schlüssel aus einer Datei: ein Ed25519 Private Key im PEM Format (PKCS #8) ergibt EdDSA,
+// jeder andere Inhalt wird als HS256 Schlüssel verwendet
+func LoadTokenIssuer(path string) (*TokenIssuer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("nur Ed25519 Schlüssel werden unterstützt")
+		}
+		return NewEdDSAIssuer(privateKey), nil
+	}
+
+	return NewHS256Issuer(bytes.TrimSpace(data))
+}
+
+// Verwendetes Signaturverfahren
+func (t *TokenIssuer) Algorithm() string {
+	return t.algorithm
+}
+
+// Stellt einen Access Token für principal aus, gültig für ttl
+func (t *TokenIssuer) Issue(principal Principal, ttl time.Duration) (string, error) {
+	now := time.Now()
+	header, err := json.Marshal(tokenHeader{Algorithm: t.algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(tokenClaims{
+		Issuer:    tokenIssuer,
+		Subject:   strconv.Itoa(principal.UserID),
+		KeyID:     principal.KeyID,
+		Scope:     strings.Join(principal.Scopes, " "),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(header) + "." + encodeSegment(claims)
+	return signingInput + "." + encodeSegment(t.sign([]byte(signingInput))), nil
+}
+
+// Prüft Signatur, Aussteller und Ablauf eines Access Tokens und liefert den angemeldeten Benutzer.
+// Das Verfahren im Header muss dem konfigurierten entsprechen, "none" oder ein anderes Verfahren wird abgelehnt.
+func (t *TokenIssuer) Verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Algorithm != t.algorithm {
+		return Principal{}, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !t.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Issuer != tokenIssuer {
+		return Principal{}, ErrInvalidToken
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	if !time.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return Principal{}, ErrTokenExpired
+	}
+
+	return Principal{UserID: userID, KeyID: claims.KeyID, Scopes: strings.Fields(claims.Scope)}, nil
+}
+
+func (t *TokenIssuer) sign(input []byte) []byte {
+	if t.algorithm == AlgorithmEdDSA {
+		return ed25519.Sign(t.privateKey, input)
+	}
+	mac := hmac.New(sha256.New, t.hmacKey)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
+
+func (t *TokenIssuer) verify(input, signature []byte) bool {
+	if t.algorithm == AlgorithmEdDSA {
+		return ed25519.Verify(t.publicKey, input, signature)
+	}
+	return hmac.Equal(t.sign(input), signature)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -3,26 +3,84 @@ package database
 import (
 	"database/sql"
 	"log"
+	"strconv"
+	"strings"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Unterstützte Datenbanksysteme
+const (
+    DialectSQLite   = "sqlite"
+    DialectPostgres = "postgres"
+)
+
 type Database struct { // Datenbankverbindung
-    Connection *sql.DB // Zeiger auf sql.DB-Instanz, die die Verbindung zur Datenbank enthält
+    Connection     *sql.DB // Zeiger auf sql.DB-Instanz, die die Verbindung zur Datenbank enthält
+    Dialect        string  // SQL-Dialekt der Verbindung (DialectSQLite oder DialectPostgres)
+    FullTextSearch bool    // Volltextindex vorhanden, unter SQLite nur mit FTS5 im Treiber (Build-Tag sqlite_fts5)
 }
 
+// Öffnet die Datenbank anhand des DSN: "postgres://..." bzw. "postgresql://..." für PostgreSQL, alles andere ist ein SQLite-Dateipfad
 func NewDatabase(dataSourceName string) *Database {
-    db, err := sql.Open("sqlite3", dataSourceName) // Öffnen der Datenbankverbindung
+    driver, dialect := "sqlite3", DialectSQLite
+    if strings.HasPrefix(dataSourceName, "postgres://") || strings.HasPrefix(dataSourceName, "postgresql://") {
+        driver, dialect = "postgres", DialectPostgres
+    }
+
+    db, err := sql.Open(driver, dataSourceName) // Öffnen der Datenbankverbindung
     if err != nil {
         log.Fatal(err)
     }
 
-    return &Database{	// Rückgabe einer Database Instanz
+    database := &Database{	// Database Instanz erstellen
         Connection: db, // Zeiger auf offene Datenbankverbindung setzen
+        Dialect:    dialect,
+    }
+
+    err = database.Migrate() // Schema anlegen bzw. auf den neuesten Stand bringen
+    if err != nil {
+        log.Fatal(err)
     }
+
+    return database // Rückgabe der Database Instanz
+}
+
+// Wandelt eine im SQLite-Stil geschriebene Abfrage (? als Platzhalter, `order` als Bezeichner)
+// in den Dialekt der Verbindung um. Für PostgreSQL werden daraus $1, $2, ... und "order".
+// Text in einfachen Anführungszeichen ('...', z.B. LIKE-Muster) und Bezeichner in doppelten bleiben unverändert.
+func (db *Database) Rebind(query string) string {
+    if db.Dialect != DialectPostgres {
+        return query
+    }
+
+    var b strings.Builder
+    n := 0
+    var quote rune // offenes Anführungszeichen, 0 außerhalb von Text und Bezeichnern
+    for _, c := range query {
+        switch {
+        case quote != 0:
+            if c == quote {
+                quote = 0 // ein verdoppeltes Zeichen ('') öffnet direkt wieder
+            }
+            b.WriteRune(c)
+        case c == '\'' || c == '"':
+            quote = c
+            b.WriteRune(c)
+        case c == '?':
+            n++
+            b.WriteString("$" + strconv.Itoa(n))
+        case c == '`':
+            b.WriteRune('"')
+        default:
+            b.WriteRune(c)
+        }
+    }
+    return b.String()
 }
 
 // Methode zum schließen der Datanbankverbindung
 func (db *Database) Close() {
     db.Connection.Close()
-}
\ No newline at end of file
+}
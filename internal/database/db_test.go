@@ -0,0 +1,288 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	postgres := &Database{Dialect: DialectPostgres}
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"Platzhalter", "SELECT id FROM todos WHERE user_id = ? AND id = ?", "SELECT id FROM todos WHERE user_id = $1 AND id = $2"},
+		{"Bezeichner", "UPDATE todos SET `order` = `order` + 1 WHERE id = ?", `UPDATE todos SET "order" = "order" + 1 WHERE id = $1`},
+		{"Text", "SELECT ? FROM todos WHERE title LIKE 'Was?%' AND description = '`x`' AND id = ?", "SELECT $1 FROM todos WHERE title LIKE 'Was?%' AND description = '`x`' AND id = $2"},
+		{"verdoppeltes Anführungszeichen", "SELECT 'it''s ?' WHERE id = ?", "SELECT 'it''s ?' WHERE id = $1"},
+		{"Bezeichner in doppelten Anführungszeichen", `SELECT "wer?" FROM todos WHERE id = ?`, `SELECT "wer?" FROM todos WHERE id = $1`},
+		{"ts_headline", "SELECT ts_headline('simple', title, q, 'StartSel=<mark>, StopSel=</mark>') WHERE x = ?", "SELECT ts_headline('simple', title, q, 'StartSel=<mark>, StopSel=</mark>') WHERE x = $1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgres.Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind(%q)\n = %q\nerwartet %q", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	// SQLite verwendet die Abfrage unverändert
+	sqlite := &Database{Dialect: DialectSQLite}
+	query := "SELECT `order` FROM todos WHERE id = ? AND title = 'a?'"
+	if got := sqlite.Rebind(query); got != query {
+		t.Errorf("Rebind für SQLite = %q", got)
+	}
+}
+
+// Öffnet eine leere SQLite-Datei, ohne sie zu migrieren
+func openSQLite(t *testing.T) *Database {
+	t.Helper()
+	connection, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "todo_app_db.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	return &Database{Connection: connection, Dialect: DialectSQLite}
+}
+
+// Führt die Migrationen bis einschließlich upTo aus, ohne sie in schema_migrations zu vermerken.
+// So entsteht eine Datenbank wie vor dem Migrationssystem bzw. eine ältere Version des Schemas.
+func execMigrations(t *testing.T, db *Database, upTo int) {
+	t.Helper()
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullText, err := db.fullTextAvailable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		if m.Version > upTo || (m.Requires == featureFTS5 && !fullText) {
+			continue
+		}
+		if _, err := db.Connection.Exec(m.SQL); err != nil {
+			t.Fatalf("%s: %v", m.Name, err)
+		}
+	}
+}
+
+// Vermerkt die Migrationen bis einschließlich upTo als angewendet
+func stampMigrations(t *testing.T, db *Database, upTo int) {
+	t.Helper()
+	_, err := db.Connection.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		if m.Version > upTo {
+			break
+		}
+		_, err = db.Connection.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Führt eine Abfrage mit einer Spalte aus und liefert alle Werte als Text
+func queryColumn(t *testing.T, db *Database, query string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := db.Connection.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func expectColumn(t *testing.T, db *Database, want []string, query string, args ...interface{}) {
+	t.Helper()
+	if got := queryColumn(t, db, query, args...); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s\n = %q\nerwartet %q", query, got, want)
+	}
+}
+
+// Alle Migrationen, die der Treiber ausführen kann, sind vermerkt
+func expectAllApplied(t *testing.T, db *Database) {
+	t.Helper()
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{}
+	for _, m := range migrations {
+		if m.Requires != featureFTS5 || db.FullTextSearch {
+			want = append(want, m.Name)
+		}
+	}
+	expectColumn(t, db, want, "SELECT name FROM schema_migrations ORDER BY version")
+}
+
+func TestMigrateEmpty(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	expectAllApplied(t, db)
+	for _, table := range []string{"users", "todos", "todo_members", "share_invitations", "group_shares", "lists", "checklist_items"} {
+		if exists, err := db.tableExists(table); err != nil || !exists {
+			t.Errorf("Tabelle %s existiert nicht: %v", table, err)
+		}
+	}
+	if exists, err := db.columnExists("todos", "original_todo_id"); err != nil || exists {
+		t.Errorf("todos.original_todo_id nach 0015 vorhanden: %v", err)
+	}
+}
+
+// Ein zweiter Start führt keine Migration erneut aus
+func TestMigrateTwice(t *testing.T) {
+	db := openSQLite(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	applied := queryColumn(t, db, "SELECT version || ' ' || applied_at FROM schema_migrations ORDER BY version")
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("zweites Migrate: %v", err)
+	}
+	expectColumn(t, db, applied, "SELECT version || ' ' || applied_at FROM schema_migrations ORDER BY version")
+}
+
+// Von Hand angelegte Datenbanken ohne schema_migrations übernehmen 0001 bzw. 0001 und 0002
+func TestBaselineLegacySchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		upTo   int
+		stamps []string
+	}{
+		{"ohne original_todo_id", 1, []string{"1"}},
+		{"mit original_todo_id", 2, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSQLite(t)
+			execMigrations(t, db, tt.upTo)
+			_, err := db.Connection.Exec("INSERT INTO todos (user_id, title, description, category, `order`, created_at, updated_at, completed) VALUES (1, 'Alt', '', 'no category', 1, ?, ?, false)", time.Now(), time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Die übernommenen Versionen werden vermerkt, aber nicht ausgeführt
+			_, err = db.Connection.Exec("CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME NOT NULL)")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.baselineLegacySchema(); err != nil {
+				t.Fatal(err)
+			}
+			expectColumn(t, db, tt.stamps, "SELECT version FROM schema_migrations ORDER BY version")
+
+			// Ab 0003 laufen die Migrationen normal, die ToDo bleibt erhalten
+			if err := db.Migrate(); err != nil {
+				t.Fatal(err)
+			}
+			expectAllApplied(t, db)
+			expectColumn(t, db, []string{"Alt"}, "SELECT title FROM todos")
+		})
+	}
+}
+
+// 0015 macht aus geteilten Kopien Mitgliedschaften an der ursprünglichen ToDo
+func TestMigrateSharedCopies(t *testing.T) {
+	db := openSQLite(t)
+	execMigrations(t, db, 2)
+	for _, key := range []string{"anna", "ben", "carla"} {
+		if _, err := db.Connection.Exec("INSERT INTO users (secret_key) VALUES (?)", key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	for _, todo := range []struct {
+		userID, order, originalID int
+		title, category           string
+	}{
+		{1, 1, 0, "Umzug", "Familie"},
+		{2, 1, 1, "Umzug", "shared"},     // Kopie -> Mitglied
+		{3, 1, 2, "Umzug", "Nachbarn"},   // Kopie einer Kopie -> Mitglied am Original
+		{2, 2, 1, "Umzug", "shared"},     // zweite Kopie desselben Originals -> eigenständig
+		{2, 3, 99, "Gelöscht", "shared"}, // Original gelöscht -> eigenständig
+		{1, 2, 1, "Eigene", "shared"},    // Kopie beim selben Benutzer -> eigenständig
+	} {
+		_, err := db.Connection.Exec("INSERT INTO todos (user_id, title, description, category, `order`, created_at, updated_at, completed, original_todo_id) VALUES (?, ?, '', ?, ?, ?, ?, false, ?)",
+			todo.userID, todo.title, todo.category, todo.order, now, now, todo.originalID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	expectColumn(t, db, []string{"1 1 Umzug", "4 2 Umzug", "5 2 Gelöscht", "6 1 Eigene"}, "SELECT id || ' ' || user_id || ' ' || title FROM todos ORDER BY id")
+	expectColumn(t, db, []string{"1 2 complete shared 1", "1 3 complete Nachbarn 1"},
+		"SELECT todo_id || ' ' || user_id || ' ' || permission || ' ' || category || ' ' || `order` FROM todo_members ORDER BY todo_id, user_id")
+}
+
+// 0019 macht aus jeder mit einer Gruppe geteilten Kategorie eine Liste ihres Eigentümers
+func TestMigrateCategoryGroupShares(t *testing.T) {
+	db := openSQLite(t)
+	execMigrations(t, db, 18)
+	stampMigrations(t, db, 18)
+	now := time.Now()
+	for _, statement := range []string{
+		"INSERT INTO users (display_name, created_at) VALUES ('Anna', ?), ('Ben', ?)",
+		"INSERT INTO groups (name, created_at) VALUES ('Team', ?)",
+		"INSERT INTO group_members (group_id, user_id, role, created_at) VALUES (1, 1, 'owner', ?), (1, 2, 'member', ?)",
+		"INSERT INTO group_shares (group_id, owner_id, category, permission, created_at) VALUES (1, 1, 'Garten', 'view', ?)",
+	} {
+		args := []interface{}{}
+		for i := 0; i < strings.Count(statement, "?"); i++ {
+			args = append(args, now)
+		}
+		if _, err := db.Connection.Exec(statement, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, todo := range []struct {
+		userID, order   int
+		title, category string
+	}{
+		{1, 1, "Rasen", "Garten"},
+		{1, 2, "Steuer", "no category"},
+		{1, 3, "Hecke", "Garten"},
+		{2, 1, "Beet", "Garten"}, // gleiche Kategorie eines anderen Benutzers bleibt ohne Liste
+	} {
+		_, err := db.Connection.Exec("INSERT INTO todos (user_id, title, description, category, `order`, created_at, updated_at, completed) VALUES (?, ?, '', ?, ?, ?, ?, false)",
+			todo.userID, todo.title, todo.category, todo.order, now, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	expectColumn(t, db, []string{"1 1 Garten"}, "SELECT id || ' ' || user_id || ' ' || name FROM lists")
+	expectColumn(t, db, []string{"1 "}, "SELECT list_id || ' ' || COALESCE(category, '') FROM group_shares")
+	expectColumn(t, db, []string{"Rasen 1 1", "Steuer 0 1", "Hecke 1 2", "Beet 0 1"}, "SELECT title || ' ' || list_id || ' ' || `order` FROM todos ORDER BY id")
+}
@@ -0,0 +1,295 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS // SQL-Dateien der Migrationen je Dialekt, werden in das Binary eingebettet
+
+type migration struct { // Eine einzelne Schemaänderung
+	Version  int    // Versionsnummer aus dem Dateinamen (0001_... -> 1)
+	Name     string // Dateiname der Migration
+	SQL      string // auszuführende SQL Befehle
+	Requires string // optionale Fähigkeit des Treibers aus der Zeile "-- requires: ...", z.B. featureFTS5
+}
+
+// Fähigkeiten des SQLite-Treibers, die nicht in jedem Build enthalten sind
+const featureFTS5 = "fts5" // Volltextsuche, nur mit dem Build-Tag sqlite_fts5
+
+// Liest die vorausgesetzte Fähigkeit aus einer Zeile "-- requires: <name>" der Migration
+func migrationRequires(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "-- requires:"); found {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// Lädt alle eingebetteten Migrationen eines Dialekts sortiert nach Version
+func loadMigrations(dialect string) ([]migration, error) {
+	dir := "migrations/" + dialect
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		// Version aus dem Präfix des Dateinamens lesen
+		prefix, _, found := strings.Cut(entry.Name(), "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s: Dateiname ohne Versionspräfix", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: ungültige Version: %w", entry.Name(), err)
+		}
+
+		content, err := migrationFiles.ReadFile(dir + "/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{Version: version, Name: entry.Name(), SQL: string(content), Requires: migrationRequires(string(content))})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	// Doppelte Versionen würden die Reihenfolge uneindeutig machen
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %s: Version %d doppelt vergeben", migrations[i].Name, migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Bringt das Datenbankschema auf den neuesten Stand. Jede Migration läuft in einer eigenen Transaktion
+// und wird in der Tabelle schema_migrations vermerkt, damit sie nur einmal ausgeführt wird.
+func (db *Database) Migrate() error {
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		return err
+	}
+
+	timestampType := "DATETIME"
+	if db.Dialect == DialectPostgres {
+		timestampType = "TIMESTAMPTZ"
+	}
+	_, err = db.Connection.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at " + timestampType + " NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	// Datenbanken, die vor dem Migrationssystem von Hand angelegt wurden, übernehmen
+	err = db.baselineLegacySchema()
+	if err != nil {
+		return err
+	}
+
+	// Ohne FTS5 sucht der Store per LIKE, der Volltextindex wird erst von einem Build mit FTS5 angelegt
+	db.FullTextSearch, err = db.fullTextAvailable()
+	if err != nil {
+		return err
+	}
+
+	applied, err := db.appliedVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Requires == featureFTS5 && !db.FullTextSearch {
+			if applied[m.Version] {
+				err = db.disableFullTextIndex(m)
+				if err != nil {
+					return err
+				}
+			}
+			log.Printf("Migration %s übersprungen: SQLite-Treiber ohne FTS5, die Suche verwendet LIKE", m.Name)
+			continue
+		}
+		if applied[m.Version] {
+			continue
+		}
+
+		tx, err := db.Connection.Begin()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(m.SQL)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+
+		_, err = tx.Exec(db.Rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"), m.Version, m.Name, time.Now())
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+
+		log.Printf("Migration %s angewendet", m.Name)
+	}
+
+	return nil
+}
+
+// Prüft ob Volltextsuche verfügbar ist: unter PostgreSQL immer, unter SQLite nur wenn der Treiber mit FTS5 gebaut wurde
+func (db *Database) fullTextAvailable() (bool, error) {
+	if db.Dialect != DialectSQLite {
+		return true, nil
+	}
+	var enabled bool
+	err := db.Connection.QueryRow("SELECT EXISTS(SELECT 1 FROM pragma_compile_options WHERE compile_options = 'ENABLE_FTS5')").Scan(&enabled)
+	return enabled, err
+}
+
+// Entfernt die Trigger des Volltextindex, den ein früherer Build mit FTS5 angelegt hat. Ohne FTS5 würden sie jedes
+// Schreiben in todos verhindern. Die Migration gilt danach als nicht angewendet, ein Build mit FTS5 baut den Index
+// beim nächsten Start neu auf.
+func (db *Database) disableFullTextIndex(m migration) error {
+	rows, err := db.Connection.Query("SELECT name FROM sqlite_master WHERE type = 'trigger' AND tbl_name = 'todos' AND name LIKE 'todos_fts_%'")
+	if err != nil {
+		return err
+	}
+	triggers := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		triggers = append(triggers, name)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := db.Connection.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, name := range triggers {
+		_, err = tx.Exec("DROP TRIGGER IF EXISTS " + name)
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+	}
+	_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Liefert die Versionen aller bereits angewendeten Migrationen
+func (db *Database) appliedVersions() (map[int]bool, error) {
+	rows, err := db.Connection.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// Vermerkt die Migrationen als angewendet, deren Änderungen in einer alten Datenbank ohne
+// schema_migrations bereits von Hand vorgenommen wurden (todos existiert, evtl. mit original_todo_id).
+// Betrifft nur SQLite, PostgreSQL-Datenbanken wurden von Anfang an über Migrationen angelegt.
+func (db *Database) baselineLegacySchema() error {
+	if db.Dialect != DialectSQLite {
+		return nil
+	}
+
+	var count int
+	err := db.Connection.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil // Migrationssystem ist bereits im Einsatz
+	}
+
+	todosExists, err := db.tableExists("todos")
+	if err != nil || !todosExists {
+		return err // leere Datenbank -> alle Migrationen normal ausführen
+	}
+
+	versions := []int{1}
+	hasOriginalID, err := db.columnExists("todos", "original_todo_id")
+	if err != nil {
+		return err
+	}
+	if hasOriginalID {
+		versions = append(versions, 2)
+	}
+
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		return err
+	}
+	names := map[int]string{}
+	for _, m := range migrations {
+		names[m.Version] = m.Name
+	}
+
+	for _, version := range versions {
+		_, err = db.Connection.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", version, names[version], time.Now())
+		if err != nil {
+			return err
+		}
+		log.Printf("Bestehendes Schema übernommen: %s", names[version])
+	}
+
+	return nil
+}
+
+// Prüft ob eine Tabelle existiert
+func (db *Database) tableExists(table string) (bool, error) {
+	var exists bool
+	err := db.Connection.QueryRow("SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", table).Scan(&exists)
+	return exists, err
+}
+
+// Prüft ob eine Spalte in einer Tabelle existiert
+func (db *Database) columnExists(table, column string) (bool, error) {
+	var exists bool
+	err := db.Connection.QueryRow("SELECT EXISTS(SELECT 1 FROM pragma_table_info(?) WHERE name = ?)", table, column).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return exists, err
+}
@@ -0,0 +1,17 @@
+-- Grundschema: Benutzer und ToDos
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    secret_key TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS todos (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    category TEXT,
+    "order" INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    completed BOOLEAN NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Verweis einer geteilten Kopie auf die ursprüngliche ToDo (0 = keine Kopie)
+ALTER TABLE todos ADD COLUMN original_todo_id INTEGER NOT NULL DEFAULT 0;
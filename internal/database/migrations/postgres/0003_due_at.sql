@@ -0,0 +1,3 @@
+-- Optionaler Fälligkeitszeitpunkt einer ToDo
+ALTER TABLE todos ADD COLUMN due_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_todos_user_due ON todos (user_id, due_at);
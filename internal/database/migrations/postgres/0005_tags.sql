@@ -0,0 +1,24 @@
+-- Tags je Benutzer und Zuordnung zu ToDos (n:m)
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    name TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_tags_tag ON todo_tags (tag_id);
+
+-- Bisherige Kategorien als Tags übernehmen (Platzhalter "no category" und "shared" ausgenommen)
+INSERT INTO tags (user_id, name)
+SELECT DISTINCT user_id, category FROM todos
+WHERE category IS NOT NULL AND category NOT IN ('', 'no category', 'shared');
+
+INSERT INTO todo_tags (todo_id, tag_id)
+SELECT todos.id, tags.id FROM todos
+JOIN tags ON tags.user_id = todos.user_id AND tags.name = todos.category;
@@ -0,0 +1,4 @@
+-- Indizes für Sortierung und Pagination der ToDo-Liste
+CREATE INDEX IF NOT EXISTS idx_todos_user_order ON todos (user_id, "order");
+CREATE INDEX IF NOT EXISTS idx_todos_user_created ON todos (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_todos_user_updated ON todos (user_id, updated_at);
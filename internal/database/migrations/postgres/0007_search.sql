@@ -0,0 +1,8 @@
+-- Volltextsuche über Titel und Beschreibung. Der Suchvektor wird von PostgreSQL selbst aktuell gehalten,
+-- der Titel ist höher gewichtet (A) als die Beschreibung (B).
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_todos_search ON todos USING GIN (search_vector);
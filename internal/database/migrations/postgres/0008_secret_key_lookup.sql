@@ -0,0 +1,4 @@
+-- Lookup (SHA-256) zum Auffinden des Benutzers anhand seines Secret Keys, wird beim ersten Zugriff gesetzt
+ALTER TABLE users ADD COLUMN IF NOT EXISTS secret_key_lookup TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_users_secret_key_lookup ON users (secret_key_lookup);
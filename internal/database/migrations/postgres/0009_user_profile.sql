@@ -0,0 +1,12 @@
+-- Profil der Benutzer: Anzeigename, E-Mail (eindeutig, optional) und Zeitpunkt der Registrierung
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+-- Bestehende Benutzer: Zeitpunkt der ersten ToDo, sonst jetzt
+UPDATE users SET created_at = COALESCE(
+    (SELECT MIN(created_at) FROM todos WHERE todos.user_id = users.id),
+    NOW()
+);
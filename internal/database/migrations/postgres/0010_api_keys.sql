@@ -0,0 +1,24 @@
+-- Mehrere benannte API Keys je Benutzer, ersetzt die Spalte users.secret_key
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL,               -- bcrypt Hash, bei übernommenen Keys evtl. noch Klartext
+    key_lookup TEXT NOT NULL DEFAULT '',  -- SHA-256 zum Auffinden, leer bis zum ersten Zugriff
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_lookup ON api_keys (key_lookup);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys (user_id);
+
+-- Bisherige Secret Keys als Key "default" übernehmen
+INSERT INTO api_keys (user_id, name, key_hash, key_lookup, created_at)
+SELECT id, 'default', secret_key, COALESCE(secret_key_lookup, ''), COALESCE(created_at, NOW())
+FROM users WHERE secret_key != '';
+
+DROP INDEX IF EXISTS idx_users_secret_key_lookup;
+ALTER TABLE users DROP COLUMN secret_key_lookup;
+ALTER TABLE users DROP COLUMN secret_key;
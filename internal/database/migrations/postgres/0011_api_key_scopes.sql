@@ -0,0 +1,2 @@
+-- Berechtigungen je API Key, durch Leerzeichen getrennt. Bestehende Keys behalten vollen Zugriff.
+ALTER TABLE api_keys ADD COLUMN scopes TEXT NOT NULL DEFAULT 'admin';
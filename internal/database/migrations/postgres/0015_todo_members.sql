@@ -0,0 +1,43 @@
+-- Geteilte ToDos existieren nur noch einmal, die Empfänger werden Mitglieder der ToDo.
+-- Berechtigung, Kategorie und Position (order) gelten je Mitglied.
+CREATE TABLE IF NOT EXISTS todo_members (
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    permission TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT 'shared',
+    "order" INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (todo_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_members_user_order ON todo_members (user_id, "order");
+
+-- Kopien von Kopien auf das Original der Kopie verweisen lassen
+UPDATE todos SET original_todo_id = (SELECT parent.original_todo_id FROM todos parent WHERE parent.id = todos.original_todo_id)
+WHERE original_todo_id IN (SELECT id FROM todos WHERE original_todo_id != 0);
+
+-- Kopien ohne gültiges Original (gelöscht, selbst eine Kopie oder vom selben Benutzer) sowie jede weitere
+-- Kopie desselben Originals beim selben Benutzer bleiben als eigenständige ToDos erhalten
+UPDATE todos SET original_todo_id = 0
+WHERE original_todo_id != 0 AND NOT EXISTS (
+    SELECT 1 FROM todos original
+    WHERE original.id = todos.original_todo_id AND original.original_todo_id = 0 AND original.user_id != todos.user_id
+);
+UPDATE todos SET original_todo_id = 0
+WHERE original_todo_id != 0 AND id != (
+    SELECT MIN(other.id) FROM todos other WHERE other.original_todo_id = todos.original_todo_id AND other.user_id = todos.user_id
+);
+
+-- Verbleibende Kopien werden zu Mitgliedschaften, ihre Tags wandern an das Original
+INSERT INTO todo_members (todo_id, user_id, permission, category, "order", created_at)
+SELECT original_todo_id, user_id, CASE WHEN share_permission = '' THEN 'complete' ELSE share_permission END,
+    COALESCE(category, 'shared'), "order", created_at
+FROM todos WHERE original_todo_id != 0;
+
+UPDATE todo_tags SET todo_id = (SELECT copy.original_todo_id FROM todos copy WHERE copy.id = todo_tags.todo_id)
+WHERE todo_id IN (SELECT id FROM todos WHERE original_todo_id != 0);
+
+DELETE FROM todos WHERE original_todo_id != 0;
+
+ALTER TABLE todos DROP COLUMN share_permission;
+ALTER TABLE todos DROP COLUMN original_todo_id;
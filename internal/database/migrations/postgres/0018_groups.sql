@@ -0,0 +1,33 @@
+-- Gruppen (Teams) von Benutzern, ToDos und ganze Listen können mit einer Gruppe geteilt werden
+CREATE TABLE IF NOT EXISTS groups (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role TEXT NOT NULL,                 -- owner, admin oder member
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_members_user ON group_members (user_id);
+
+-- Freigabe einer ToDo (todo_id) oder aller ToDos einer Kategorie des Eigentümers (category) für alle Mitglieder einer Gruppe.
+-- Seit 0019 verweisen Freigaben einer Liste auf list_id, category ist dann immer NULL.
+CREATE TABLE IF NOT EXISTS group_shares (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL,
+    owner_id INT NOT NULL,
+    todo_id INT,
+    category TEXT,
+    permission TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (group_id, todo_id),
+    UNIQUE (group_id, owner_id, category)
+);
+
+-- Mitgliedschaften, die über eine Gruppe bestehen, werden mit der Gruppe abgeglichen, direkte Freigaben haben keine group_id
+ALTER TABLE todo_members ADD COLUMN group_id INT;
@@ -0,0 +1,11 @@
+-- Offene Einladungen in Gruppen, der Empfänger wird erst mit der Annahme Mitglied (group_members)
+CREATE TABLE IF NOT EXISTS group_invitations (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role TEXT NOT NULL,                 -- admin oder member, gilt ab der Annahme
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_invitations_user ON group_invitations (user_id);
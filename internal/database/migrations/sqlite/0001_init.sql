@@ -0,0 +1,17 @@
+-- Grundschema: Benutzer und ToDos
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    secret_key TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS todos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    category TEXT,
+    `order` INT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    completed BOOLEAN NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Priorität einer ToDo (0 = none, 1 = low, 2 = medium, 3 = high, 4 = urgent)
+ALTER TABLE todos ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_todos_user_priority ON todos (user_id, priority);
@@ -0,0 +1,10 @@
+-- Zeitpunkte einheitlich in UTC speichern, damit Vergleiche und Sortierung auf dem Text stimmen.
+-- Format wie vom Treiber geschrieben: Nachkommastellen ohne abschließende Nullen, Offset +00:00.
+UPDATE todos SET
+    created_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', created_at), '0'), '.') || '+00:00',
+    updated_at = rtrim(rtrim(strftime('%Y-%m-%d %H:%M:%f', updated_at), '0'), '.') || '+00:00';
+
+-- Indizes für Sortierung und Pagination der ToDo-Liste
+CREATE INDEX IF NOT EXISTS idx_todos_user_order ON todos (user_id, `order`);
+CREATE INDEX IF NOT EXISTS idx_todos_user_created ON todos (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_todos_user_updated ON todos (user_id, updated_at);
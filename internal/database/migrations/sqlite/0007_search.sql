@@ -0,0 +1,27 @@
+-- Volltextsuche über Titel und Beschreibung. Der Index liest seinen Inhalt aus todos (external content)
+-- und wird über Trigger aktuell gehalten. Benötigt einen SQLite-Treiber mit FTS5 (Build-Tag sqlite_fts5),
+-- ohne FTS5 wird die Migration übersprungen und die Suche verwendet LIKE.
+-- requires: fts5
+CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+    title,
+    description,
+    content = 'todos',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- Bestehende ToDos indexieren
+INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF title, description ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
@@ -0,0 +1,12 @@
+-- Profil der Benutzer: Anzeigename, E-Mail (eindeutig, optional) und Zeitpunkt der Registrierung
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN email TEXT;
+ALTER TABLE users ADD COLUMN created_at DATETIME;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+-- Bestehende Benutzer: Zeitpunkt der ersten ToDo, sonst jetzt (UTC wie in 0006)
+UPDATE users SET created_at = COALESCE(
+    (SELECT MIN(created_at) FROM todos WHERE todos.user_id = users.id),
+    strftime('%Y-%m-%d %H:%M:%S', 'now') || '+00:00'
+);
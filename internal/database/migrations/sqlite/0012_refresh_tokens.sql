@@ -0,0 +1,12 @@
+-- Refresh Tokens zum Erneuern der Access Tokens, gespeichert wird nur der SHA-256 Hash
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key_id INT NOT NULL,              -- API Key, mit dem die Anmeldung erfolgte
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,                 -- gesetzt sobald gegen ein neues Paar getauscht
+    revoked_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_key ON refresh_tokens (key_id);
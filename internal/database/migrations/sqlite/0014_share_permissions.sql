@@ -0,0 +1,4 @@
+-- Berechtigung des Empfängers je geteilter Kopie (view, complete, edit), leer bei eigenen ToDos.
+-- Bestehende Kopien durften bisher nur erledigt gesetzt werden.
+ALTER TABLE todos ADD COLUMN share_permission TEXT NOT NULL DEFAULT '';
+UPDATE todos SET share_permission = 'complete' WHERE original_todo_id != 0;
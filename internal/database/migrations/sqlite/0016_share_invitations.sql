@@ -0,0 +1,11 @@
+-- Offene Einladungen zu geteilten ToDos, der Empfänger wird erst mit der Annahme Mitglied (todo_members)
+CREATE TABLE IF NOT EXISTS share_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    permission TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE (todo_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_invitations_user ON share_invitations (user_id);
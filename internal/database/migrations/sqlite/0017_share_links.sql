@@ -0,0 +1,13 @@
+-- Öffentliche Links zum Lesen einer ToDo oder einer gefilterten Liste ohne Anmeldung, gespeichert wird nur der SHA-256 Hash des Tokens
+CREATE TABLE IF NOT EXISTS share_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,               -- Ersteller, die Liste zeigt seine ToDos
+    todo_id INT,                        -- geteilte ToDo, NULL für eine Liste
+    filter TEXT NOT NULL DEFAULT '',    -- Query-Parameter der Liste wie bei GET /todo/me
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME,
+    revoked_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_user ON share_links (user_id);
@@ -0,0 +1,46 @@
+-- Listen (Projekte) eines Benutzers, jede ToDo liegt aus Sicht jedes Benutzers in höchstens einer seiner Listen.
+-- Die Position (order) zählt je Benutzer und Liste, list_id 0 steht für ToDos ohne Liste.
+CREATE TABLE IF NOT EXISTS lists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_lists_user ON lists (user_id);
+
+-- Bestehende ToDos liegen ohne Liste und behalten ihre bisherige Position
+ALTER TABLE todos ADD COLUMN list_id INT NOT NULL DEFAULT 0;
+ALTER TABLE todo_members ADD COLUMN list_id INT NOT NULL DEFAULT 0;
+
+DROP INDEX IF EXISTS idx_todos_user_order;
+DROP INDEX IF EXISTS idx_todo_members_user_order;
+CREATE INDEX IF NOT EXISTS idx_todos_user_list_order ON todos (user_id, list_id, `order`);
+CREATE INDEX IF NOT EXISTS idx_todo_members_user_list_order ON todo_members (user_id, list_id, `order`);
+
+-- Freigaben einer Liste für Gruppen verweisen auf die Liste (list_id) statt auf eine Kategorie. Jede bisher geteilte
+-- Kategorie wird zu einer Liste ihres Eigentümers, seine ToDos dieser Kategorie wandern in sie und behalten ihre Reihenfolge.
+ALTER TABLE group_shares ADD COLUMN list_id INT;
+
+INSERT INTO lists (user_id, name, created_at)
+SELECT owner_id, category, MIN(created_at) FROM group_shares
+WHERE todo_id IS NULL AND category IS NOT NULL GROUP BY owner_id, category ORDER BY MIN(created_at);
+
+UPDATE group_shares SET list_id = (SELECT lists.id FROM lists WHERE lists.user_id = group_shares.owner_id AND lists.name = group_shares.category), category = NULL
+WHERE todo_id IS NULL;
+
+UPDATE todos SET list_id = (SELECT lists.id FROM lists WHERE lists.user_id = todos.user_id AND lists.name = todos.category)
+WHERE EXISTS (SELECT 1 FROM lists WHERE lists.user_id = todos.user_id AND lists.name = todos.category);
+
+-- Positionen der betroffenen Benutzer je Liste neu durchnummerieren, berechnet vor dem Ändern
+CREATE TABLE todo_order_migration AS
+SELECT id, (SELECT COUNT(*) FROM todos AS other WHERE other.user_id = todos.user_id AND other.list_id = todos.list_id
+    AND (other.`order` < todos.`order` OR (other.`order` = todos.`order` AND other.id <= todos.id))) AS new_order
+FROM todos WHERE user_id IN (SELECT user_id FROM lists);
+
+UPDATE todos SET `order` = (SELECT new_order FROM todo_order_migration WHERE todo_order_migration.id = todos.id)
+WHERE id IN (SELECT id FROM todo_order_migration);
+
+DROP TABLE todo_order_migration;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_group_shares_list ON group_shares (group_id, list_id);
@@ -0,0 +1,14 @@
+-- Checkliste (Teilschritte) einer ToDo mit eigener Reihenfolge, gilt für den Eigentümer und alle Mitglieder
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    todo_id INT NOT NULL,
+    title TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT 0,
+    `order` INT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_items_todo_order ON checklist_items (todo_id, `order`);
+
+-- Mit auto_complete folgt der Status der ToDo ihrer Checkliste
+ALTER TABLE todos ADD COLUMN auto_complete BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Wiederholung einer ToDo als RRULE (z.B. "FREQ=WEEKLY;BYDAY=MO"), leer = keine Wiederholung
+ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
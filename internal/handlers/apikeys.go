@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const maxKeyNameLength = 50 // maximale Länge eines Key-Namens
+
+// /users/me/keys und /users/me/keys/{keyID}: API Keys des angemeldeten Benutzers
+func (s *Server) APIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keyParam := strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/me/keys"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && keyParam == "":
+		s.getAPIKeys(w, r) // GET /users/me/keys: alle Keys ohne den Key selbst
+	case r.Method == http.MethodPost && keyParam == "":
+		s.createAPIKey(w, r) // POST /users/me/keys: weiteren Key erzeugen
+	case r.Method == http.MethodDelete && keyParam != "":
+		s.revokeAPIKey(w, r, keyParam) // DELETE /users/me/keys/{keyID}: Key widerrufen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+func (s *Server) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.store.GetAPIKeysByUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (s *Server) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	// Request Body auslesen
+	var body struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`     // optional, ohne Angabe admin
+		ExpiresAt *time.Time `json:"expires_at"` // RFC3339, optional
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Name fehlt")
+		return
+	}
+	if len([]rune(name)) > maxKeyNameLength {
+		sendErrorResponse(w, http.StatusBadRequest, "Name ist länger als "+strconv.Itoa(maxKeyNameLength)+" Zeichen")
+		return
+	}
+	scopes := []string{auth.ScopeAdmin}
+	if body.Scopes != nil {
+		var ok bool
+		scopes, ok = auth.NormalizeScopes(body.Scopes)
+		if !ok {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültiger Scope (erlaubt: "+strings.Join(auth.Scopes, ", ")+")")
+			return
+		}
+		if len(scopes) == 0 {
+			sendErrorResponse(w, http.StatusBadRequest, "Mindestens ein Scope erforderlich")
+			return
+		}
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		sendErrorResponse(w, http.StatusBadRequest, "Ablaufdatum liegt in der Vergangenheit")
+		return
+	}
+
+	// Neuen Secret Key erzeugen, gespeichert wird nur der Hash
+	secretKey, err := auth.GenerateSecret()
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	hash, err := auth.HashSecret(secretKey)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	key := models.APIKey{Name: name, Scopes: scopes, ExpiresAt: body.ExpiresAt}
+	err = s.store.CreateAPIKey(callerID(r), &key, hash, auth.LookupKey(secretKey))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort, der Secret Key wird nur dieses eine Mal übermittelt
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Key       models.APIKey `json:"key"`
+		SecretKey string        `json:"secret_key"`
+	}{
+		Key:       key,
+		SecretKey: secretKey,
+	})
+}
+
+func (s *Server) revokeAPIKey(w http.ResponseWriter, r *http.Request, keyParam string) {
+	keyID, err := strconv.ParseInt(keyParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige key_id")
+		return
+	}
+
+	err = s.store.RevokeAPIKey(callerID(r), int(keyID))
+	switch err {
+	case nil:
+	case store.ErrNotFound:
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige key_id")
+		return
+	case store.ErrLastAPIKey:
+		sendErrorResponse(w, http.StatusBadRequest, "Der letzte gültige API Key mit Scope admin kann nicht widerrufen werden")
+		return
+	default:
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "API Key erfolgreich widerrufen",
+	})
+}
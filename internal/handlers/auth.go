@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+var errAmbiguousSecretKey = errors.New("Secret Key ist mehreren Benutzern zugeordnet")
+
+// Abgelehnter Bearer Token, wird mit 401 beantwortet
+type invalidTokenError string
+
+func (e invalidTokenError) Error() string {
+	return string(e)
+}
+
+// Middleware für alle Endpunkte mit Anmeldung: ermittelt Benutzer und API Key zum Access Token im Authorization
+// Header bzw. zum Secret-Key Header, legt sie im Request Context ab (siehe callerID) und prüft den Scope, den
+// rule für den Request verlangt (festgelegt bei der Registrierung in routes)
+func (s *Server) requireAuth(rule scopeRule, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		r = r.WithContext(auth.NewContext(r.Context(), principal))
+		if !requireScope(w, r, rule.scopeFor(r)) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Ermittelt den angemeldeten Benutzer eines Requests, sendet bei Fehlern selbst die Antwort und liefert dann false
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (auth.Principal, bool) {
+	// Access Tokens werden anhand der Signatur geprüft und dem Benutzer bzw. API Key zugeordnet
+	if header := r.Header.Get("Authorization"); header != "" {
+		principal, err := s.principalForBearer(header)
+		var tokenErr invalidTokenError
+		switch {
+		case errors.As(err, &tokenErr):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			sendErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return principal, false
+		case err == errIdentityProvider:
+			sendErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+			return principal, false
+		case err != nil:
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return principal, false
+		}
+		return principal, true
+	}
+
+	// Secret Key aus dem Header auslesen
+	secretKey := r.Header.Get("Secret-Key")
+	if secretKey == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Secret Key oder Bearer Token fehlt")
+		return auth.Principal{}, false
+	}
+
+	credential, err := s.credentialForSecretKey(secretKey)
+	if err == errAmbiguousSecretKey {
+		sendErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return auth.Principal{}, false
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return auth.Principal{}, false
+	}
+	if credential == nil {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return auth.Principal{}, false
+	}
+
+	// Letzte Verwendung des Keys vermerken, ein Fehler verhindert die Anmeldung nicht
+	if err := s.store.MarkAPIKeyUsed(credential.KeyID); err != nil {
+		s.logger.Printf("Verwendung von API Key %d konnte nicht gespeichert werden: %v", credential.KeyID, err)
+	}
+
+	return auth.Principal{UserID: credential.UserID, KeyID: credential.KeyID, Scopes: credential.Scopes}, true
+}
+
+// Prüft einen Authorization Header der Form "Bearer {token}", zuständig ist je nach iss Claim
+// der OIDC Provider oder der eigene Aussteller
+func (s *Server) principalForBearer(header string) (auth.Principal, error) {
+	scheme, token, found := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return auth.Principal{}, invalidTokenError("Ungültiger Authorization Header, erwartet wird Bearer {token}")
+	}
+
+	if s.oidc != nil && auth.UnverifiedIssuer(token) == s.oidc.Issuer() {
+		return s.principalForOIDC(token)
+	}
+
+	principal, err := s.tokens.Verify(token)
+	switch err {
+	case nil:
+	case auth.ErrTokenExpired:
+		return auth.Principal{}, invalidTokenError("Access Token abgelaufen")
+	default:
+		return auth.Principal{}, invalidTokenError("Ungültiger Access Token")
+	}
+
+	// Nach dem Löschen des Kontos bzw. dem Widerruf des Keys gilt auch ein noch nicht abgelaufener Token nicht mehr
+	exists, err := s.store.UserExists(principal.UserID)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	active, err := s.store.APIKeyActive(principal.UserID, principal.KeyID)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if !exists || !active {
+		return auth.Principal{}, invalidTokenError("Access Token nicht mehr gültig, Benutzer oder API Key existiert nicht mehr")
+	}
+	return principal, nil
+}
+
+// UserID des angemeldeten Benutzers, nur innerhalb von requireAuth aufrufen
+func callerID(r *http.Request) int {
+	principal, _ := auth.FromContext(r.Context())
+	return principal.UserID
+}
+
+// Prüft ob der API Key des Requests die Berechtigung scope besitzt, sendet sonst 403.
+// Aufgerufen von requireAuth, bevor der Handler Daten liest oder ändert.
+func requireScope(w http.ResponseWriter, r *http.Request, scope string) bool {
+	principal, _ := auth.FromContext(r.Context())
+	if principal.HasScope(scope) {
+		return true
+	}
+	sendErrorResponse(w, http.StatusForbidden, "API Key fehlt die Berechtigung \""+scope+"\"")
+	return false
+}
+
+// Ermittelt den aktiven API Key zu einem Secret Key, nil wenn kein Key passt
+func (s *Server) credentialForSecretKey(secretKey string) (*store.Credential, error) {
+	lookup := auth.LookupKey(secretKey)
+	candidates, err := s.store.FindCredentials(lookup, secretKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		auth.VerifySecret("", secretKey) // gleiche Antwortzeit wie bei einem bekannten Key
+		return nil, nil
+	}
+
+	var match *store.Credential
+	for i, candidate := range candidates {
+		ok, rehash := auth.VerifySecret(candidate.Hash, secretKey)
+		if !ok {
+			continue
+		}
+		if match != nil && match.UserID != candidate.UserID {
+			// Gleicher Klartext-Key bei mehreren Benutzern (ältere Datenbanken), Benutzer nicht bestimmbar
+			s.logger.Printf("Secret Key von Benutzer %d und %d ist identisch", match.UserID, candidate.UserID)
+			return nil, errAmbiguousSecretKey
+		}
+		if match == nil {
+			match = &candidates[i]
+		}
+
+		if rehash || candidate.Lookup == "" {
+			s.upgradeAPIKey(candidate, secretKey, rehash, lookup)
+		}
+	}
+
+	return match, nil
+}
+
+// Ersetzt Klartext-Keys durch einen Hash und ergänzt den Lookup, Fehler werden nur protokolliert
+func (s *Server) upgradeAPIKey(credential store.Credential, secretKey string, rehash bool, lookup string) {
+	hash := credential.Hash
+	var err error
+	if rehash {
+		hash, err = auth.HashSecret(secretKey)
+	}
+	if err == nil {
+		err = s.store.UpdateAPIKeyHash(credential.KeyID, hash, lookup)
+	}
+	if err != nil {
+		s.logger.Printf("API Key %d konnte nicht aktualisiert werden: %v", credential.KeyID, err)
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const maxItemTitleLength = 200 // maximale Länge eines Punkts der Checkliste
+
+// /todo/{todoID}/items und /todo/{todoID}/items/{itemID}: Checkliste einer ToDo, gilt für Eigentümer und Mitglieder
+func (s *Server) TodoItemsHandler(w http.ResponseWriter, r *http.Request) {
+	// Parameter auslesen und prüfen
+	pathSegments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/todo/"), "/"), "/") // {todoID}, "items", {itemID}
+	todoID, err := strconv.ParseInt(pathSegments[0], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige todo_id")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(pathSegments) == 2:
+		s.getChecklist(w, r, int(todoID)) // GET /todo/{id}/items: Punkte in ihrer Reihenfolge
+	case r.Method == http.MethodPost && len(pathSegments) == 2:
+		s.addChecklistItem(w, r, int(todoID)) // POST /todo/{id}/items: Punkt am Ende anhängen
+	case len(pathSegments) == 3:
+		itemID, err := strconv.ParseInt(pathSegments[2], 10, 0)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige item_id")
+			return
+		}
+		switch r.Method {
+		case http.MethodPatch:
+			s.patchChecklistItem(w, r, int(todoID), int(itemID)) // PATCH /todo/{id}/items/{itemID}: Titel, Position, Status
+		case http.MethodDelete:
+			s.deleteChecklistItem(w, r, int(todoID), int(itemID)) // DELETE /todo/{id}/items/{itemID}: Punkt löschen
+		default:
+			sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+		}
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+// Liest die ToDo aus Sicht des angemeldeten Benutzers und prüft den Zugriff,
+// sendet bei Fehlern selbst die Antwort und liefert dann false
+func (s *Server) checklistTodo(w http.ResponseWriter, r *http.Request, todoID int) (models.ToDo, bool) {
+	todo, err := s.store.GetTodo(todoID, callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige todo_id")
+			return todo, false
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return todo, false
+	}
+	if !canAccess(todo, callerID(r)) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return todo, false
+	}
+	return todo, true
+}
+
+// Prüft den Titel eines Punkts und entfernt Leerzeichen am Rand, sendet bei Fehlern selbst die Antwort
+func validItemTitle(w http.ResponseWriter, title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	if len([]rune(title)) > maxItemTitleLength {
+		sendErrorResponse(w, http.StatusBadRequest, "Titel ist länger als "+strconv.Itoa(maxItemTitleLength)+" Zeichen")
+		return "", false
+	}
+	return title, true
+}
+
+// Antwort für Fehler beim Zugriff auf einen einzelnen Punkt
+func sendChecklistError(w http.ResponseWriter, err error) {
+	if err == store.ErrNotFound {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige item_id")
+		return
+	}
+	sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+}
+
+func (s *Server) getChecklist(w http.ResponseWriter, r *http.Request, todoID int) {
+	if _, ok := s.checklistTodo(w, r, todoID); !ok {
+		return
+	}
+
+	items, err := s.store.GetChecklist(todoID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(items)
+}
+
+func (s *Server) addChecklistItem(w http.ResponseWriter, r *http.Request, todoID int) {
+	todo, ok := s.checklistTodo(w, r, todoID)
+	if !ok {
+		return
+	}
+	if !sharedAllows(todo, callerID(r), models.PermissionEdit) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung zum Bearbeiten dieser geteilten ToDo")
+		return
+	}
+
+	// Request Body auslesen
+	var body struct {
+		Title string `json:"title"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+	title, ok := validItemTitle(w, body.Title)
+	if !ok {
+		return
+	}
+	if title == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Titel fehlt")
+		return
+	}
+
+	// Der Punkt wird offen am Ende angehängt, eine automatisch erledigte ToDo ist danach wieder offen
+	item := models.ChecklistItem{TodoID: todoID, Title: title}
+	err = s.store.AddChecklistItem(&item)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+func (s *Server) patchChecklistItem(w http.ResponseWriter, r *http.Request, todoID, itemID int) {
+	todo, ok := s.checklistTodo(w, r, todoID)
+	if !ok {
+		return
+	}
+
+	// Request Body auslesen, completed ist optional und wird getrennt vom Inhalt übernommen
+	var body struct {
+		Title     string `json:"title"`
+		Order     int    `json:"order"`
+		Completed *bool  `json:"completed"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+	title, ok := validItemTitle(w, body.Title)
+	if !ok {
+		return
+	}
+	contentChanged := title != "" || body.Order != 0
+	if !contentChanged && body.Completed == nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Keine gültigen Parameter im Request Body")
+		return
+	}
+
+	// Titel und Position ändert ein Mitglied nur mit "edit", den Status schon mit "complete"
+	if contentChanged && !sharedAllows(todo, callerID(r), models.PermissionEdit) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung zum Bearbeiten dieser geteilten ToDo")
+		return
+	}
+	if body.Completed != nil && !sharedAllows(todo, callerID(r), models.PermissionComplete) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung zum Abschließen dieser geteilten ToDo")
+		return
+	}
+
+	// Aktualisieren des Punkts, die Positionen der anderen Punkte passt der Store an
+	if contentChanged {
+		err = s.store.UpdateChecklistItem(todoID, itemID, models.ChecklistItem{Title: title, Order: body.Order})
+		switch err {
+		case nil:
+		case store.ErrOrderOutOfRange:
+			sendErrorResponse(w, http.StatusBadRequest, "Die neue Position liegt außerhalb der erlaubten Positionen")
+			return
+		case store.ErrOrderUnchanged:
+			sendErrorResponse(w, http.StatusBadRequest, "Die neue Position ist die gleiche wie die alte Position")
+			return
+		default:
+			sendChecklistError(w, err)
+			return
+		}
+	}
+
+	// Mit auto_complete folgt der Status der ToDo dem der Punkte
+	if body.Completed != nil {
+		err = s.store.SetChecklistItemStatus(todoID, itemID, *body.Completed)
+		if err != nil {
+			sendChecklistError(w, err)
+			return
+		}
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Punkt erfolgreich aktualisiert",
+	})
+}
+
+func (s *Server) deleteChecklistItem(w http.ResponseWriter, r *http.Request, todoID, itemID int) {
+	todo, ok := s.checklistTodo(w, r, todoID)
+	if !ok {
+		return
+	}
+	if !sharedAllows(todo, callerID(r), models.PermissionEdit) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung zum Bearbeiten dieser geteilten ToDo")
+		return
+	}
+
+	// Die nachfolgenden Punkte rücken auf
+	err := s.store.DeleteChecklistItem(todoID, itemID)
+	if err != nil {
+		sendChecklistError(w, err)
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Punkt erfolgreich gelöscht",
+	})
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const (
+	defaultPageSize = 100 // Anzahl der ToDos je Seite ohne limit
+	maxPageSize     = 500 // größtes erlaubtes limit
+)
+
+// Erlaubte Werte für sort, ein vorangestelltes "-" kehrt die Sortierung um (z.B. sort=-created_at)
+var sortFields = map[string]string{
+	"order":      store.SortByOrder,
+	"priority":   store.SortByPriority,
+	"created_at": store.SortByCreatedAt,
+	"updated_at": store.SortByUpdatedAt,
+	"title":      store.SortByTitle,
+}
+
+// Inhalt eines Cursors. Die Sortierung wird mitgeführt, damit ein Cursor nicht mit einer anderen Sortierung verwendet wird.
+type pageCursor struct {
+	Sort       string       `json:"sort"`
+	Descending bool         `json:"desc"`
+	Position   store.Cursor `json:"pos"`
+}
+
+// Kodiert die Position hinter der letzten ToDo einer Seite als undurchsichtigen String für next_cursor
+func encodeCursor(filter store.TodoFilter, position store.Cursor) string {
+	data, _ := json.Marshal(pageCursor{Sort: filter.Sort, Descending: filter.Descending, Position: position})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
+
+// Liest einen Zeitpunkt aus einem Query-Parameter: RFC3339 oder Datum (Beginn des Tages in loc)
+func parseTimeParam(query url.Values, name string, loc *time.Location) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return nil, errors.New("Ungültiger Zeitpunkt für " + name + " (erwartet: 2006-01-02 oder RFC3339)")
+		}
+	}
+	return &t, nil
+}
+
+// Liest einen Wahrheitswert aus einem Query-Parameter
+func parseBoolParam(query url.Values, name string) (*bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.New("Ungültiger Wert für " + name + " (erlaubt: true, false)")
+	}
+	return &b, nil
+}
+
+// Liest Filter, Sortierung und Pagination für GET /todo/user/{id} aus den Query-Parametern:
+//
+//	due=overdue     offene ToDos, deren Fälligkeit überschritten ist
+//	due=today       ToDos, die heute fällig sind
+//	due=week        ToDos, die in der aktuellen Woche (Montag bis Sonntag) fällig sind
+//	tz=<IANA>       Zeitzone für "heute", "Woche" und reine Datumsangaben, z.B. Europe/Berlin (Standard: Serverzeit)
+//	completed=true  nur erledigte (true) bzw. offene (false) ToDos
+//	category=x      nur ToDos der Kategorie x
+//	list=3          nur ToDos der Liste 3, list=0 nur ToDos ohne Liste
+//	shared=true     nur mit dem Benutzer geteilte (true) bzw. nur eigene ToDos (false)
+//	created_after=, created_before=, updated_after=, updated_before=
+//	                Zeiträume als Datum oder RFC3339, "after" einschließlich, "before" ausschließlich
+//	tags=a,b        nur ToDos mit diesen Tags
+//	tag_mode=or     mindestens einer der Tags genügt (Standard: and, alle Tags)
+//	sort=priority   order (Standard), priority, created_at, updated_at oder title, "-" davor kehrt die Sortierung um
+//	limit=50        Seitengröße (Standard 100, maximal 500)
+//	cursor=...      next_cursor der vorherigen Seite
+func parseTodoFilter(query url.Values, now time.Time) (store.TodoFilter, error) {
+	var filter store.TodoFilter
+	var err error
+
+	loc := time.Local
+	if tz := query.Get("tz"); tz != "" {
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return filter, errors.New("Ungültige Zeitzone")
+		}
+	}
+	now = now.In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch query.Get("due") {
+	case "":
+	case "overdue":
+		open := false
+		filter.Completed = &open
+		filter.DueUntil = &now
+	case "today":
+		endOfDay := startOfDay.AddDate(0, 0, 1)
+		filter.DueFrom = &startOfDay
+		filter.DueUntil = &endOfDay
+	case "week":
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7 // Sonntag = 0 in Go
+		startOfWeek := startOfDay.AddDate(0, 0, -daysSinceMonday)
+		endOfWeek := startOfWeek.AddDate(0, 0, 7)
+		filter.DueFrom = &startOfWeek
+		filter.DueUntil = &endOfWeek
+	default:
+		return filter, errors.New("Ungültiger Wert für due (erlaubt: overdue, today, week)")
+	}
+
+	// Einfache Filter, completed überschreibt den Status aus due=overdue
+	completed, err := parseBoolParam(query, "completed")
+	if err != nil {
+		return filter, err
+	}
+	if completed != nil {
+		filter.Completed = completed
+	}
+	filter.Shared, err = parseBoolParam(query, "shared")
+	if err != nil {
+		return filter, err
+	}
+	filter.Category = query.Get("category")
+	if list := query.Get("list"); list != "" {
+		listID, err := strconv.Atoi(list)
+		if err != nil || listID < 0 {
+			return filter, errors.New("Ungültiger Wert für list (ID einer Liste, 0 = ohne Liste)")
+		}
+		filter.ListID = &listID
+	}
+
+	// Zeiträume
+	for _, p := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"created_after", &filter.CreatedFrom},
+		{"created_before", &filter.CreatedUntil},
+		{"updated_after", &filter.UpdatedFrom},
+		{"updated_before", &filter.UpdatedUntil},
+	} {
+		*p.value, err = parseTimeParam(query, p.name, loc)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	if tags := query.Get("tags"); tags != "" {
+		filter.Tags, err = normalizeTags(strings.Split(tags, ","))
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	switch query.Get("tag_mode") {
+	case "", "and":
+		filter.TagMode = store.TagModeAll
+	case "or":
+		filter.TagMode = store.TagModeAny
+	default:
+		return filter, errors.New("Ungültiger Wert für tag_mode (erlaubt: and, or)")
+	}
+
+	// Sortierung
+	sortParam := query.Get("sort")
+	if strings.HasPrefix(sortParam, "-") {
+		filter.Descending = true
+		sortParam = sortParam[1:]
+	}
+	if sortParam == "" {
+		sortParam = "order"
+	}
+	sortField, ok := sortFields[sortParam]
+	if !ok {
+		return filter, errors.New("Ungültiger Wert für sort (erlaubt: order, priority, created_at, updated_at, title)")
+	}
+	filter.Sort = sortField
+
+	// Pagination
+	filter.Limit = defaultPageSize
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxPageSize {
+			return filter, errors.New("Ungültiger Wert für limit (1 bis " + strconv.Itoa(maxPageSize) + ")")
+		}
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return filter, errors.New("Ungültiger Cursor")
+		}
+		if cursor.Sort != filter.Sort || cursor.Descending != filter.Descending {
+			return filter, errors.New("Cursor passt nicht zur Sortierung")
+		}
+		filter.After = &cursor.Position
+	}
+
+	return filter, nil
+}
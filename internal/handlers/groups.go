@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const maxGroupNameLength = 50 // maximale Länge eines Gruppennamens
+
+// /groups und /groups/{groupID}[/members/{userID} | /shares[/{shareID}]]: Gruppen des angemeldeten Benutzers
+func (s *Server) GroupsHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/groups"), "/")
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.getGroups(w, r) // GET /groups: eigene Gruppen mit Rolle
+		case http.MethodPost:
+			s.createGroup(w, r) // POST /groups: Gruppe anlegen
+		default:
+			sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+		}
+		return
+	}
+
+	pathSegments := strings.Split(path, "/")
+	groupID, err := strconv.ParseInt(pathSegments[0], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige group_id")
+		return
+	}
+
+	// Alle weiteren Endpunkte nur für Mitglieder der Gruppe
+	role, err := s.store.GetGroupRole(int(groupID), callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige group_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch {
+	case len(pathSegments) == 1 && r.Method == http.MethodGet:
+		s.getGroup(w, r, int(groupID), role) // GET /groups/{groupID}: Gruppe mit Mitgliedern
+	case len(pathSegments) == 1 && r.Method == http.MethodPatch:
+		s.renameGroup(w, r, int(groupID), role) // PATCH /groups/{groupID}: umbenennen
+	case len(pathSegments) == 1 && r.Method == http.MethodDelete:
+		s.deleteGroup(w, r, int(groupID), role) // DELETE /groups/{groupID}: Gruppe löschen
+	case len(pathSegments) == 3 && pathSegments[1] == "members" && r.Method == http.MethodPost:
+		s.inviteGroupMember(w, r, int(groupID), role, pathSegments[2]) // POST /groups/{groupID}/members/{userID}: Benutzer in die Gruppe einladen
+	case len(pathSegments) == 3 && pathSegments[1] == "members" && r.Method == http.MethodPatch:
+		s.setGroupMemberRole(w, r, int(groupID), role, pathSegments[2]) // PATCH /groups/{groupID}/members/{userID}: Rolle ändern
+	case len(pathSegments) == 3 && pathSegments[1] == "members" && r.Method == http.MethodDelete:
+		s.removeGroupMember(w, r, int(groupID), role, pathSegments[2]) // DELETE /groups/{groupID}/members/{userID}: Mitglied entfernen, austreten bzw. Einladung zurückziehen
+	case len(pathSegments) == 2 && pathSegments[1] == "shares" && r.Method == http.MethodGet:
+		s.getGroupShares(w, r, int(groupID)) // GET /groups/{groupID}/shares: Freigaben der Gruppe
+	case len(pathSegments) == 2 && pathSegments[1] == "shares" && r.Method == http.MethodPost:
+		s.createGroupShare(w, r, int(groupID)) // POST /groups/{groupID}/shares: ToDo oder Liste mit der Gruppe teilen
+	case len(pathSegments) == 3 && pathSegments[1] == "shares" && r.Method == http.MethodDelete:
+		s.deleteGroupShare(w, r, int(groupID), role, pathSegments[2]) // DELETE /groups/{groupID}/shares/{shareID}: Freigabe entfernen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+// Liest den Namen einer Gruppe bzw. Liste aus dem Request Body und prüft ihn, sendet bei Fehlern selbst die Antwort
+func decodeName(w http.ResponseWriter, r *http.Request, maxLength int) (string, bool) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return "", false
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Name fehlt")
+		return "", false
+	}
+	if len([]rune(name)) > maxLength {
+		sendErrorResponse(w, http.StatusBadRequest, "Name ist länger als "+strconv.Itoa(maxLength)+" Zeichen")
+		return "", false
+	}
+	return name, true
+}
+
+func (s *Server) getGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.store.GetGroupsByUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(groups)
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeName(w, r, maxGroupNameLength)
+	if !ok {
+		return
+	}
+
+	// Der angemeldete Benutzer wird Eigentümer der Gruppe
+	group := models.Group{Name: name}
+	err := s.store.CreateGroup(&group, callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole) {
+	group, err := s.store.GetGroup(groupID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	group.Role = role
+
+	// Offene Einladungen sehen nur Eigentümer und Admins
+	if role.CanManage() {
+		group.Invitations, err = s.store.GetGroupInvitationsByGroup(groupID)
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(group)
+}
+
+func (s *Server) renameGroup(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole) {
+	if !role.CanManage() {
+		sendErrorResponse(w, http.StatusForbidden, "Nur Eigentümer und Admins können die Gruppe verwalten")
+		return
+	}
+
+	name, ok := decodeName(w, r, maxGroupNameLength)
+	if !ok {
+		return
+	}
+	err := s.store.RenameGroup(groupID, name)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Gruppe erfolgreich umbenannt",
+	})
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole) {
+	if role != models.GroupRoleOwner {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann die Gruppe löschen")
+		return
+	}
+
+	// Die Mitglieder verlieren den Zugriff auf alle mit der Gruppe geteilten ToDos
+	err := s.store.DeleteGroup(groupID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Gruppe erfolgreich gelöscht",
+	})
+}
+
+// Liest die Rolle aus dem Request Body, ohne Angabe member
+func decodeGroupRole(r *http.Request) (models.GroupRole, error) {
+	var body struct {
+		Role string `json:"role"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil && err != io.EOF {
+		return "", errors.New("Request Body konnte nicht decodiert werden")
+	}
+	if body.Role == "" {
+		return models.GroupRoleMember, nil
+	}
+	role, err := models.ParseGroupRole(body.Role)
+	if err != nil {
+		return "", errors.New("Ungültige Rolle (erlaubt: admin, member)")
+	}
+	return role, nil
+}
+
+// Liest ein weiteres Mitglied der Gruppe aus dem Pfad, der Eigentümer kann weder entfernt werden noch eine andere Rolle erhalten.
+// Sendet bei Fehlern selbst die Antwort und liefert dann false.
+func (s *Server) groupMember(w http.ResponseWriter, groupID int, userParam string) (int, models.GroupRole, bool) {
+	userID, err := strconv.ParseInt(userParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige UserID")
+		return 0, "", false
+	}
+	role, err := s.store.GetGroupRole(groupID, int(userID))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Benutzer ist kein Mitglied der Gruppe")
+			return 0, "", false
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return 0, "", false
+	}
+	if role == models.GroupRoleOwner {
+		sendErrorResponse(w, http.StatusBadRequest, "Der Eigentümer bleibt in der Gruppe, sie kann nur gelöscht werden")
+		return 0, "", false
+	}
+	return int(userID), role, true
+}
+
+func (s *Server) inviteGroupMember(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole, userParam string) {
+	// Admins laden Mitglieder ein, Admins ernennt nur der Eigentümer
+	if !role.CanManage() {
+		sendErrorResponse(w, http.StatusForbidden, "Nur Eigentümer und Admins können die Gruppe verwalten")
+		return
+	}
+	userID, err := strconv.ParseInt(userParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige UserID")
+		return
+	}
+	newRole, err := decodeGroupRole(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if newRole == models.GroupRoleAdmin && role != models.GroupRoleOwner {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann Admins ernennen")
+		return
+	}
+	exists, err := s.store.UserExists(int(userID))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !exists {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige UserID")
+		return
+	}
+
+	// Einladung an den Benutzer, erst mit der Annahme sieht er die mit der Gruppe geteilten ToDos
+	invitation := models.GroupInvitation{GroupID: groupID, UserID: int(userID), Role: newRole}
+	err = s.store.CreateGroupInvitation(&invitation)
+	switch err {
+	case nil:
+	case store.ErrAlreadyMember:
+		sendErrorResponse(w, http.StatusConflict, "Benutzer ist bereits Mitglied der Gruppe")
+		return
+	case store.ErrInvitationOpen:
+		sendErrorResponse(w, http.StatusConflict, "Einladung an diesen Benutzer ist bereits offen")
+		return
+	default:
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	invitation, err = s.store.GetGroupInvitation(invitation.ID) // mit Namen der Gruppe
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
+}
+
+func (s *Server) setGroupMemberRole(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole, userParam string) {
+	// Rollen vergibt nur der Eigentümer
+	if role != models.GroupRoleOwner {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann Rollen ändern")
+		return
+	}
+	userID, _, ok := s.groupMember(w, groupID, userParam)
+	if !ok {
+		return
+	}
+	newRole, err := decodeGroupRole(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.store.SetGroupRole(groupID, userID, newRole)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Rolle erfolgreich geändert",
+	})
+}
+
+func (s *Server) removeGroupMember(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole, userParam string) {
+	// Ist der Benutzer noch kein Mitglied, ziehen Eigentümer und Admins seine offene Einladung zurück
+	if invitedID, err := strconv.ParseInt(userParam, 10, 0); err == nil && role.CanManage() {
+		err = s.withdrawGroupInvitation(groupID, int(invitedID))
+		if err == nil {
+			// Senden der Antwort
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(struct {
+				Message string `json:"message"`
+			}{
+				Message: "Einladung erfolgreich zurückgezogen",
+			})
+			return
+		}
+		if err != store.ErrNotFound {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	userID, targetRole, ok := s.groupMember(w, groupID, userParam)
+	if !ok {
+		return
+	}
+
+	// Jedes Mitglied kann austreten, Admins entfernen Mitglieder, Admins entfernt nur der Eigentümer
+	if userID != callerID(r) && (!role.CanManage() || (targetRole == models.GroupRoleAdmin && role != models.GroupRoleOwner)) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung, dieses Mitglied zu entfernen")
+		return
+	}
+
+	// Das Mitglied verliert den Zugriff auf alle ToDos, die es nur über die Gruppe sieht
+	err := s.store.RemoveGroupMember(groupID, userID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Mitglied erfolgreich entfernt",
+	})
+}
+
+// Löscht die offene Einladung des Benutzers in die Gruppe, store.ErrNotFound wenn keine offen ist
+func (s *Server) withdrawGroupInvitation(groupID, userID int) error {
+	invitations, err := s.store.GetGroupInvitationsByGroup(groupID)
+	if err != nil {
+		return err
+	}
+	for _, invitation := range invitations {
+		if invitation.UserID == userID {
+			return s.store.DeleteGroupInvitation(invitation.ID)
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (s *Server) getGroupShares(w http.ResponseWriter, r *http.Request, groupID int) {
+	shares, err := s.store.GetGroupShares(groupID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shares)
+}
+
+func (s *Server) createGroupShare(w http.ResponseWriter, r *http.Request, groupID int) {
+	// Request Body auslesen: entweder eine ToDo oder eine eigene Liste
+	var body struct {
+		TodoID     *int   `json:"todo_id"`
+		ListID     *int   `json:"list_id"`
+		Permission string `json:"permission"` // optional, Standard complete
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+	if (body.TodoID == nil) == (body.ListID == nil) {
+		sendErrorResponse(w, http.StatusBadRequest, "Entweder todo_id oder list_id angeben")
+		return
+	}
+	permission, err := parseSharePermission(body.Permission)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Jedes Mitglied der Gruppe kann eigene ToDos und Listen mit ihr teilen
+	share := models.GroupShare{GroupID: groupID, OwnerID: callerID(r), TodoID: body.TodoID, ListID: body.ListID, Permission: permission}
+	if body.ListID != nil {
+		_, err := s.store.GetList(*body.ListID, callerID(r))
+		if err != nil {
+			if err == store.ErrNotFound {
+				sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+				return
+			}
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if body.TodoID != nil {
+		todo, err := s.store.GetTodo(*body.TodoID, callerID(r))
+		if err != nil {
+			if err == store.ErrNotFound {
+				sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+				return
+			}
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !canAccess(todo, callerID(r)) {
+			sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+			return
+		}
+		if todo.UserID != callerID(r) {
+			sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann eine ToDo teilen")
+			return
+		}
+	}
+
+	err = s.store.CreateGroupShare(&share)
+	if err == store.ErrAlreadyShared {
+		sendErrorResponse(w, http.StatusConflict, "ToDo bzw. Liste ist bereits mit der Gruppe geteilt")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(share)
+}
+
+func (s *Server) deleteGroupShare(w http.ResponseWriter, r *http.Request, groupID int, role models.GroupRole, shareParam string) {
+	shareID, err := strconv.ParseInt(shareParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige share_id")
+		return
+	}
+	share, err := s.store.GetGroupShare(int(shareID))
+	if err == store.ErrNotFound || (err == nil && share.GroupID != groupID) {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige share_id")
+		return
+	}
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Der Eigentümer der ToDo bzw. Liste sowie Eigentümer und Admins der Gruppe können die Freigabe entfernen
+	if share.OwnerID != callerID(r) && !role.CanManage() {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer der Freigabe oder Admins der Gruppe können sie entfernen")
+		return
+	}
+
+	err = s.store.DeleteGroupShare(share.ID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Freigabe erfolgreich entfernt",
+	})
+}
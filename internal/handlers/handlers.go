@@ -1,52 +1,57 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
-
-	db "github.com/Paul-frank/todo-api/internal/database"
 	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
 )
 
 
-var database *db.Database // globaler DB-Pointer
-
-func SetDatabase(db *db.Database) {  // Datenbankverbindung aus der Main übergeben
-    database = db
-}
-
-
-func ToDoHandler(w http.ResponseWriter, r *http.Request){
+func (s *Server) ToDoHandler(w http.ResponseWriter, r *http.Request){
 	switch r.Method{
 	case http.MethodPost:
-		createTodo(w, r) 	// POST /todo: Erstellen eines neuen ToDo-Eintrags
+		s.createTodo(w, r) 	// POST /todo: Erstellen eines neuen ToDo-Eintrags
 	default:
 		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
     }
 }
 
-func ToDoParameterHandler(w http.ResponseWriter, r *http.Request){
+func (s *Server) ToDoParameterHandler(w http.ResponseWriter, r *http.Request){
+	// Unterressourcen einer ToDo: /todo/{id}/...
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/todo/"), "/")
+	if len(pathSegments) > 1 {
+		switch pathSegments[1] {
+		case "tags":
+			s.TodoTagsHandler(w, r) // /todo/{id}/tags[/{name}]: Tags einer ToDo
+		case "links":
+			s.TodoLinksHandler(w, r) // /todo/{id}/links[/{linkID}]: öffentliche Links zu einer ToDo
+		case "items":
+			s.TodoItemsHandler(w, r) // /todo/{id}/items[/{itemID}]: Checkliste einer ToDo
+		default:
+			sendErrorResponse(w, http.StatusNotFound, "Unbekannter Pfad")
+		}
+		return
+	}
+
 	switch r.Method{
 	case http.MethodGet:
-		getToDoById(w, r)	// GET /todo/{id}: Abrufen eines spezifischen ToDo-Eintrags.
+		s.getToDoById(w, r)	// GET /todo/{id}: Abrufen eines spezifischen ToDo-Eintrags.
 	case http.MethodPatch:
-		patchToDoById(w, r)	// PATCH /todo/{id}: Aktualisieren eines ToDo-Eintrags.
+		s.patchToDoById(w, r)	// PATCH /todo/{id}: Aktualisieren eines ToDo-Eintrags.
 	case http.MethodDelete:
-		deleteToDoById(w,r) // DELETE /todo/{id}: löschen eines ToDo-Eintrags.
+		s.deleteToDoById(w, r) // DELETE /todo/{id}: löschen eines ToDo-Eintrags.
 	default:
 		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
     }
 }
 
 
-func patchToDoById(w http.ResponseWriter, r *http.Request){
-
+func (s *Server) patchToDoById(w http.ResponseWriter, r *http.Request){
 	// Parameter Id auslesen und prüfen
 	todoID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/todo/"), 10, 0)
 	if err != nil{
@@ -54,13 +59,6 @@ func patchToDoById(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	// Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
-
 	// Umwandeln in neue Todo Instanz
 	var updatedToDo models.ToDo
 	err = json.NewDecoder(r.Body).Decode(&updatedToDo)
@@ -69,116 +67,48 @@ func patchToDoById(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	// Beginn der Transaktion
-	tx, err := database.Connection.Begin()
-	if err != nil{
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	// Beenden wenn geteilte ToDo
-	var userID, currentOriginalID int
-	err = tx.QueryRow("SELECT user_id, original_todo_id FROM todos WHERE id = ?", todoID).Scan(&userID, &currentOriginalID)
+	// Abrufen der ToDo für die Berechtigung
+	current, err := s.store.GetTodo(int(todoID), callerID(r))
 	if err != nil{
-		tx.Rollback()
+		if err == store.ErrNotFound{
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige todo_id")
+			return
+		}
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if currentOriginalID != 0{
-		sendErrorResponse(w, http.StatusBadRequest, "Geteilte Todo kann nicht angepasst werden")
-		return
-	}
 
-	// Authentifizierung prüfen
-    if !authenticateUser(userID, secretKey) {
+	// Berechtigung prüfen
+    if !canAccess(current, callerID(r)) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
 
-	// Wenn Position sich verändert, dann ...
-	if updatedToDo.Order != 0{
-		// Abrufen der aktuellen Position (order) und der UserID
-		var currentOrder,userID, maxOrder int
-		err = tx.QueryRow("SELECT `order`, user_id FROM todos WHERE id = ?", todoID).Scan(&currentOrder, &userID)
-		if err != nil{
-			tx.Rollback()
-			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		// Abrufen der maximalen Postion (order)
-		err = tx.QueryRow("SELECT MAX(`order`) FROM todos WHERE user_id = ?", userID).Scan(&maxOrder)
-    	if err != nil {
-			tx.Rollback()
-			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-        	return
-		}
-		// Überprüfen ob die neue Position (order) im Bereich der gültigen Werte liegt
-		if updatedToDo.Order > maxOrder {
-			tx.Rollback()
-			sendErrorResponse(w, http.StatusBadRequest, "Die neue Position ist größer als die maximal erlaubte Position")
-    	    return
-    	}
-		if updatedToDo.Order == currentOrder {
-			tx.Rollback()
-			sendErrorResponse(w, http.StatusBadRequest, "Die neue Position ist die gleiche wie die alte Position")
-        	return
-		}
-		// Anpassen der Position (order) der anderen ToDos
-		if updatedToDo.Order > currentOrder {
-			_, err = tx.Exec("UPDATE todos SET `order` = `order` - 1 WHERE `order` > ? AND `order` <= ? AND user_id = ?", currentOrder, updatedToDo.Order, userID)
-		} else if updatedToDo.Order < currentOrder {
-			_, err = tx.Exec("UPDATE todos SET `order` = `order` + 1 WHERE `order` >= ? AND `order` < ? AND user_id = ?", updatedToDo.Order, currentOrder, userID)
-		}	
-		if err != nil{
-			tx.Rollback()
-			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-			return 
-		}
-	}
-
-	// Bilden des SQL Strings für die Aktualisierung
-	args := []interface{}{} 		// -> Slice vom Typ Interface um Argumente der unterschiedlichen Typen aufzunehmen
-	query := "UPDATE todos SET "	// -> SQL Execution String
-
-	if updatedToDo.Title != ""{
-		query += "title = ?, "
-		args = append(args, updatedToDo.Title)
-	}
-	if updatedToDo.Description != ""{
-		query += "description = ?, "
-		args = append(args, updatedToDo.Description)
-	}
-	if updatedToDo.Category != ""{
-		query += "category = ?, "
-		args = append(args, updatedToDo.Category)
+	// Den Inhalt einer geteilten ToDo darf ein Mitglied nur mit "edit" ändern, seine Kategorie, Liste und Position immer
+	contentChanged := updatedToDo.Title != "" || updatedToDo.Description != "" || updatedToDo.DueAt != nil || updatedToDo.Priority != nil || updatedToDo.AutoComplete != nil ||
+		updatedToDo.Recurrence != nil
+	if contentChanged && !sharedAllows(current, callerID(r), models.PermissionEdit) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung zum Bearbeiten dieser geteilten ToDo")
+		return
 	}
-	if updatedToDo.Order != 0{
-		query += "`order` = ?, "
-		args = append(args, updatedToDo.Order)
+	if !s.validListID(w, r, updatedToDo.ListID) {
+		return
 	}
 
-	if len(args) > 0{
-		query += "updated_at = ?"
-		args = append(args, time.Now())
-	} else {
+	// Aktualisieren der ToDo, die Positionen (order) der anderen ToDos in alter und neuer Liste passt der Store an
+	err = s.store.UpdateTodo(int(todoID), callerID(r), updatedToDo)
+	switch err {
+	case nil:
+	case store.ErrNoChanges:
 		sendErrorResponse(w, http.StatusBadRequest, "Keine gültigen Parameter im Request Body")
 		return
-	}
-
-	query += " WHERE id = ?"
-	args = append(args, todoID)
-
-	// Ausführen des SQL Strings für die Aktualisierung der ausgewählten ToDo
-	_, err = tx.Exec(query, args...)
-	if err != nil{
-		tx.Rollback()
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	case store.ErrOrderOutOfRange:
+		sendErrorResponse(w, http.StatusBadRequest, "Die neue Position liegt außerhalb der erlaubten Positionen")
 		return
-	}
-
-	// Commit der Transaktion
-	err = tx.Commit()
-	if err != nil{
+	case store.ErrOrderUnchanged:
+		sendErrorResponse(w, http.StatusBadRequest, "Die neue Position ist die gleiche wie die alte Position")
+		return
+	default:
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -193,7 +123,7 @@ func patchToDoById(w http.ResponseWriter, r *http.Request){
     })
 }
 
-func getToDoById(w http.ResponseWriter, r *http.Request){
+func (s *Server) getToDoById(w http.ResponseWriter, r *http.Request){
 	// Parameter Id auslesen und prüfen
 	todoID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/todo/"), 10, 0)
 	if err != nil{
@@ -201,27 +131,19 @@ func getToDoById(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	// Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
-
-	// SQL Select Abfrage zum einlesen und Umwandeln in eine ToDo Instanz 
-	var todo models.ToDo
-	err = database.Connection.QueryRow("SELECT id, user_id, title, description, category, `order`, created_at, updated_at, completed, original_todo_id FROM todos WHERE id = ?", todoID).Scan(&todo.ID, &todo.UserID, &todo.Title, &todo.Description, &todo.Category, &todo.Order, &todo.CreatedAt, &todo.UpdatedAt, &todo.Completed, &todo.OriginalID)
+	// Einlesen der ToDo aus Sicht des angemeldeten Benutzers
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
 	if err != nil{
-		if err == sql.ErrNoRows{
+		if err == store.ErrNotFound{
 			sendErrorResponse(w, http.StatusBadRequest, "Ungültige todo_id")
 			return
 		}
-		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Authentifizierung prüfen
-    if !authenticateUser(todo.UserID, secretKey) {
+	// Berechtigung prüfen
+    if !canAccess(todo, callerID(r)) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
@@ -232,14 +154,7 @@ func getToDoById(w http.ResponseWriter, r *http.Request){
     json.NewEncoder(w).Encode(todo)
 }
 
-func createTodo(w http.ResponseWriter, r *http.Request) {
-	// Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
-
+func (s *Server) createTodo(w http.ResponseWriter, r *http.Request) {
 	var newTodo models.ToDo // erstellen einer neuen ToDo Instanz
 
 	// Überprüfen ob Json in Struct ToDo umgewandelt werden kann
@@ -249,31 +164,19 @@ func createTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-
-	// Überprüfen, ob die UserID in der User-Tabelle existiert
-	var exists bool
-	err = database.Connection.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", newTodo.UserID).Scan(&exists)
-	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if !exists {
-		sendErrorResponse(w, http.StatusBadRequest, "UserID existiert nicht")
-		return
+	// Ohne user_id gehört die ToDo dem angemeldeten Benutzer, eine fremde user_id ist nicht erlaubt,
+	// sonst kann ein fremder User für mich eine Todo erstellen
+	if newTodo.UserID == 0 {
+		newTodo.UserID = callerID(r)
 	}
-
-	// Authentifizierung prüfen, sonnst kann ein fremder User für mich eine Todo erstellen
-    if !authenticateUser(newTodo.UserID, secretKey) {
+    if newTodo.UserID != callerID(r) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
 
 	// Überprüfen ob alle Parameter enthalten
-	if newTodo.UserID == 0 || newTodo.Title == "" || newTodo.Description == ""{
+	if newTodo.Title == "" || newTodo.Description == ""{
 		missingFields := []string{}
-		if newTodo.UserID == 0 {
-			missingFields = append(missingFields, "UserID")
-		}
 		if newTodo.Title == ""{
 			missingFields = append(missingFields, "Titel")
 		}
@@ -289,32 +192,39 @@ func createTodo(w http.ResponseWriter, r *http.Request) {
 	if newTodo.Category == "" {
 		newTodo.Category = "no category"
     }
-
-	// Ermitteln der Order für die UserID
-	var maxOrderPtr *int
-	err = database.Connection.QueryRow("SELECT MAX(`order`) FROM todos WHERE user_id = ?", newTodo.UserID).Scan(&maxOrderPtr) // Wenn keine Zeile vorhanden ist gibt Max() Null zurück -> Go kann kein Null in int konventieren -> Zwischenschritt mit Pointer
+	if newTodo.DueAt != nil && newTodo.DueAt.IsZero() {
+		newTodo.DueAt = nil // leere Fälligkeit -> keine Fälligkeit
+	}
+	if newTodo.Recurrence != nil && newTodo.Recurrence.IsZero() {
+		newTodo.Recurrence = nil // leere Wiederholung -> keine Wiederholung
+	}
+	tags, err := normalizeTags(newTodo.Tags)
 	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	var maxOrder int
-	if maxOrderPtr != nil{
-		maxOrder = *maxOrderPtr
-	} else {
-		maxOrder = 0 // 0 wenn der User noch keine ToDos hat
+	if !s.validListID(w, r, newTodo.ListID) {
+		return
 	}
 
-	newTodo.Order = maxOrder + 1
-
-	// SQL Befehl zum Einfügen
-	_ , err = database.Connection.Exec("INSERT INTO todos (user_id, title, description, category, `order`, created_at, updated_at, completed, original_todo_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-	newTodo.UserID, newTodo.Title, newTodo.Description, newTodo.Category, newTodo.Order, time.Now(), time.Now(), false, 0) // -> "false", da neue ToDo nicht schon erledigt sein kann
+	// Einfügen am Ende der Liste (bzw. der ToDos ohne Liste) des Benutzers
+	newTodo.Completed = false // neue ToDo kann nicht schon erledigt sein
+	newTodo.Permission = ""
+	err = s.store.CreateTodo(&newTodo)
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// Tags aus dem Request Body anhängen
+	if len(tags) > 0 {
+		err = s.store.AddTags(newTodo.ID, newTodo.UserID, tags)
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
 	// Senden der Antwort
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -325,7 +235,7 @@ func createTodo(w http.ResponseWriter, r *http.Request) {
     })
 }
 
-func deleteToDoById (w http.ResponseWriter, r *http.Request){
+func (s *Server) deleteToDoById(w http.ResponseWriter, r *http.Request){
 	// Parameter Id auslesen und prüfen
 	todoID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/todo/"), 10, 0)
 	if err != nil{
@@ -333,56 +243,37 @@ func deleteToDoById (w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-    // Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
-
-	// Beginn der Transaktion
-	tx, err := database.Connection.Begin()
+	// Prüfen ob todoID vorhanden und userID auslesen
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
 	if err != nil{
+		if err == store.ErrNotFound{
+			sendErrorResponse(w, http.StatusBadRequest, "todo_id nicht vorhanden")
+			return
+		}
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// Prüfen ob todoID vorhand und userID auslesen
-	var currentOrder, userID int
-	err = tx.QueryRow("SELECT `order`, user_id FROM todos WHERE id = ?", todoID).Scan(&currentOrder, &userID) // Position (order) und userID auslesen
-	if err != nil{
-		tx.Rollback()
-		sendErrorResponse(w, http.StatusBadRequest, "todo_id nicht vorhanden")
-		return
-	}
-
-	// Authentifizierung prüfen
-    if !authenticateUser(int(userID), secretKey) {
+	// Berechtigung prüfen
+    if !canAccess(todo, callerID(r)) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
 
-	// Anpassen der Position (order)
-	_, err = tx.Exec("UPDATE todos SET `order` = `order` - 1 WHERE `order` > ? AND user_id = ?", currentOrder, userID) // Positionen (order) der anderen ToDos anpassen 
-    if err != nil {
-		tx.Rollback()
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-        return
-    }
-
-	// Löschen der ToDo
-	_, err = tx.Exec("DELETE FROM todos WHERE id = ?", todoID)
-	if err != nil{
-		tx.Rollback()
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-		return 
+	// Der Eigentümer löscht die ToDo für alle, ein Mitglied entfernt sie nur aus seiner Liste.
+	// Die Positionen (order) der anderen ToDos passt der Store an.
+	if todo.UserID == callerID(r) {
+		err = s.store.DeleteTodo(int(todoID))
+	} else {
+		err = s.store.RemoveMember(int(todoID), callerID(r))
+	}
+	if err == store.ErrGroupAccess {
+		sendErrorResponse(w, http.StatusConflict, groupAccessMessage)
+		return
 	}
-
-	// Commit der Transaktion
-	err = tx.Commit()
 	if err != nil{
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-		return
+		return 
 	}
 
 	// Senden der Antwort
@@ -395,7 +286,7 @@ func deleteToDoById (w http.ResponseWriter, r *http.Request){
     })
 }
 
-func GetTodosByUser(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetTodosByUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur Get Methode erlaubt")
 		return
@@ -407,66 +298,78 @@ func GetTodosByUser(w http.ResponseWriter, r *http.Request) {
 		sendErrorResponse(w, http.StatusBadRequest, "Ungültige userID")
 		return
 	}
-	
-	// Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
 
-	// Authentifizierung prüfen
-    if !authenticateUser(int(userID), secretKey) {
+	// Berechtigung prüfen
+    if int(userID) != callerID(r) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
 
-	// Überprüfen, ob die UserID existiert
-    var exists bool
-    err = database.Connection.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&exists)
-    if err != nil {
-        sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-        return
-    }
-    if !exists {
-        sendErrorResponse(w, http.StatusBadRequest, "User mit dieser ID nicht vorhanden")
-        return
-    }
+	s.listTodos(w, r, int(userID))
+}
+
+// GET /todo/me: ToDos des angemeldeten Benutzers, gleiche Query-Parameter wie /todo/user/{userID}
+func (s *Server) GetOwnTodos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur Get Methode erlaubt")
+		return
+	}
 
-	// Select per SQL Befehl an Datenbank
-	result, err := database.Connection.Query("SELECT id, user_id, title, description, category, `order`, created_at, updated_at, completed, original_todo_id FROM todos WHERE user_id = ?", userID)
+	s.listTodos(w, r, callerID(r))
+}
+
+// Sendet eine Seite der ToDos eines Benutzers, gefiltert und sortiert nach den Query-Parametern
+func (s *Server) listTodos(w http.ResponseWriter, r *http.Request, userID int) {
+	// Filter aus den Query-Parametern auslesen (z.B. ?due=overdue)
+	filter, err := parseTodoFilter(r.URL.Query(), time.Now())
 	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer result.Close()
 
-	// Erstellen eines Slice von todos
-	todos := []models.ToDo{} 
+	s.sendTodoPage(w, userID, filter)
+}
 
-	// Jede SQL Zeile in todo umwandeln und an das Slice todos anfügen
-	for result.Next(){
-		var todo models.ToDo
-		err := result.Scan(&todo.ID, &todo.UserID, &todo.Title, &todo.Description, &todo.Category, &todo.Order, &todo.CreatedAt, &todo.UpdatedAt, &todo.Completed, &todo.OriginalID)
-		if err != nil{
-			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-			return
-		}
-		todos = append(todos, todo)
+// Sendet eine Seite der ToDos eines Benutzers zum bereits ausgelesenen Filter
+func (s *Server) sendTodoPage(w http.ResponseWriter, userID int, filter store.TodoFilter) {
+	todos, nextCursor, err := s.todoPage(userID, filter)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	// Senden der Antwort
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)	
-	json.NewEncoder(w).Encode(todos) 
+	json.NewEncoder(w).Encode(struct {
+		Todos      []models.ToDo `json:"todos"`
+		NextCursor *string       `json:"next_cursor"` // null auf der letzten Seite
+	}{
+		Todos:      todos,
+		NextCursor: nextCursor,
+	})
 }
 
-func ShareToDoByID (w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST"{
-		sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur Post Methode erlaubt")
-		return
+// Eine Seite der ToDos des Benutzers und der Cursor der nächsten Seite (nil auf der letzten Seite)
+func (s *Server) todoPage(userID int, filter store.TodoFilter) ([]models.ToDo, *string, error) {
+	// Eine ToDo mehr abrufen um zu erkennen ob weitere Seiten folgen
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+	todos, err := s.store.GetTodosByUser(userID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nextCursor *string
+	if len(todos) > pageSize {
+		todos = todos[:pageSize]
+		cursor := encodeCursor(filter, store.CursorFor(todos[pageSize-1]))
+		nextCursor = &cursor
 	}
+	return todos, nextCursor, nil
+}
 
+func (s *Server) ShareToDoByID(w http.ResponseWriter, r *http.Request) {
 	// Parameter auslesen und prüfen
 	pathSegments := strings.Split(r.URL.Path, "/") // teilt den Path in seine Bestandteile
 	if len(pathSegments) < 5{
@@ -486,40 +389,38 @@ func ShareToDoByID (w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
-
-	// Authentifizierung prüfen für die ursprüngliche ToDo
-    var originalUser int
-    err = database.Connection.QueryRow("SELECT user_id FROM todos WHERE id = ?", todoID).Scan(&originalUser)
-    if err != nil {
-        sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-        return
-    }
-    if !authenticateUser(originalUser, secretKey) {
+	// Prüfen ob todoID vorhanden ist und Berechtigung prüfen, nur der Eigentümer kann teilen
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+    if !canAccess(todo, callerID(r)) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
+	if todo.UserID != callerID(r) {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann eine ToDo teilen")
+		return
+	}
+	if int(userID) == callerID(r) {
+		sendErrorResponse(w, http.StatusBadRequest, "ToDo kann nicht mit sich selbst geteilt werden")
+		return
+	}
 
-	// Prüfen ob todoID vorhanden ist und Todo einlesen
-	var originalTodo models.ToDo
-	err = database.Connection.QueryRow("SELECT title, description, category, completed FROM todos WHERE id = ?", todoID).Scan(&originalTodo.Title, &originalTodo.Description, &originalTodo.Category, &originalTodo.Completed)
+	// Berechtigung des Empfängers aus dem optionalen Request Body, ohne Angabe "complete"
+	permission, err := decodeSharePermission(r)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
-			return
-		}
 		sendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Prüfen ob userID vorhanden ist 
-	var userExists bool
-	err = database.Connection.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&userExists)
+	userExists, err := s.store.UserExists(int(userID))
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -530,34 +431,22 @@ func ShareToDoByID (w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ermitteln der Order für die UserID
-	var maxOrderPtr *int
-	err = database.Connection.QueryRow("SELECT MAX(`order`) FROM todos WHERE user_id = ?", userID).Scan(&maxOrderPtr) // Wenn keine Zeile vorhanden ist gibt Max() Null zurück -> Go kann kein Null in int konventieren -> Zwischenschritt mit Pointer
-	if err != nil {
+	// Einladung an den Empfänger, erst mit der Annahme erscheint die ToDo in seiner Liste
+	invitation := models.Invitation{TodoID: int(todoID), UserID: int(userID), Permission: permission}
+	err = s.store.CreateInvitation(&invitation)
+	switch err {
+	case nil:
+	case store.ErrAlreadyMember:
+		sendErrorResponse(w, http.StatusConflict, "ToDo ist bereits mit diesem Benutzer geteilt")
+		return
+	case store.ErrInvitationOpen:
+		sendErrorResponse(w, http.StatusConflict, "Einladung an diesen Benutzer ist bereits offen")
+		return
+	default:
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	var maxOrder int
-	if maxOrderPtr != nil{
-		maxOrder = *maxOrderPtr
-	} else {
-		maxOrder = 0 // 0 wenn der User noch keine ToDos hat
-	}
-
-	// Erstellen der neuen Todo
-	var newTodo models.ToDo
-	newTodo.UserID = int(userID)
-	newTodo.Title = originalTodo.Title
-	newTodo.Description = originalTodo.Description
-	newTodo.Category = "shared"
-	newTodo.Order = maxOrder + 1
-	newTodo.Completed = originalTodo.Completed
-	newTodo.OriginalID = int(todoID)
-
-	// SQL Befehl zum Einfügen
-	_ , err = database.Connection.Exec("INSERT INTO todos (user_id, title, description, category, `order`, created_at, updated_at, completed, original_todo_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-	newTodo.UserID, newTodo.Title, newTodo.Description, newTodo.Category, newTodo.Order, time.Now(), time.Now(), newTodo.Completed, newTodo.OriginalID) // -> "false", da neue ToDo nicht schon erledigt sein kann
+	invitation, err = s.store.GetInvitation(invitation.ID) // mit Titel und Eigentümer der ToDo
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
@@ -565,15 +454,11 @@ func ShareToDoByID (w http.ResponseWriter, r *http.Request) {
 
 	// Senden der Antwort
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(struct {
-		Message string `json:"message"`
-	}{
-		Message: "ToDo erfolgreich geteilt",
-	})
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
 }
 
-func UpdateToDoStatus(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdateToDoStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "PATCH" {
         sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur PATCH Methode erlaubt")
         return
@@ -592,13 +477,6 @@ func UpdateToDoStatus(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	// Secret Key aus dem Header auslesen
-    secretKey := r.Header.Get("Secret-Key")
-    if secretKey == "" {
-        sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
-        return
-    }
-
 	// Request Body auslesen
 	var updatedTodo models.ToDo
 	err = json.NewDecoder(r.Body).Decode(&updatedTodo)
@@ -607,71 +485,43 @@ func UpdateToDoStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Start der Transaktion
-	tx, err := database.Connection.Begin()
-	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	
-	// Abrufen der userID und der original_todo_id
-	var userID, originalTodoID int
-	err = tx.QueryRow("SELECT user_id, original_todo_id FROM todos WHERE id = ?", todoID).Scan(&userID, &originalTodoID)
+	// Abrufen der ToDo für die Berechtigung
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
 	if err != nil {
-		tx.Rollback()
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+			return
+		}
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-    // Authentifizierung prüfen
-    if !authenticateUser(userID, secretKey) {
-        tx.Rollback()
+    // Berechtigung prüfen
+    if !canAccess(todo, callerID(r)) {
         sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
         return
     }
+	if !sharedAllows(todo, callerID(r), models.PermissionComplete) {
+		sendErrorResponse(w, http.StatusForbidden, "Keine Berechtigung zum Abschließen dieser geteilten ToDo")
+		return
+	}
 
-    // Update-Logik
-    if originalTodoID == 0 { // Aktuelles ToDo ist das Original
-        // Aktualisieren des Originals und aller verknüpften ToDos
-        if _, err = tx.Exec("UPDATE todos SET completed = ? WHERE id = ? OR original_todo_id = ?", updatedTodo.Completed, todoID, todoID); err != nil {
-            tx.Rollback()
-            sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-            return
-        }
-    } else { // Aktuelles ToDo ist eine Kopie
-        // Aktualisieren nur des Original-ToDos
-        if _, err = tx.Exec("UPDATE todos SET completed = ? WHERE id = ?", updatedTodo.Completed, originalTodoID); err != nil {
-            tx.Rollback()
-            sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-            return
-        }
-        // Zusätzlich Aktualisieren aller verknüpften ToDos
-        if _, err = tx.Exec("UPDATE todos SET completed = ? WHERE original_todo_id = ?", updatedTodo.Completed, originalTodoID); err != nil {
-            tx.Rollback()
-            sendErrorResponse(w, http.StatusInternalServerError, err.Error())
-            return
-        }
-    }
-		/*
-		Nicht behandelte Edge Cases:
-			- Ich kann Todos mit mir selbst teilen
-			- Wenn Todo auf eine gelöschte Origanl Todo verweist -> was dann? -> kein Fehler -> der Status der geteilten Todo verändert sich 
-			- Wenn eine Todo geteilt wird und die geteilte Todo wieder geteilt wird -> was dann? -> Nur eine Anpassung der angesprochenen Todo und derer original Todo, die urpsüngliche Todo bleibt unverändert bis zu den Zeitpunkt wo Sie oder die geteilte Version angesprochen werden
-		*/
-
-	// Commit der Transaktion
-	if err := tx.Commit(); err != nil {
+    // Aktualisieren des Status, gilt für alle Mitglieder der ToDo. Bei wiederkehrenden ToDos legt der Store den nächsten Termin an.
+	nextID, err := s.store.SetTodoStatus(int(todoID), updatedTodo.Completed)
+	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	
+
 	// Senden der Antwort
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(struct {
 		Message string `json:"message"`
+		NextID  int    `json:"next_id,omitempty"` // ID des nächsten Termins einer wiederkehrenden ToDo
 	}{
 		Message: "ToDo-Status erfolgreich aktualisiert",
+		NextID:  nextID,
 	})
 }
 
@@ -687,16 +537,4 @@ func sendErrorResponse (w http.ResponseWriter, statusCode int, errorMessage stri
 		Statuscode: statusCode,
 		Error: errorMessage,
 	})
-}
-
-func authenticateUser(userID int, secretKey string) bool {
-
-    // Logik zum Überprüfen der Authentifizierung
-    var storedSecretKey string
-    err := database.Connection.QueryRow("SELECT secret_key FROM users WHERE id = ?", userID).Scan(&storedSecretKey)
-    if err != nil {
-        return false
-    }
-
-    return secretKey == storedSecretKey
 }
\ No newline at end of file
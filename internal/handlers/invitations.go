@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// /invitations und /invitations/{invitationID}/{accept|decline}: Einladungen an den angemeldeten Benutzer zu geteilten ToDos,
+// /invitations/groups und /invitations/groups/{invitationID}/{accept|decline}: Einladungen in Gruppen
+func (s *Server) InvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	pathSegments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/invitations"), "/"), "/")
+
+	if pathSegments[0] == "groups" {
+		s.groupInvitationsHandler(w, r, pathSegments[1:])
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && pathSegments[0] == "":
+		s.getInvitations(w, r) // GET /invitations: offene Einladungen, älteste zuerst
+	case r.Method == http.MethodPost && len(pathSegments) == 2 && pathSegments[1] == "accept":
+		s.answerInvitation(w, r, pathSegments[0], true) // POST /invitations/{invitationID}/accept: Einladung annehmen
+	case r.Method == http.MethodPost && len(pathSegments) == 2 && pathSegments[1] == "decline":
+		s.answerInvitation(w, r, pathSegments[0], false) // POST /invitations/{invitationID}/decline: Einladung ablehnen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+func (s *Server) getInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := s.store.GetInvitationsByUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invitations)
+}
+
+func (s *Server) answerInvitation(w http.ResponseWriter, r *http.Request, idParam string, accept bool) {
+	invitationID, err := strconv.ParseInt(idParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+		return
+	}
+
+	// Nur der Empfänger kann seine Einladung beantworten
+	invitation, err := s.store.GetInvitation(int(invitationID))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if invitation.UserID != callerID(r) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return
+	}
+
+	if !accept {
+		err = s.store.DeleteInvitation(invitation.ID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+				return
+			}
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// Senden der Antwort
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(struct {
+			Message string `json:"message"`
+		}{
+			Message: "Einladung abgelehnt",
+		})
+		return
+	}
+
+	// Annehmen: die ToDo erscheint mit Kategorie "shared" am Ende der Liste des Empfängers
+	todo, err := s.store.AcceptInvitation(invitation.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(todo)
+}
+
+func (s *Server) groupInvitationsHandler(w http.ResponseWriter, r *http.Request, pathSegments []string) {
+	switch {
+	case r.Method == http.MethodGet && len(pathSegments) == 0:
+		s.getGroupInvitations(w, r) // GET /invitations/groups: offene Einladungen in Gruppen, älteste zuerst
+	case r.Method == http.MethodPost && len(pathSegments) == 2 && pathSegments[1] == "accept":
+		s.answerGroupInvitation(w, r, pathSegments[0], true) // POST /invitations/groups/{invitationID}/accept: Einladung annehmen
+	case r.Method == http.MethodPost && len(pathSegments) == 2 && pathSegments[1] == "decline":
+		s.answerGroupInvitation(w, r, pathSegments[0], false) // POST /invitations/groups/{invitationID}/decline: Einladung ablehnen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+func (s *Server) getGroupInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := s.store.GetGroupInvitationsByUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invitations)
+}
+
+func (s *Server) answerGroupInvitation(w http.ResponseWriter, r *http.Request, idParam string, accept bool) {
+	invitationID, err := strconv.ParseInt(idParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+		return
+	}
+
+	// Nur der Empfänger kann seine Einladung beantworten
+	invitation, err := s.store.GetGroupInvitation(int(invitationID))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if invitation.UserID != callerID(r) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return
+	}
+
+	if !accept {
+		err = s.store.DeleteGroupInvitation(invitation.ID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+				return
+			}
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// Senden der Antwort
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(struct {
+			Message string `json:"message"`
+		}{
+			Message: "Einladung abgelehnt",
+		})
+		return
+	}
+
+	// Annehmen: der Empfänger wird Mitglied und sieht ab jetzt alle mit der Gruppe geteilten ToDos
+	group, err := s.store.AcceptGroupInvitation(invitation.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige invitation_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(group)
+}
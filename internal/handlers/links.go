@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Query-Parameter, die nicht zum Filter eines Links gehören, sondern bei jedem Abruf angegeben werden
+var pageParams = []string{"limit", "cursor"}
+
+// /todo/{todoID}/links und /todo/{todoID}/links/{linkID}: öffentliche Links zu einer ToDo, nur für den Eigentümer
+func (s *Server) TodoLinksHandler(w http.ResponseWriter, r *http.Request) {
+	pathSegments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/todo/"), "/"), "/") // {todoID}, links[, {linkID}]
+	todoID, err := strconv.ParseInt(pathSegments[0], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+		return
+	}
+
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !canAccess(todo, callerID(r)) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return
+	}
+	if todo.UserID != callerID(r) {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann öffentliche Links verwalten")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(pathSegments) == 2:
+		s.getShareLinks(w, r, &todo.ID) // GET /todo/{todoID}/links: Links zur ToDo
+	case r.Method == http.MethodPost && len(pathSegments) == 2:
+		s.createShareLink(w, r, models.ShareLink{TodoID: &todo.ID}) // POST /todo/{todoID}/links: Link erzeugen
+	case r.Method == http.MethodDelete && len(pathSegments) == 3:
+		s.revokeShareLink(w, r, pathSegments[2], &todo.ID) // DELETE /todo/{todoID}/links/{linkID}: Link widerrufen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+// /todo/me/links und /todo/me/links/{linkID}: alle öffentlichen Links des angemeldeten Benutzers und Links zu gefilterten Listen
+func (s *Server) OwnLinksHandler(w http.ResponseWriter, r *http.Request) {
+	linkParam := strings.Trim(strings.TrimPrefix(r.URL.Path, "/todo/me/links"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && linkParam == "":
+		s.getShareLinks(w, r, nil) // GET /todo/me/links: alle Links
+	case r.Method == http.MethodPost && linkParam == "":
+		s.createListLink(w, r) // POST /todo/me/links?category=...: Link zur gefilterten Liste erzeugen
+	case r.Method == http.MethodDelete && linkParam != "":
+		s.revokeShareLink(w, r, linkParam, nil) // DELETE /todo/me/links/{linkID}: Link widerrufen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+// Links des angemeldeten Benutzers, mit todoID nur die Links zu dieser ToDo
+func (s *Server) getShareLinks(w http.ResponseWriter, r *http.Request, todoID *int) {
+	links, err := s.store.GetShareLinksByUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if todoID != nil {
+		filtered := []models.ShareLink{}
+		for _, link := range links {
+			if link.TodoID != nil && *link.TodoID == *todoID {
+				filtered = append(filtered, link)
+			}
+		}
+		links = filtered
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(links)
+}
+
+func (s *Server) createListLink(w http.ResponseWriter, r *http.Request) {
+	// Filter wie bei GET /todo/me prüfen, gespeichert werden die Query-Parameter und beim Abruf neu ausgewertet (z.B. due=today)
+	query := r.URL.Query()
+	_, err := parseTodoFilter(query, time.Now())
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.Get("shared") == "true" {
+		sendErrorResponse(w, http.StatusBadRequest, "Öffentliche Listen enthalten nur eigene ToDos")
+		return
+	}
+	query.Del("shared")
+	for _, name := range pageParams {
+		query.Del(name)
+	}
+
+	s.createShareLink(w, r, models.ShareLink{Filter: query.Encode()})
+}
+
+// Erzeugt einen Link mit neuem Token, Aufrufer prüft den Scope share
+func (s *Server) createShareLink(w http.ResponseWriter, r *http.Request, link models.ShareLink) {
+	// Request Body auslesen (optional)
+	var body struct {
+		ExpiresAt *time.Time `json:"expires_at"` // RFC3339, optional
+	}
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+			return
+		}
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		sendErrorResponse(w, http.StatusBadRequest, "Ablaufdatum liegt in der Vergangenheit")
+		return
+	}
+	link.ExpiresAt = body.ExpiresAt
+
+	// Neuen Token erzeugen, gespeichert wird nur sein Hash
+	token, err := auth.GenerateSecret()
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	err = s.store.CreateShareLink(callerID(r), &link, auth.LookupKey(token))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort, der Token wird nur dieses eine Mal übermittelt
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Link  models.ShareLink `json:"link"`
+		Token string           `json:"token"`
+		Path  string           `json:"path"` // Pfad zum Abruf ohne Anmeldung
+	}{
+		Link:  link,
+		Token: token,
+		Path:  "/public/" + token,
+	})
+}
+
+// Widerruft einen Link des angemeldeten Benutzers, mit todoID nur einen Link zu dieser ToDo
+func (s *Server) revokeShareLink(w http.ResponseWriter, r *http.Request, linkParam string, todoID *int) {
+	linkID, err := strconv.ParseInt(linkParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige link_id")
+		return
+	}
+	if todoID != nil {
+		links, err := s.store.GetShareLinksByUser(callerID(r))
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		found := false
+		for _, link := range links {
+			found = found || (link.ID == int(linkID) && link.TodoID != nil && *link.TodoID == *todoID)
+		}
+		if !found {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige link_id")
+			return
+		}
+	}
+
+	err = s.store.RevokeShareLink(callerID(r), int(linkID))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige link_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Link erfolgreich widerrufen",
+	})
+}
+
+// GET /public/{token}: ToDo bzw. gefilterte Liste eines öffentlichen Links, ohne Anmeldung und nur lesend
+func (s *Server) PublicHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur Get Methode erlaubt")
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/public/")
+	link, userID, err := s.store.ResolveShareLink(auth.LookupKey(token))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusNotFound, "Link ungültig oder abgelaufen")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Link zu einer gefilterten Liste: nur eigene ToDos des Erstellers, Pagination wie bei GET /todo/me
+	if link.TodoID == nil {
+		query, err := url.ParseQuery(link.Filter)
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, name := range pageParams {
+			if value := r.URL.Query().Get(name); value != "" {
+				query.Set(name, value)
+			}
+		}
+		query.Set("shared", "false")
+		filter, err := parseTodoFilter(query, time.Now())
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		todos, nextCursor, err := s.todoPage(userID, filter)
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		page := struct {
+			Todos      []publicTodo `json:"todos"`
+			NextCursor *string      `json:"next_cursor"` // null auf der letzten Seite
+		}{Todos: []publicTodo{}, NextCursor: nextCursor}
+		for _, todo := range todos {
+			public, err := s.publicTodo(todo)
+			if err != nil {
+				sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			page.Todos = append(page.Todos, public)
+		}
+
+		// Senden der Antwort
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(page)
+		return
+	}
+
+	// Link zu einer ToDo, die ToDo muss dem Ersteller noch gehören
+	todo, err := s.store.GetTodo(*link.TodoID, userID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusNotFound, "Link ungültig oder abgelaufen")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if todo.UserID != userID {
+		sendErrorResponse(w, http.StatusNotFound, "Link ungültig oder abgelaufen")
+		return
+	}
+	public, err := s.publicTodo(todo)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(public)
+}
+
+// Öffentliche Ansicht einer ToDo über einen Link: nur Inhalt, Status, Fälligkeit und Checkliste, keine IDs, Benutzer oder Tags
+type publicTodo struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Completed   bool                  `json:"completed"`
+	DueAt       *models.DueTime       `json:"due_at,omitempty"`
+	Checklist   []publicChecklistItem `json:"checklist"`
+}
+
+type publicChecklistItem struct {
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+func (s *Server) publicTodo(todo models.ToDo) (publicTodo, error) {
+	items, err := s.store.GetChecklist(todo.ID)
+	if err != nil {
+		return publicTodo{}, err
+	}
+
+	public := publicTodo{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		DueAt:       todo.DueAt,
+		Checklist:   []publicChecklistItem{},
+	}
+	for _, item := range items {
+		public.Checklist = append(public.Checklist, publicChecklistItem{Title: item.Title, Completed: item.Completed})
+	}
+	return public, nil
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const maxListNameLength = 50 // maximale Länge eines Listennamens
+
+// /lists und /lists/{listID}: Listen (Projekte) des angemeldeten Benutzers
+func (s *Server) ListsHandler(w http.ResponseWriter, r *http.Request) {
+	listParam := strings.Trim(strings.TrimPrefix(r.URL.Path, "/lists"), "/")
+	if listParam == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.getLists(w, r) // GET /lists: alle Listen mit Anzahl der ToDos
+		case http.MethodPost:
+			s.createList(w, r) // POST /lists: Liste anlegen
+		default:
+			sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+		}
+		return
+	}
+
+	listID, err := strconv.ParseInt(listParam, 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getList(w, r, int(listID)) // GET /lists/{listID}: einzelne Liste
+	case http.MethodPatch:
+		s.renameList(w, r, int(listID)) // PATCH /lists/{listID}: umbenennen
+	case http.MethodDelete:
+		s.deleteList(w, r, int(listID)) // DELETE /lists/{listID}: Liste löschen, die ToDos bleiben erhalten
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+// Prüft eine list_id aus dem Request Body, nil und 0 (ohne Liste) sind immer gültig, sonst muss die Liste dem
+// angemeldeten Benutzer gehören. Sendet bei Fehlern selbst die Antwort und liefert dann false.
+func (s *Server) validListID(w http.ResponseWriter, r *http.Request, listID *int) bool {
+	if listID == nil || *listID == 0 {
+		return true
+	}
+	if *listID < 0 {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+		return false
+	}
+
+	_, err := s.store.GetList(*listID, callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+			return false
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	return true
+}
+
+func (s *Server) getLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := s.store.GetListsByUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lists)
+}
+
+func (s *Server) createList(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeName(w, r, maxListNameLength)
+	if !ok {
+		return
+	}
+
+	list := models.List{Name: name}
+	err := s.store.CreateList(callerID(r), &list)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *Server) getList(w http.ResponseWriter, r *http.Request, listID int) {
+	list, err := s.store.GetList(listID, callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *Server) renameList(w http.ResponseWriter, r *http.Request, listID int) {
+	name, ok := decodeName(w, r, maxListNameLength)
+	if !ok {
+		return
+	}
+	err := s.store.RenameList(listID, callerID(r), name)
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Liste erfolgreich umbenannt",
+	})
+}
+
+func (s *Server) deleteList(w http.ResponseWriter, r *http.Request, listID int) {
+	// Die ToDos der Liste rücken in ihrer Reihenfolge ans Ende der ToDos ohne Liste
+	err := s.store.DeleteList(listID, callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige list_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Liste erfolgreich gelöscht",
+	})
+}
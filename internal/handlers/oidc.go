@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+var errIdentityProvider = errors.New("Identity Provider nicht erreichbar")
+
+// Prüft einen Token des OpenID Connect Providers und ermittelt den zugehörigen Benutzer. Beim ersten Login
+// wird der Benutzer automatisch angelegt. Die Anmeldung über den Provider hat alle Berechtigungen.
+func (s *Server) principalForOIDC(token string) (auth.Principal, error) {
+	identity, err := s.oidc.Verify(token)
+	switch err {
+	case nil:
+	case auth.ErrTokenExpired:
+		return auth.Principal{}, invalidTokenError("Access Token abgelaufen")
+	case auth.ErrInvalidToken:
+		return auth.Principal{}, invalidTokenError("Ungültiger Access Token")
+	default:
+		s.logger.Printf("Schlüssel von %s konnten nicht geladen werden: %v", s.oidc.Issuer(), err)
+		return auth.Principal{}, errIdentityProvider
+	}
+
+	userID, err := s.store.UserForIdentity(identity.Issuer, identity.Subject)
+	if err == store.ErrNotFound {
+		userID, err = s.provisionUser(identity)
+	}
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	return auth.Principal{UserID: userID, Scopes: []string{auth.ScopeAdmin}}, nil
+}
+
+// Legt den Benutzer zu einer neuen Identität an. Die E-Mail wird nur übernommen, wenn der Provider sie bestätigt hat
+// und sie noch nicht vergeben ist, bestehende Konten werden nie automatisch verknüpft.
+func (s *Server) provisionUser(identity auth.Identity) (int, error) {
+	name := identity.Name
+	if name == "" {
+		name = identity.Email
+	}
+	if name == "" {
+		name = identity.Subject
+	}
+	if runes := []rune(name); len(runes) > maxDisplayNameLength {
+		name = string(runes[:maxDisplayNameLength])
+	}
+	email := ""
+	if identity.EmailVerified {
+		email = identity.Email
+	}
+
+	user, err := normalizeProfile(models.User{DisplayName: name, Email: email})
+	if err != nil {
+		user.Email = "" // ungültige E-Mail des Providers
+	}
+
+	err = s.store.CreateUserWithIdentity(&user, identity.Issuer, identity.Subject)
+	if err == store.ErrEmailExists {
+		user.Email = ""
+		err = s.store.CreateUserWithIdentity(&user, identity.Issuer, identity.Subject)
+	}
+	if err != nil {
+		// Gleichzeitiger erster Login derselben Identität, der andere Request hat den Benutzer bereits angelegt
+		if userID, lookupErr := s.store.UserForIdentity(identity.Issuer, identity.Subject); lookupErr == nil {
+			return userID, nil
+		}
+		return 0, err
+	}
+
+	s.logger.Printf("Benutzer %d für %s (%s) angelegt", user.ID, identity.Subject, identity.Issuer)
+	return user.ID, nil
+}
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/auth/oidctest"
+	"github.com/Paul-frank/todo-api/internal/handlers"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Server wie newTestServer, zusätzlich mit Anmeldung über den OIDC Provider des verifier
+func newOIDCServer(t *testing.T, verifier *auth.OIDCVerifier) *httptest.Server {
+	t.Helper()
+	memory := store.NewMemoryStore()
+	memory.AddUser(annaKey)
+	memory.AddUser(benKey)
+
+	server := httptest.NewServer(handlers.NewServer(handlers.Options{
+		Store:  memory,
+		Logger: log.New(io.Discard, "", 0),
+		OIDC:   verifier,
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOIDCLogin(t *testing.T) {
+	t.Parallel()
+	issuer := oidctest.NewIssuer(t)
+	server := newOIDCServer(t, auth.NewOIDCVerifier(auth.OIDCConfig{Issuer: issuer.URL, Audience: oidctest.Audience, HTTPClient: issuer.Client}))
+
+	// Bestätigte E-Mail, die Anna bereits verwendet: Carla wird ohne E-Mail angelegt
+	carla := issuer.Claims("carla")
+	carla["name"] = "Carla"
+	carla["email"] = "anna@example.com"
+	carla["email_verified"] = true
+	carlaToken := "Bearer " + issuer.Token(t, "k1", carla)
+
+	dora := issuer.Claims("dora")
+	dora["preferred_username"] = "dora"
+	dora["email"] = "dora@example.com"
+	dora["email_verified"] = true
+	doraToken := "Bearer " + issuer.Token(t, "k1", dora)
+
+	unverified := issuer.Claims("emil")
+	unverified["name"] = "Emil"
+	unverified["email"] = "emil@example.com"
+
+	wrongAudience := issuer.Claims("carla")
+	wrongAudience["aud"] = "andere"
+	expired := issuer.Claims("carla")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := issuer.Claims("carla")
+	wrongIssuer["iss"] = "https://idp.example.com"
+
+	runSteps(t, server, []step{
+		{"E-Mail vergeben", "PATCH", "/users/me", annaKey, `{"email":"anna@example.com"}`, http.StatusOK, ""},
+		{"erster Login", "GET", "/users/me", carlaToken, "", http.StatusOK, `{"id":3,"display_name":"Carla","created_at"`},
+		{"erneuter Login", "GET", "/users/me", carlaToken, "", http.StatusOK, `{"id":3,"display_name":"Carla","created_at"`},
+		{"mit E-Mail", "GET", "/users/me", doraToken, "", http.StatusOK, `{"id":4,"display_name":"dora","email":"dora@example.com"`},
+		{"unbestätigte E-Mail", "GET", "/users/me", "Bearer " + issuer.Token(t, "k1", unverified), "", http.StatusOK, `{"id":5,"display_name":"Emil","created_at"`},
+		{"ToDo anlegen", "POST", "/todo", carlaToken, `{"title":"SSO","description":"über den Provider"}`, http.StatusCreated, ""},
+		{"ToDo lesen", "GET", "/todo/me", carlaToken, "", http.StatusOK, `"title":"SSO"`},
+		{"falsche aud", "GET", "/todo/me", "Bearer " + issuer.Token(t, "k1", wrongAudience), "", http.StatusUnauthorized, "Ungültiger Access Token"},
+		{"abgelaufen", "GET", "/todo/me", "Bearer " + issuer.Token(t, "k1", expired), "", http.StatusUnauthorized, "Access Token abgelaufen"},
+		{"falscher iss", "GET", "/todo/me", "Bearer " + issuer.Token(t, "k1", wrongIssuer), "", http.StatusUnauthorized, "Ungültiger Access Token"},
+
+		// Die Anmeldung über den Provider bleibt, daher darf auch der einzige API Key widerrufen werden
+		{"Key anlegen", "POST", "/users/me/keys", carlaToken, `{"name":"skript"}`, http.StatusCreated, `"id":3`},
+		{"einzigen Key widerrufen", "DELETE", "/users/me/keys/3", carlaToken, "", http.StatusOK, ""},
+		{"ohne Identität nicht", "DELETE", "/users/me/keys/1", annaKey, "", http.StatusBadRequest, "kann nicht widerrufen werden"},
+	})
+
+	discovery, jwks := issuer.Requests()
+	if discovery != 1 || jwks != 1 {
+		t.Fatalf("%d Discovery und %d JWKS Abrufe, erwartet je einer", discovery, jwks)
+	}
+}
+
+// Können die Schlüssel des Providers nicht geladen werden, antwortet die API mit 503
+func TestOIDCProviderUnavailable(t *testing.T) {
+	t.Parallel()
+	issuer := oidctest.NewIssuer(t)
+	server := newOIDCServer(t, auth.NewOIDCVerifier(auth.OIDCConfig{Issuer: issuer.URL, Audience: oidctest.Audience, JWKSURL: issuer.URL + "/fehlt", HTTPClient: issuer.Client}))
+
+	runSteps(t, server, []step{
+		{"Provider nicht erreichbar", "GET", "/todo/me", "Bearer " + issuer.Token(t, "k1", issuer.Claims("carla")), "", http.StatusServiceUnavailable, "Identity Provider nicht erreichbar"},
+		{"Secret Key weiterhin", "GET", "/todo/me", annaKey, "", http.StatusOK, ""},
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20  // Anzahl der Treffer ohne limit
+	maxSearchLimit     = 100 // größtes erlaubtes limit
+)
+
+// GET /todo/search?q={suchbegriffe}[&limit=n]: Volltextsuche in den eigenen und geteilten ToDos des angemeldeten Benutzers
+func (s *Server) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+		return
+	}
+	// Parameter auslesen und prüfen
+	query := r.URL.Query()
+	searchQuery := strings.TrimSpace(query.Get("q"))
+	if searchQuery == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Suchbegriff fehlt")
+		return
+	}
+	limit := defaultSearchLimit
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültiger Wert für limit (1 bis "+strconv.Itoa(maxSearchLimit)+")")
+			return
+		}
+	}
+
+	// Mit dem Benutzer geteilte ToDos werden mit durchsucht
+	results, err := s.store.SearchTodos(callerID(r), searchQuery, limit)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Einstellungen des Servers
+type Config struct {
+	Addr            string        // Adresse für ListenAndServe, z.B. ":8080"
+	AccessTokenTTL  time.Duration // Gültigkeit der Access Tokens, Standard 15 Minuten
+	RefreshTokenTTL time.Duration // Gültigkeit der Refresh Tokens, Standard 30 Tage
+}
+
+// Abhängigkeiten und Einstellungen für NewServer
+type Options struct {
+	Store  store.Store        // Datenzugriff (Pflicht)
+	Logger *log.Logger        // optional, Standard ist log.Default()
+	Tokens *auth.TokenIssuer  // optional, Standard ist ein zufälliger HS256 Schlüssel, Tokens gelten dann nur bis zum Neustart
+	OIDC   *auth.OIDCVerifier // optional, ohne Angabe keine Anmeldung über einen OpenID Connect Provider
+	Config Config
+}
+
+// Eine unabhängige Instanz der API. Alle Abhängigkeiten hängen an der Instanz,
+// sodass mehrere Server (z.B. parallele httptest Server) im selben Prozess laufen können.
+type Server struct {
+	store   store.Store
+	logger  *log.Logger
+	tokens  *auth.TokenIssuer
+	oidc    *auth.OIDCVerifier
+	config  Config
+	handler http.Handler // Router mit allen Endpunkten
+}
+
+func NewServer(opts Options) *Server {
+	if opts.Store == nil {
+		panic("handlers: NewServer ohne Store")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Default()
+	}
+	if opts.Config.Addr == "" {
+		opts.Config.Addr = ":8080"
+	}
+	if opts.Config.AccessTokenTTL == 0 {
+		opts.Config.AccessTokenTTL = 15 * time.Minute
+	}
+	if opts.Config.RefreshTokenTTL == 0 {
+		opts.Config.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+	if opts.Tokens == nil {
+		tokens, err := auth.NewRandomIssuer()
+		if err != nil {
+			panic("handlers: kein Schlüssel für Access Tokens: " + err.Error())
+		}
+		opts.Logger.Printf("Kein Schlüssel für Access Tokens konfiguriert, Tokens gelten nur bis zum Neustart")
+		opts.Tokens = tokens
+	}
+
+	s := &Server{
+		store:  opts.Store,
+		logger: opts.Logger,
+		tokens: opts.Tokens,
+		oidc:   opts.OIDC,
+		config: opts.Config,
+	}
+	s.handler = s.routes()
+	return s
+}
+
+// Registriert alle Endpunkte auf einem eigenen ServeMux. Endpunkte hinter requireAuth kennen den
+// angemeldeten Benutzer über callerID, der benötigte Scope wird hier je Endpunkt und Methode festgelegt.
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/todo/status/", s.requireAuth(scope(auth.ScopeWrite), s.UpdateToDoStatus))
+	mux.HandleFunc("/todo/user/", s.requireAuth(scope(auth.ScopeRead), s.GetTodosByUser))
+	mux.HandleFunc("/todo/me", s.requireAuth(scope(auth.ScopeRead), s.GetOwnTodos))
+	mux.HandleFunc("/todo/me/links", s.requireAuth(linkScopes, s.OwnLinksHandler))
+	mux.HandleFunc("/todo/me/links/", s.requireAuth(linkScopes, s.OwnLinksHandler))
+	mux.HandleFunc("/todo/share/", s.requireAuth(shareScopes{
+		http.MethodGet:    auth.ScopeRead,
+		http.MethodPost:   auth.ScopeShare,
+		http.MethodPatch:  auth.ScopeShare,
+		http.MethodDelete: auth.ScopeShare,
+	}, s.ShareHandler))
+	mux.HandleFunc("/todo/search", s.requireAuth(scope(auth.ScopeRead), s.SearchTodos))
+	mux.HandleFunc("/invitations", s.requireAuth(invitationScopes, s.InvitationsHandler))
+	mux.HandleFunc("/invitations/", s.requireAuth(invitationScopes, s.InvitationsHandler))
+	mux.HandleFunc("/lists", s.requireAuth(listScopes, s.ListsHandler))
+	mux.HandleFunc("/lists/", s.requireAuth(listScopes, s.ListsHandler))
+	mux.HandleFunc("/groups", s.requireAuth(groupScopes, s.GroupsHandler))
+	mux.HandleFunc("/groups/", s.requireAuth(groupScopes, s.GroupsHandler))
+	mux.HandleFunc("/todo", s.requireAuth(scope(auth.ScopeWrite), s.ToDoHandler))
+	mux.HandleFunc("/todo/", s.requireAuth(todoScopes{
+		"":      {http.MethodGet: auth.ScopeRead, http.MethodPatch: auth.ScopeWrite, http.MethodDelete: auth.ScopeAdmin},
+		"tags":  {http.MethodPost: auth.ScopeWrite, http.MethodDelete: auth.ScopeWrite},
+		"links": linkScopes,
+		"items": {http.MethodGet: auth.ScopeRead, http.MethodPost: auth.ScopeWrite, http.MethodPatch: auth.ScopeWrite, http.MethodDelete: auth.ScopeWrite},
+	}, s.ToDoParameterHandler))
+	mux.HandleFunc("/tags/user/", s.requireAuth(methodScopes{http.MethodGet: auth.ScopeRead, http.MethodPatch: auth.ScopeWrite}, s.TagsHandler))
+	mux.HandleFunc("/auth/token", s.TokenHandler)
+	mux.HandleFunc("/public/", s.PublicHandler)
+	mux.HandleFunc("/users", s.UsersHandler)
+	mux.HandleFunc("/users/me", s.requireAuth(methodScopes{http.MethodGet: auth.ScopeRead, http.MethodPatch: auth.ScopeAdmin, http.MethodDelete: auth.ScopeAdmin}, s.CurrentUserHandler))
+	mux.HandleFunc("/users/me/keys", s.requireAuth(scope(auth.ScopeAdmin), s.APIKeysHandler)) // nur mit allen Berechtigungen, sonst könnte sich ein eingeschränkter Key erweitern
+	mux.HandleFunc("/users/me/keys/", s.requireAuth(scope(auth.ScopeAdmin), s.APIKeysHandler))
+	return mux
+}
+
+// Scopes der Endpunkte, die unter mehreren Pfaden registriert sind
+var (
+	linkScopes       = methodScopes{http.MethodGet: auth.ScopeRead, http.MethodPost: auth.ScopeShare, http.MethodDelete: auth.ScopeShare}
+	invitationScopes = methodScopes{http.MethodGet: auth.ScopeRead, http.MethodPost: auth.ScopeWrite}
+	listScopes       = methodScopes{http.MethodGet: auth.ScopeRead, http.MethodPost: auth.ScopeWrite, http.MethodPatch: auth.ScopeWrite, http.MethodDelete: auth.ScopeWrite}
+	groupScopes      = methodScopes{http.MethodGet: auth.ScopeRead, http.MethodPost: auth.ScopeShare, http.MethodPatch: auth.ScopeShare, http.MethodDelete: auth.ScopeShare}
+)
+
+// Legt fest, welchen Scope ein Request an einen Endpunkt benötigt, geprüft von requireAuth vor dem Handler
+type scopeRule interface {
+	scopeFor(r *http.Request) string
+}
+
+// Ein Scope für alle Methoden des Endpunkts
+type scope string
+
+func (s scope) scopeFor(r *http.Request) string {
+	return string(s)
+}
+
+// Scope je HTTP-Methode. Nicht aufgeführte Methoden benötigen admin, der Handler lehnt sie dann selbst ab.
+type methodScopes map[string]string
+
+func (m methodScopes) scopeFor(r *http.Request) string {
+	if name, ok := m[r.Method]; ok {
+		return name
+	}
+	return auth.ScopeAdmin
+}
+
+// Scopes einer ToDo (Schlüssel "") und ihrer Unterressourcen /todo/{todoID}/{name}/...
+type todoScopes map[string]methodScopes
+
+func (t todoScopes) scopeFor(r *http.Request) string {
+	pathSegments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/todo/"), "/"), "/")
+	name := ""
+	if len(pathSegments) > 1 {
+		name = pathSegments[1]
+	}
+	return t[name].scopeFor(r)
+}
+
+// Scopes der Freigaben /todo/share/{todoID}/{userID}. Verlässt ein Mitglied die ToDo (DELETE mit der eigenen userID),
+// genügt write, wie beim Annehmen und Ablehnen einer Einladung.
+type shareScopes methodScopes
+
+func (m shareScopes) scopeFor(r *http.Request) string {
+	pathSegments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/todo/share/"), "/"), "/")
+	if r.Method == http.MethodDelete && len(pathSegments) == 2 && pathSegments[1] == strconv.Itoa(callerID(r)) {
+		return auth.ScopeWrite
+	}
+	return methodScopes(m).scopeFor(r)
+}
+
+// Server erfüllt http.Handler und kann direkt an http.Server oder httptest.NewServer übergeben werden
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.handler.ServeHTTP(rec, r)
+	path := r.URL.Path
+	if strings.HasPrefix(path, "/public/") {
+		path = "/public/..." // Token öffentlicher Links nicht ins Log schreiben
+	}
+	s.logger.Printf("%s %s %d %s", r.Method, path, rec.status, time.Since(start))
+}
+
+// Startet den Server auf der konfigurierten Adresse
+func (s *Server) ListenAndServe() error {
+	s.logger.Printf("Server startet auf %s", s.config.Addr)
+	return http.ListenAndServe(s.config.Addr, s)
+}
+
+// Merkt sich den Statuscode der Antwort für das Logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,818 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/handlers"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Secret Keys der Benutzer, die newTestServer anlegt
+const (
+	annaKey = "key-anna" // Benutzer 1
+	benKey  = "key-ben"  // Benutzer 2
+)
+
+// Startet einen Server auf einem leeren MemoryStore mit den Benutzern Anna (1) und Ben (2)
+func newTestServer(t *testing.T) (*httptest.Server, *store.MemoryStore) {
+	t.Helper()
+	memory := store.NewMemoryStore()
+	memory.AddUser(annaKey)
+	memory.AddUser(benKey)
+
+	server := httptest.NewServer(handlers.NewServer(handlers.Options{
+		Store:  memory,
+		Logger: log.New(io.Discard, "", 0),
+	}))
+	t.Cleanup(server.Close)
+	return server, memory
+}
+
+// Ein Request gegen den Testserver mit Secret Key bzw. "Bearer {token}" (leer = ohne Anmeldung), liefert Statuscode und Body
+func request(t *testing.T, server *httptest.Server, method, path, secretKey, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case strings.HasPrefix(secretKey, "Bearer "):
+		req.Header.Set("Authorization", secretKey)
+	case secretKey != "":
+		req.Header.Set("Secret-Key", secretKey)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+// Schritt eines Ablaufs gegen denselben Server, die Schritte bauen aufeinander auf
+type step struct {
+	name       string
+	method     string
+	path       string
+	key        string
+	body       string
+	wantStatus int
+	wantBody   string // Teil der Antwort, leer = nicht prüfen
+}
+
+func runSteps(t *testing.T, server *httptest.Server, steps []step) {
+	t.Helper()
+	for _, tt := range steps {
+		status, body := request(t, server, tt.method, tt.path, tt.key, tt.body)
+		if status != tt.wantStatus {
+			t.Fatalf("%s: %s %s = %d %s, erwartet %d", tt.name, tt.method, tt.path, status, body, tt.wantStatus)
+		}
+		if !strings.Contains(body, tt.wantBody) {
+			t.Fatalf("%s: %s %s = %s, erwartet %q", tt.name, tt.method, tt.path, body, tt.wantBody)
+		}
+	}
+}
+
+func TestTodoEndpoints(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ohne Beschreibung", "POST", "/todo", annaKey, `{"title":"Einkaufen"}`, http.StatusBadRequest, "Beschreibung fehlt"},
+		{"ungültiger Body", "POST", "/todo", annaKey, `{`, http.StatusBadRequest, ""},
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Einkaufen","description":"Milch"}`, http.StatusCreated, "ToDo erfolgreich erstellt"},
+		{"zweite anlegen", "POST", "/todo", annaKey, `{"title":"Putzen","description":"Bad"}`, http.StatusCreated, ""},
+		{"lesen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"title":"Einkaufen","description":"Milch"`},
+		{"fremd lesen", "GET", "/todo/1", benKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"unbekannte ToDo", "GET", "/todo/99", annaKey, "", http.StatusBadRequest, "Ungültige todo_id"},
+		{"fremd ändern", "PATCH", "/todo/1", benKey, `{"title":"x"}`, http.StatusUnauthorized, "Nicht autorisiert"},
+		{"Position außerhalb", "PATCH", "/todo/2", annaKey, `{"order":3}`, http.StatusBadRequest, "außerhalb"},
+		{"Position ändern", "PATCH", "/todo/2", annaKey, `{"order":1}`, http.StatusOK, ""},
+		{"Reihenfolge", "GET", "/todo/me", annaKey, "", http.StatusOK, `"title":"Putzen","description":"Bad","category":"no category","list_id":null,"order":1`},
+		{"erledigen", "PATCH", "/todo/status/1", annaKey, `{"completed":true}`, http.StatusOK, "ToDo-Status erfolgreich aktualisiert"},
+		{"erledigt", "GET", "/todo/1", annaKey, "", http.StatusOK, `"completed":true`},
+		{"fremd löschen", "DELETE", "/todo/1", benKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"löschen", "DELETE", "/todo/1", annaKey, "", http.StatusOK, ""},
+		{"gelöscht", "GET", "/todo/1", annaKey, "", http.StatusBadRequest, "Ungültige todo_id"},
+		{"Lücke geschlossen", "GET", "/todo/2", annaKey, "", http.StatusOK, `"order":1`},
+	})
+}
+
+// Titel der ToDos aus GET path in der gelieferten Reihenfolge
+func listTitles(t *testing.T, server *httptest.Server, path, secretKey string) []string {
+	t.Helper()
+	status, body := request(t, server, "GET", path, secretKey, "")
+	if status != http.StatusOK {
+		t.Fatalf("GET %s = %d %s", path, status, body)
+	}
+	var page struct {
+		Todos []struct {
+			Title string `json:"title"`
+		} `json:"todos"`
+	}
+	if err := json.Unmarshal([]byte(body), &page); err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, todo := range page.Todos {
+		titles = append(titles, todo.Title)
+	}
+	return titles
+}
+
+func expectListTitles(t *testing.T, server *httptest.Server, path, secretKey string, want ...string) {
+	t.Helper()
+	got := listTitles(t, server, path, secretKey)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("GET %s = %q, erwartet %q", path, got, want)
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	today := time.Now().Format("2006-01-02")
+
+	runSteps(t, server, []step{
+		{"ungültiges Datum", "POST", "/todo", annaKey, `{"title":"x","description":"y","due_at":"morgen"}`, http.StatusBadRequest, "ungültiges Datum"},
+		{"überfällig", "POST", "/todo", annaKey, `{"title":"Steuer","description":"Erklärung","due_at":"2020-01-31T18:00:00+01:00"}`, http.StatusCreated, ""},
+		{"heute", "POST", "/todo", annaKey, `{"title":"Heute","description":"Datum","due_at":"` + today + `"}`, http.StatusCreated, ""},
+		{"später", "POST", "/todo", annaKey, `{"title":"Später","description":"Urlaub","due_at":"2099-06-01"}`, http.StatusCreated, ""},
+		{"ohne Fälligkeit", "POST", "/todo", annaKey, `{"title":"Irgendwann","description":"-"}`, http.StatusCreated, ""},
+		{"lesen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"due_at":"2020-01-31T18:00:00+01:00"`},
+		{"ungültiger Zeitraum", "GET", "/todo/me?due=gestern", annaKey, "", http.StatusBadRequest, "Ungültiger Wert für due"},
+		{"ungültige Zeitzone", "GET", "/todo/me?due=today&tz=Mars", annaKey, "", http.StatusBadRequest, "Ungültige Zeitzone"},
+	})
+
+	expectListTitles(t, server, "/todo/me?due=overdue", annaKey, "Steuer")
+	expectListTitles(t, server, "/todo/me?due=today", annaKey, "Heute")
+	expectListTitles(t, server, "/todo/me?due=week", annaKey, "Heute")
+
+	// Erledigte ToDos sind nicht mehr überfällig, ohne Fälligkeit fällt die ToDo aus allen Zeiträumen
+	runSteps(t, server, []step{
+		{"erledigen", "PATCH", "/todo/status/1", annaKey, `{"completed":true}`, http.StatusOK, ""},
+		{"Fälligkeit entfernen", "PATCH", "/todo/2", annaKey, `{"due_at":""}`, http.StatusOK, ""},
+	})
+	expectListTitles(t, server, "/todo/me?due=overdue", annaKey)
+	expectListTitles(t, server, "/todo/me?due=today", annaKey)
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ungültige Priorität", "POST", "/todo", annaKey, `{"title":"x","description":"y","priority":"sofort"}`, http.StatusBadRequest, "ungültige Priorität"},
+		{"ohne Priorität", "POST", "/todo", annaKey, `{"title":"Ablage","description":"Papiere"}`, http.StatusCreated, ""},
+		{"hoch", "POST", "/todo", annaKey, `{"title":"Rechnung","description":"bezahlen","priority":"high"}`, http.StatusCreated, ""},
+		{"niedrig", "POST", "/todo", annaKey, `{"title":"Keller","description":"aufräumen","priority":"low"}`, http.StatusCreated, ""},
+		{"lesen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"priority":"none"`},
+		{"ungültige Sortierung", "GET", "/todo/me?sort=wichtig", annaKey, "", http.StatusBadRequest, "sort"},
+	})
+	expectListTitles(t, server, "/todo/me?sort=priority", annaKey, "Rechnung", "Keller", "Ablage")
+	expectListTitles(t, server, "/todo/me?sort=-priority", annaKey, "Ablage", "Keller", "Rechnung")
+
+	runSteps(t, server, []step{
+		{"dringend", "PATCH", "/todo/1", annaKey, `{"priority":"urgent"}`, http.StatusOK, ""},
+	})
+	expectListTitles(t, server, "/todo/me?sort=priority", annaKey, "Ablage", "Rechnung", "Keller")
+	expectListTitles(t, server, "/todo/me", annaKey, "Ablage", "Rechnung", "Keller")
+}
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"mit Tags anlegen", "POST", "/todo", annaKey, `{"title":"Einkaufen","description":"Milch","tags":["Haushalt"]}`, http.StatusCreated, ""},
+		{"ohne Tags anlegen", "POST", "/todo", annaKey, `{"title":"Bericht","description":"Q3"}`, http.StatusCreated, ""},
+		{"lesen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"tags":["Haushalt"]`},
+		{"hinzufügen", "POST", "/todo/2/tags", annaKey, `{"tags":["Arbeit","Haushalt"]}`, http.StatusOK, "Tags erfolgreich hinzugefügt"},
+		{"keine Tags", "POST", "/todo/2/tags", annaKey, `{"tags":[]}`, http.StatusBadRequest, "Keine Tags angegeben"},
+		{"fremde ToDo", "POST", "/todo/2/tags", benKey, `{"tags":["x"]}`, http.StatusUnauthorized, "Nicht autorisiert"},
+		{"Tags des Benutzers", "GET", "/tags/user/1", annaKey, "", http.StatusOK, `"name":"Haushalt","count":2`},
+		{"fremde Tags", "GET", "/tags/user/1", benKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"nicht vergeben", "DELETE", "/todo/1/tags/Arbeit", annaKey, "", http.StatusBadRequest, "trägt diesen Tag nicht"},
+		{"entfernen", "DELETE", "/todo/2/tags/Haushalt", annaKey, "", http.StatusOK, "Tag erfolgreich entfernt"},
+	})
+	expectListTitles(t, server, "/todo/me?tags=Haushalt", annaKey, "Einkaufen")
+	expectListTitles(t, server, "/todo/me?tags=Haushalt,Arbeit&tag_mode=or", annaKey, "Einkaufen", "Bericht")
+	expectListTitles(t, server, "/todo/me?tags=Haushalt,Arbeit", annaKey)
+
+	runSteps(t, server, []step{
+		{"umbenennen", "PATCH", "/tags/user/1/Arbeit", annaKey, `{"name":"Büro"}`, http.StatusOK, "Tag erfolgreich umbenannt"},
+		{"zusammenführen", "PATCH", "/tags/user/1/Büro", annaKey, `{"name":"Haushalt"}`, http.StatusOK, "zusammengeführt"},
+		{"unbekannter Tag", "PATCH", "/tags/user/1/Büro", annaKey, `{"name":"x"}`, http.StatusBadRequest, "Tag nicht vorhanden"},
+	})
+	expectListTitles(t, server, "/todo/me?tags=Haushalt", annaKey, "Einkaufen", "Bericht")
+}
+
+func TestTodoFilter(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	runSteps(t, server, []step{
+		{"A", "POST", "/todo", annaKey, `{"title":"A","description":"a"}`, http.StatusCreated, ""},
+		{"B", "POST", "/todo", annaKey, `{"title":"B","description":"b","category":"Arbeit"}`, http.StatusCreated, ""},
+		{"C", "POST", "/todo", annaKey, `{"title":"C","description":"c","category":"Arbeit"}`, http.StatusCreated, ""},
+		{"fremd", "POST", "/todo", benKey, `{"title":"Fremd","description":"f"}`, http.StatusCreated, ""},
+		{"erledigen", "PATCH", "/todo/status/2", annaKey, `{"completed":true}`, http.StatusOK, ""},
+		{"ungültiger Filter", "GET", "/todo/me?completed=vielleicht", annaKey, "", http.StatusBadRequest, "Ungültiger Wert für completed"},
+		{"ungültiges limit", "GET", "/todo/me?limit=0", annaKey, "", http.StatusBadRequest, "Ungültiger Wert für limit"},
+		{"ungültiger Cursor", "GET", "/todo/me?cursor=abc", annaKey, "", http.StatusBadRequest, "Ungültiger Cursor"},
+	})
+	expectListTitles(t, server, "/todo/me?completed=false", annaKey, "A", "C")
+	expectListTitles(t, server, "/todo/me?category=Arbeit&completed=false", annaKey, "C")
+	expectListTitles(t, server, "/todo/user/1?sort=-title", annaKey, "C", "B", "A")
+
+	// Seiten über next_cursor, der Cursor gilt nur für seine Sortierung
+	status, body := request(t, server, "GET", "/todo/me?sort=title&limit=2", annaKey, "")
+	var page struct {
+		NextCursor *string `json:"next_cursor"`
+	}
+	if err := json.Unmarshal([]byte(body), &page); status != http.StatusOK || err != nil || page.NextCursor == nil {
+		t.Fatalf("erste Seite = %d %s", status, body)
+	}
+	expectListTitles(t, server, "/todo/me?sort=title&limit=2&cursor="+*page.NextCursor, annaKey, "C")
+	runSteps(t, server, []step{
+		{"letzte Seite", "GET", "/todo/me?sort=title&limit=2&cursor=" + *page.NextCursor, annaKey, "", http.StatusOK, `"next_cursor":null`},
+		{"andere Sortierung", "GET", "/todo/me?sort=priority&cursor=" + *page.NextCursor, annaKey, "", http.StatusBadRequest, "Cursor passt nicht zur Sortierung"},
+	})
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Einkaufen","description":"Milch und Brot"}`, http.StatusCreated, ""},
+		{"fremd anlegen", "POST", "/todo", benKey, `{"title":"Milch","description":"Ben"}`, http.StatusCreated, ""},
+		{"ohne Suchbegriff", "GET", "/todo/search", annaKey, "", http.StatusBadRequest, "Suchbegriff fehlt"},
+		{"ungültiges limit", "GET", "/todo/search?q=milch&limit=0", annaKey, "", http.StatusBadRequest, "Ungültiger Wert für limit"},
+		{"Treffer markiert", "GET", "/todo/search?q=milch", annaKey, "", http.StatusOK, `"title":"Einkaufen","snippet":"\u003cmark\u003eMilch\u003c/mark\u003e und Brot"`},
+		{"nur eigene", "GET", "/todo/search?q=ben", annaKey, "", http.StatusOK, "[]"},
+	})
+}
+
+// Im Klartext gespeicherte Keys werden beim ersten Zugriff durch einen bcrypt Hash ersetzt
+func TestSecretKeyRehash(t *testing.T) {
+	t.Parallel()
+	server, memory := newTestServer(t)
+
+	credentials, err := memory.FindCredentials(auth.LookupKey(annaKey), annaKey)
+	if err != nil || len(credentials) != 1 || credentials[0].Hash != annaKey {
+		t.Fatalf("Key vor dem ersten Zugriff = %+v, %v", credentials, err)
+	}
+	runSteps(t, server, []step{
+		{"falscher Key", "GET", "/todo/me", "key-anna-falsch", "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"erster Zugriff", "GET", "/todo/me", annaKey, "", http.StatusOK, ""},
+	})
+
+	credentials, err = memory.FindCredentials(auth.LookupKey(annaKey), annaKey)
+	if err != nil || len(credentials) != 1 || credentials[0].Lookup != auth.LookupKey(annaKey) {
+		t.Fatalf("Key nach dem ersten Zugriff = %+v, %v", credentials, err)
+	}
+	if ok, rehash := auth.VerifySecret(credentials[0].Hash, annaKey); !ok || rehash {
+		t.Fatalf("gespeicherter Wert %q ist kein Hash des Keys", credentials[0].Hash)
+	}
+	runSteps(t, server, []step{
+		{"erneuter Zugriff", "GET", "/todo/me", annaKey, "", http.StatusOK, ""},
+	})
+}
+
+// Der Benutzer ergibt sich aus dem Secret Key, IDs in Pfad oder Body müssen zu ihm passen
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ohne Anmeldung", "GET", "/todo/me", "", "", http.StatusBadRequest, "Secret Key oder Bearer Token fehlt"},
+		{"falscher Key", "GET", "/todo/me", "falsch", "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"ohne user_id", "POST", "/todo", benKey, `{"title":"Ben","description":"eigene"}`, http.StatusCreated, ""},
+		{"gehört dem Aufrufer", "GET", "/todo/1", benKey, "", http.StatusOK, `"user_id":2`},
+		{"eigene user_id", "POST", "/todo", annaKey, `{"user_id":1,"title":"Anna","description":"eigene"}`, http.StatusCreated, ""},
+		{"für fremden Benutzer", "POST", "/todo", annaKey, `{"user_id":2,"title":"x","description":"y"}`, http.StatusUnauthorized, "Nicht autorisiert"},
+		{"eigene Liste", "GET", "/todo/user/1", annaKey, "", http.StatusOK, `"title":"Anna"`},
+		{"fremde Liste", "GET", "/todo/user/2", annaKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"ungültige userID", "GET", "/todo/user/x", annaKey, "", http.StatusBadRequest, "Ungültige userID"},
+	})
+	expectListTitles(t, server, "/todo/me", benKey, "Ben")
+}
+
+func TestUsers(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ohne Anzeigename", "POST", "/users", "", `{"email":"carla@example.com"}`, http.StatusBadRequest, "Anzeigename fehlt"},
+		{"ungültige E-Mail", "POST", "/users", "", `{"display_name":"Carla","email":"carla"}`, http.StatusBadRequest, "Ungültige E-Mail Adresse"},
+		{"nur POST", "GET", "/users", "", "", http.StatusMethodNotAllowed, ""},
+	})
+
+	status, body := request(t, server, "POST", "/users", "", `{"display_name":" Carla ","email":"Carla@Example.com"}`)
+	if status != http.StatusCreated || !strings.Contains(body, `"display_name":"Carla","email":"carla@example.com"`) {
+		t.Fatalf("Registrierung = %d %s", status, body)
+	}
+	var registered struct {
+		SecretKey string `json:"secret_key"`
+	}
+	if err := json.Unmarshal([]byte(body), &registered); err != nil || registered.SecretKey == "" {
+		t.Fatalf("Secret Key der Registrierung: %s, %v", body, err)
+	}
+	carlaKey := registered.SecretKey
+
+	runSteps(t, server, []step{
+		{"E-Mail vergeben", "POST", "/users", "", `{"display_name":"C2","email":"carla@example.com"}`, http.StatusConflict, "E-Mail bereits vergeben"},
+		{"Profil", "GET", "/users/me", carlaKey, "", http.StatusOK, `"id":3,"display_name":"Carla"`},
+		{"ohne Änderungen", "PATCH", "/users/me", carlaKey, `{}`, http.StatusBadRequest, "Keine gültigen Parameter"},
+		{"fremde E-Mail", "PATCH", "/users/me", annaKey, `{"email":"carla@example.com"}`, http.StatusConflict, "E-Mail bereits vergeben"},
+		{"umbenennen", "PATCH", "/users/me", carlaKey, `{"display_name":"Carla B."}`, http.StatusOK, ""},
+		{"umbenannt", "GET", "/users/me", carlaKey, "", http.StatusOK, `"display_name":"Carla B."`},
+		{"ToDo anlegen", "POST", "/todo", carlaKey, `{"title":"Abmelden","description":"Konto löschen"}`, http.StatusCreated, ""},
+		{"Konto löschen", "DELETE", "/users/me", carlaKey, "", http.StatusOK, ""},
+		{"Key ungültig", "GET", "/users/me", carlaKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"ToDo gelöscht", "GET", "/todo/1", annaKey, "", http.StatusBadRequest, "Ungültige todo_id"},
+	})
+}
+
+// Legt für den Benutzer mit secretKey einen API Key mit nur einem Scope an und liefert dessen Secret Key
+func createKey(t *testing.T, server *httptest.Server, secretKey, scope string) string {
+	t.Helper()
+	status, body := request(t, server, "POST", "/users/me/keys", secretKey, `{"name":"`+scope+`","scopes":["`+scope+`"]}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Key %s anlegen = %d %s", scope, status, body)
+	}
+	var created struct {
+		SecretKey string `json:"secret_key"`
+	}
+	if err := json.Unmarshal([]byte(body), &created); err != nil {
+		t.Fatal(err)
+	}
+	return created.SecretKey
+}
+
+func TestAPIKeys(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ohne Name", "POST", "/users/me/keys", annaKey, `{}`, http.StatusBadRequest, "Name fehlt"},
+		{"ungültiger Scope", "POST", "/users/me/keys", annaKey, `{"name":"x","scopes":["alles"]}`, http.StatusBadRequest, "Ungültiger Scope"},
+		{"ohne Scope", "POST", "/users/me/keys", annaKey, `{"name":"x","scopes":[]}`, http.StatusBadRequest, "Mindestens ein Scope"},
+		{"abgelaufen", "POST", "/users/me/keys", annaKey, `{"name":"x","expires_at":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest, "Vergangenheit"},
+		{"einziger Key", "DELETE", "/users/me/keys/1", annaKey, "", http.StatusBadRequest, "kann nicht widerrufen werden"},
+	})
+
+	laptopKey := createKey(t, server, annaKey, "admin") // Key 3
+	runSteps(t, server, []step{
+		{"neuer Key meldet an", "GET", "/todo/me", laptopKey, "", http.StatusOK, ""},
+		{"Keys", "GET", "/users/me/keys", annaKey, "", http.StatusOK, `"id":3,"name":"admin","scopes":["admin"]`},
+		{"zuletzt verwendet", "GET", "/users/me/keys", annaKey, "", http.StatusOK, `"last_used_at"`},
+		{"fremder Key", "DELETE", "/users/me/keys/3", benKey, "", http.StatusBadRequest, "Ungültige key_id"},
+		{"alten Key widerrufen", "DELETE", "/users/me/keys/1", laptopKey, "", http.StatusOK, "API Key erfolgreich widerrufen"},
+		{"widerrufen", "GET", "/todo/me", annaKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"doppelt", "DELETE", "/users/me/keys/1", laptopKey, "", http.StatusBadRequest, "Ungültige key_id"},
+		{"widerrufen gelistet", "GET", "/users/me/keys", laptopKey, "", http.StatusOK, `"revoked_at"`},
+	})
+
+	// Der Secret Key selbst wird nur beim Anlegen übermittelt
+	if _, body := request(t, server, "GET", "/users/me/keys", laptopKey, ""); strings.Contains(body, laptopKey) || strings.Contains(body, "secret_key") {
+		t.Fatalf("Keys enthalten den Secret Key: %s", body)
+	}
+}
+
+// Eine mit der Gruppe geteilte Liste umfasst die ToDos, die gerade in ihr liegen
+func TestGroupListShare(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"Liste anlegen", "POST", "/lists", annaKey, `{"name":"Baustelle"}`, http.StatusCreated, `"id":1`},
+		{"in der Liste", "POST", "/todo", annaKey, `{"title":"Estrich","description":"trocknen lassen","list_id":1}`, http.StatusCreated, ""},
+		{"ohne Liste", "POST", "/todo", annaKey, `{"title":"Privat","description":"nur Anna"}`, http.StatusCreated, ""},
+		{"Gruppe anlegen", "POST", "/groups", annaKey, `{"name":"Team"}`, http.StatusCreated, ""},
+		{"Ben einladen", "POST", "/groups/1/members/2", annaKey, "", http.StatusCreated, ""},
+		{"Ben nimmt an", "POST", "/invitations/groups/1/accept", benKey, "", http.StatusOK, ""},
+		{"nicht beides", "POST", "/groups/1/shares", annaKey, `{"todo_id":2,"list_id":1}`, http.StatusBadRequest, "Entweder todo_id oder list_id"},
+		{"Kategorie nicht mehr", "POST", "/groups/1/shares", annaKey, `{"category":"Baustelle"}`, http.StatusBadRequest, "Entweder todo_id oder list_id"},
+		{"fremde Liste", "POST", "/groups/1/shares", benKey, `{"list_id":1}`, http.StatusBadRequest, "Ungültige list_id"},
+		{"Liste teilen", "POST", "/groups/1/shares", annaKey, `{"list_id":1,"permission":"view"}`, http.StatusCreated, `"list_id":1,"permission":"view"`},
+		{"doppelt", "POST", "/groups/1/shares", annaKey, `{"list_id":1}`, http.StatusConflict, ""},
+		{"Ben sieht die Liste", "GET", "/todo/me", benKey, "", http.StatusOK, `"title":"Estrich"`},
+		{"in die Liste legen", "PATCH", "/todo/2", annaKey, `{"list_id":1}`, http.StatusOK, ""},
+		{"Ben sieht die neue ToDo", "GET", "/todo/2", benKey, "", http.StatusOK, `"title":"Privat"`},
+		{"Liste löschen", "DELETE", "/lists/1", annaKey, "", http.StatusOK, ""},
+		{"Zugriff endet", "GET", "/todo/me", benKey, "", http.StatusOK, `{"todos":[],"next_cursor":null}`},
+		{"Freigabe entfernt", "GET", "/groups/1/shares", annaKey, "", http.StatusOK, "[]"},
+	})
+}
+
+// Öffentliche Links zeigen nur Inhalt, Status, Fälligkeit und Checkliste
+func TestPublicLinks(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Bad","description":"Fliesen","due_at":"2030-02-01T12:00:00Z","tags":["Geheim"]}`, http.StatusCreated, ""},
+		{"Checkliste", "POST", "/todo/1/items", annaKey, `{"title":"Fugen"}`, http.StatusCreated, ""},
+	})
+
+	var paths []string
+	for _, path := range []string{"/todo/1/links", "/todo/me/links?completed=false"} {
+		status, body := request(t, server, "POST", path, annaKey, "")
+		if status != http.StatusCreated {
+			t.Fatalf("Link anlegen %s = %d %s", path, status, body)
+		}
+		var created struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(body), &created); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, created.Path)
+	}
+
+	public := `{"title":"Bad","description":"Fliesen","completed":false,"due_at":"2030-02-01T12:00:00Z","checklist":[{"title":"Fugen","completed":false}]}`
+	runSteps(t, server, []step{
+		{"ToDo", "GET", paths[0], "", "", http.StatusOK, public},
+		{"Liste", "GET", paths[1], "", "", http.StatusOK, `{"todos":[` + public + `],"next_cursor":null}`},
+		{"unbekannt", "GET", "/public/unbekannt", "", "", http.StatusNotFound, ""},
+	})
+	for _, path := range paths {
+		_, body := request(t, server, "GET", path, "", "")
+		for _, field := range []string{"user_id", "tags", "Geheim", `"id"`, "category", "list_id", "order"} {
+			if strings.Contains(body, field) {
+				t.Fatalf("%s enthält %s: %s", path, field, body)
+			}
+		}
+	}
+}
+
+// Eingeschränkte API Keys dürfen nur, was ihr Scope erlaubt
+func TestScopes(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"A","description":"a"}`, http.StatusCreated, ""},
+	})
+
+	keys := map[string]string{}
+	for _, scope := range []string{"read", "write", "share"} {
+		keys[scope] = createKey(t, server, annaKey, scope)
+	}
+	benWrite := createKey(t, server, benKey, "write")
+
+	runSteps(t, server, []step{
+		{"read liest", "GET", "/todo/1", keys["read"], "", http.StatusOK, ""},
+		{"read legt nicht an", "POST", "/todo", keys["read"], `{"title":"B","description":"b"}`, http.StatusForbidden, `Berechtigung \"write\"`},
+		{"read ändert nicht", "PATCH", "/todo/status/1", keys["read"], `{"completed":true}`, http.StatusForbidden, ""},
+		{"write legt an", "POST", "/todo", keys["write"], `{"title":"B","description":"b"}`, http.StatusCreated, ""},
+		{"write teilt nicht", "POST", "/todo/share/1/2", keys["write"], "", http.StatusForbidden, `Berechtigung \"share\"`},
+		{"share teilt", "POST", "/todo/share/1/2", keys["share"], "", http.StatusCreated, ""},
+		{"share liest nicht", "GET", "/todo/me", keys["share"], "", http.StatusForbidden, ""},
+		{"annehmen", "POST", "/invitations/1/accept", benWrite, "", http.StatusOK, ""},
+		{"write entfernt keine Mitglieder", "DELETE", "/todo/share/1/2", keys["write"], "", http.StatusForbidden, `Berechtigung \"share\"`},
+		{"write verlässt ToDo", "DELETE", "/todo/share/1/2", benWrite, "", http.StatusOK, ""},
+		{"unbekannte Methode nur admin", "PUT", "/todo/1", keys["write"], "", http.StatusForbidden, `Berechtigung \"admin\"`},
+		{"nur admin verwaltet Keys", "GET", "/users/me/keys", keys["write"], "", http.StatusForbidden, `Berechtigung \"admin\"`},
+	})
+}
+
+// Tokens aus einer Antwort von POST /auth/token
+func decodeTokens(t *testing.T, status int, body string) (access, refresh string) {
+	t.Helper()
+	if status != http.StatusOK {
+		t.Fatalf("Token anfordern = %d %s", status, body)
+	}
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal([]byte(body), &tokens); err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + tokens.AccessToken, tokens.RefreshToken
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	runSteps(t, server, []step{
+		{"nur POST", "GET", "/auth/token", "", "", http.StatusMethodNotAllowed, ""},
+		{"ungültiger grant_type", "POST", "/auth/token", "", `{"grant_type":"password"}`, http.StatusBadRequest, "Ungültiger grant_type"},
+		{"ohne Secret Key", "POST", "/auth/token", "", `{"grant_type":"secret_key"}`, http.StatusBadRequest, "Secret Key fehlt"},
+		{"falscher Secret Key", "POST", "/auth/token", "", `{"grant_type":"secret_key","secret_key":"falsch"}`, http.StatusUnauthorized, "Nicht autorisiert"},
+		{"unbekannter Refresh Token", "POST", "/auth/token", "", `{"grant_type":"refresh_token","refresh_token":"abc"}`, http.StatusUnauthorized, "Ungültiger Refresh Token"},
+	})
+
+	status, body := request(t, server, "POST", "/auth/token", "", `{"grant_type":"secret_key","secret_key":"`+annaKey+`"}`)
+	access, refresh := decodeTokens(t, status, body)
+	runSteps(t, server, []step{
+		{"Access Token", "POST", "/todo", access, `{"title":"A","description":"a"}`, http.StatusCreated, ""},
+		{"Benutzer des Tokens", "GET", "/todo/1", access, "", http.StatusOK, `"user_id":1`},
+	})
+
+	// Ein Refresh Token gilt einmal, seine Wiederverwendung widerruft auch die später ausgestellten
+	status, body = request(t, server, "POST", "/auth/token", "", `{"grant_type":"refresh_token","refresh_token":"`+refresh+`"}`)
+	next, nextRefresh := decodeTokens(t, status, body)
+	runSteps(t, server, []step{
+		{"neuer Access Token", "GET", "/todo/me", next, "", http.StatusOK, `"title":"A"`},
+		{"Refresh Token erneut", "POST", "/auth/token", "", `{"grant_type":"refresh_token","refresh_token":"` + refresh + `"}`, http.StatusUnauthorized, "bereits verwendet"},
+		{"späterer Refresh Token", "POST", "/auth/token", "", `{"grant_type":"refresh_token","refresh_token":"` + nextRefresh + `"}`, http.StatusUnauthorized, "Ungültiger Refresh Token"},
+	})
+}
+
+// Mitglieder dürfen lesen (view), zusätzlich den Status ändern (complete) bzw. auch den Inhalt bearbeiten (edit)
+func TestSharePermissions(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Umzug","description":"Kisten"}`, http.StatusCreated, ""},
+		{"ungültige Berechtigung", "POST", "/todo/share/1/2", annaKey, `{"permission":"alles"}`, http.StatusBadRequest, "Ungültige Berechtigung"},
+		{"einladen", "POST", "/todo/share/1/2", annaKey, `{"permission":"view"}`, http.StatusCreated, `"permission":"view"`},
+		{"annehmen", "POST", "/invitations/1/accept", benKey, "", http.StatusOK, ""},
+		{"view liest", "GET", "/todo/1", benKey, "", http.StatusOK, `"permission":"view"`},
+		{"view erledigt nicht", "PATCH", "/todo/status/1", benKey, `{"completed":true}`, http.StatusForbidden, "Abschließen"},
+		{"view bearbeitet nicht", "PATCH", "/todo/1", benKey, `{"title":"Neu"}`, http.StatusForbidden, "Bearbeiten"},
+		{"nur Eigentümer ändert Berechtigung", "PATCH", "/todo/share/1/2", benKey, `{"permission":"edit"}`, http.StatusForbidden, "Nur der Eigentümer"},
+		{"ohne Berechtigung", "PATCH", "/todo/share/1/2", annaKey, `{}`, http.StatusBadRequest, "Ungültige Berechtigung"},
+		{"complete", "PATCH", "/todo/share/1/2", annaKey, `{"permission":"complete"}`, http.StatusOK, ""},
+		{"complete erledigt", "PATCH", "/todo/status/1", benKey, `{"completed":true}`, http.StatusOK, ""},
+		{"complete bearbeitet nicht", "PATCH", "/todo/1", benKey, `{"title":"Neu"}`, http.StatusForbidden, "Bearbeiten"},
+		{"edit", "PATCH", "/todo/share/1/2", annaKey, `{"permission":"edit"}`, http.StatusOK, ""},
+		{"edit bearbeitet", "PATCH", "/todo/1", benKey, `{"title":"Neu"}`, http.StatusOK, ""},
+		{"Eigentümer sieht Änderung", "GET", "/todo/1", annaKey, "", http.StatusOK, `"title":"Neu"`},
+		{"edit teilt nicht weiter", "POST", "/todo/share/1/1", benKey, "", http.StatusForbidden, "Nur der Eigentümer"},
+	})
+}
+
+// Alle Mitglieder sehen denselben Datensatz, ein Mitglied entfernt die ToDo nur aus seiner eigenen Liste
+func TestSharedTodo(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"eigene", "POST", "/todo", benKey, `{"title":"Eigene","description":"Ben"}`, http.StatusCreated, ""},
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Umzug","description":"Kisten"}`, http.StatusCreated, ""},
+		{"einladen", "POST", "/todo/share/2/2", annaKey, `{"permission":"complete"}`, http.StatusCreated, ""},
+		{"annehmen", "POST", "/invitations/1/accept", benKey, "", http.StatusOK, `"category":"shared","list_id":null,"order":2`},
+		{"Eigentümer ändert", "PATCH", "/todo/2", annaKey, `{"description":"Kisten und Band"}`, http.StatusOK, ""},
+		{"Mitglied sieht Änderung", "GET", "/todo/2", benKey, "", http.StatusOK, `"description":"Kisten und Band"`},
+		{"Mitglied erledigt", "PATCH", "/todo/status/2", benKey, `{"completed":true}`, http.StatusOK, ""},
+		{"für alle erledigt", "GET", "/todo/2", annaKey, "", http.StatusOK, `"completed":true`},
+		{"eigene Kategorie", "PATCH", "/todo/2", benKey, `{"category":"Familie"}`, http.StatusOK, ""},
+		{"Kategorie des Eigentümers", "GET", "/todo/2", annaKey, "", http.StatusOK, `"category":"no category"`},
+		{"Mitglied entfernt", "DELETE", "/todo/2", benKey, "", http.StatusOK, ""},
+		{"beim Eigentümer erhalten", "GET", "/todo/2", annaKey, "", http.StatusOK, `"title":"Umzug"`},
+		{"nicht mehr sichtbar", "GET", "/todo/2", benKey, "", http.StatusUnauthorized, ""},
+	})
+	expectListTitles(t, server, "/todo/me", benKey, "Eigene")
+}
+
+func TestCollaborators(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	carlaKey := register(t, server, "Carla") // Benutzer 3
+
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Umzug","description":"Kisten"}`, http.StatusCreated, ""},
+		{"Ben einladen", "POST", "/todo/share/1/2", annaKey, `{"permission":"view"}`, http.StatusCreated, ""},
+		{"Carla einladen", "POST", "/todo/share/1/3", annaKey, "", http.StatusCreated, `"permission":"complete"`},
+		{"Ben nimmt an", "POST", "/invitations/1/accept", benKey, "", http.StatusOK, ""},
+		{"Eigentümer sieht Einladungen", "GET", "/todo/share/1", annaKey, "", http.StatusOK, `"owner_id":1,"members":[{"user_id":2,`},
+		{"offene Einladung", "GET", "/todo/share/1", annaKey, "", http.StatusOK, `"invitations":[{"id":2,"todo_id":1`},
+		{"Mitglied sieht Mitglieder", "GET", "/todo/share/1", benKey, "", http.StatusOK, `"user_id":2`},
+		{"Fremde nicht", "GET", "/todo/share/1", carlaKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"Mitglied entfernt andere nicht", "DELETE", "/todo/share/1/3", benKey, "", http.StatusForbidden, "Nur der Eigentümer"},
+		{"Einladung zurückziehen", "DELETE", "/todo/share/1/3", annaKey, "", http.StatusOK, ""},
+		{"keine Einladung mehr", "GET", "/invitations", carlaKey, "", http.StatusOK, "[]"},
+		{"kein Mitglied", "DELETE", "/todo/share/1/9", annaKey, "", http.StatusBadRequest, "nicht mit diesem Benutzer geteilt"},
+		{"Freigabe entfernen", "DELETE", "/todo/share/1/2", annaKey, "", http.StatusOK, "Freigabe erfolgreich entfernt"},
+		{"danach unsichtbar", "GET", "/todo/1", benKey, "", http.StatusUnauthorized, ""},
+		{"keine Mitglieder", "GET", "/todo/share/1", annaKey, "", http.StatusOK, `"members":[]`},
+	})
+}
+
+// Registriert einen weiteren Benutzer und liefert seinen Secret Key
+func register(t *testing.T, server *httptest.Server, name string) string {
+	t.Helper()
+	status, body := request(t, server, "POST", "/users", "", `{"display_name":"`+name+`"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Registrierung %s = %d %s", name, status, body)
+	}
+	var registered struct {
+		SecretKey string `json:"secret_key"`
+	}
+	if err := json.Unmarshal([]byte(body), &registered); err != nil {
+		t.Fatal(err)
+	}
+	return registered.SecretKey
+}
+
+// Geteilt wird über Einladungen, die der Empfänger annimmt oder ablehnt
+func TestInvitations(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	carlaKey := register(t, server, "Carla") // Benutzer 3
+
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Umzug","description":"Kisten"}`, http.StatusCreated, ""},
+		{"nur Eigentümer teilt", "POST", "/todo/share/1/1", benKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"mit sich selbst", "POST", "/todo/share/1/1", annaKey, "", http.StatusBadRequest, "mit sich selbst"},
+		{"unbekannter Benutzer", "POST", "/todo/share/1/9", annaKey, "", http.StatusBadRequest, "Ungültige UserID"},
+		{"einladen", "POST", "/todo/share/1/2", annaKey, `{"permission":"view"}`, http.StatusCreated, `"permission":"view"`},
+		{"doppelt einladen", "POST", "/todo/share/1/2", annaKey, `{"permission":"view"}`, http.StatusConflict, "bereits offen"},
+		{"vor Annahme unsichtbar", "GET", "/todo/1", benKey, "", http.StatusUnauthorized, ""},
+		{"Einladungen", "GET", "/invitations", benKey, "", http.StatusOK, `"todo_title":"Umzug"`},
+		{"fremde Einladung", "POST", "/invitations/1/accept", carlaKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"ablehnen", "POST", "/invitations/1/decline", benKey, "", http.StatusOK, ""},
+		{"abgelehnt", "POST", "/invitations/1/accept", benKey, "", http.StatusBadRequest, "Ungültige invitation_id"},
+		{"erneut einladen", "POST", "/todo/share/1/2", annaKey, `{"permission":"view"}`, http.StatusCreated, `"id":2`},
+		{"annehmen", "POST", "/invitations/2/accept", benKey, "", http.StatusOK, `"permission":"view"`},
+		{"Mitglied liest", "GET", "/todo/1", benKey, "", http.StatusOK, `"title":"Umzug"`},
+		{"keine offenen Einladungen", "GET", "/invitations", benKey, "", http.StatusOK, "[]"},
+		{"bereits Mitglied", "POST", "/todo/share/1/2", annaKey, "", http.StatusConflict, "bereits mit diesem Benutzer geteilt"},
+		{"ungültige ID", "POST", "/invitations/x/accept", benKey, "", http.StatusBadRequest, "Ungültige invitation_id"},
+	})
+}
+
+// Mitglieder einer Gruppe sehen die mit ihr geteilten ToDos, solange sie Mitglied sind
+func TestGroups(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	carlaKey := register(t, server, "Carla") // Benutzer 3
+
+	runSteps(t, server, []step{
+		{"ohne Name", "POST", "/groups", annaKey, `{}`, http.StatusBadRequest, "Name fehlt"},
+		{"anlegen", "POST", "/groups", annaKey, `{"name":"Team"}`, http.StatusCreated, `"role":"owner"`},
+		{"Nichtmitglied", "GET", "/groups/1", benKey, "", http.StatusBadRequest, "Ungültige group_id"},
+		{"Admin einladen", "POST", "/groups/1/members/3", annaKey, `{"role":"admin"}`, http.StatusCreated, `"id":1,"group_id":1,"group_name":"Team","user_id":3,"role":"admin"`},
+		{"noch kein Mitglied", "GET", "/groups/1", carlaKey, "", http.StatusBadRequest, "Ungültige group_id"},
+		{"Einladung offen", "GET", "/groups/1", annaKey, "", http.StatusOK, `"invitations":[{"id":1`},
+		{"doppelt eingeladen", "POST", "/groups/1/members/3", annaKey, "", http.StatusConflict, "bereits offen"},
+		{"Einladungen des Admins", "GET", "/invitations/groups", carlaKey, "", http.StatusOK, `"group_name":"Team"`},
+		{"fremde Einladung", "POST", "/invitations/groups/1/accept", benKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"Admin nimmt an", "POST", "/invitations/groups/1/accept", carlaKey, "", http.StatusOK, `"name":"Team","role":"admin"`},
+		{"Admin lädt ein", "POST", "/groups/1/members/2", carlaKey, "", http.StatusCreated, `"id":2`},
+		{"ablehnen", "POST", "/invitations/groups/2/decline", benKey, "", http.StatusOK, "Einladung abgelehnt"},
+		{"abgelehnt", "GET", "/invitations/groups", benKey, "", http.StatusOK, "[]"},
+		{"erneut einladen", "POST", "/groups/1/members/2", annaKey, "", http.StatusCreated, `"id":3`},
+		{"zurückziehen", "DELETE", "/groups/1/members/2", carlaKey, "", http.StatusOK, "Einladung erfolgreich zurückgezogen"},
+		{"zurückgezogen", "POST", "/invitations/groups/3/accept", benKey, "", http.StatusBadRequest, "Ungültige invitation_id"},
+		{"Mitglied einladen", "POST", "/groups/1/members/2", carlaKey, "", http.StatusCreated, `"id":4`},
+		{"Mitglied nimmt an", "POST", "/invitations/groups/4/accept", benKey, "", http.StatusOK, ""},
+		{"doppelt", "POST", "/groups/1/members/2", annaKey, "", http.StatusConflict, "bereits Mitglied"},
+		{"Mitglied verwaltet nicht", "PATCH", "/groups/1", benKey, `{"name":"Neu"}`, http.StatusForbidden, "Nur Eigentümer und Admins"},
+		{"Gruppen des Mitglieds", "GET", "/groups", benKey, "", http.StatusOK, `"name":"Team","role":"member"`},
+		{"ToDo anlegen", "POST", "/todo", annaKey, `{"title":"Sprint","description":"planen"}`, http.StatusCreated, ""},
+		{"ToDo teilen", "POST", "/groups/1/shares", annaKey, `{"todo_id":1,"permission":"edit"}`, http.StatusCreated, ""},
+		{"Mitglied sieht ToDo", "GET", "/todo/1", benKey, "", http.StatusOK, `"permission":"edit"`},
+		{"Mitglieder der ToDo", "GET", "/todo/share/1", annaKey, "", http.StatusOK, `"group_id":1`},
+		{"nur über die Gruppe", "DELETE", "/todo/share/1/2", benKey, "", http.StatusConflict, "über eine Gruppe"},
+		{"Eigentümer bleibt", "DELETE", "/groups/1/members/1", carlaKey, "", http.StatusBadRequest, "Eigentümer bleibt"},
+		{"austreten", "DELETE", "/groups/1/members/2", benKey, "", http.StatusOK, ""},
+		{"Zugriff endet", "GET", "/todo/1", benKey, "", http.StatusUnauthorized, ""},
+		{"Admin löscht nicht", "DELETE", "/groups/1", carlaKey, "", http.StatusForbidden, "Nur der Eigentümer"},
+		{"löschen", "DELETE", "/groups/1", annaKey, "", http.StatusOK, ""},
+		{"Zugriff des Admins endet", "GET", "/todo/1", carlaKey, "", http.StatusUnauthorized, ""},
+	})
+}
+
+// Die Position (order) zählt je Liste, beim Verschieben werden beide Listen neu nummeriert
+func TestLists(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ohne Name", "POST", "/lists", annaKey, `{}`, http.StatusBadRequest, "Name fehlt"},
+		{"anlegen", "POST", "/lists", annaKey, `{"name":"Garten"}`, http.StatusCreated, `"id":1,"name":"Garten"`},
+		{"fremde Liste", "GET", "/lists/1", benKey, "", http.StatusBadRequest, "Ungültige list_id"},
+		{"in fremde Liste", "POST", "/todo", benKey, `{"title":"x","description":"y","list_id":1}`, http.StatusBadRequest, "Ungültige list_id"},
+		{"ohne Liste", "POST", "/todo", annaKey, `{"title":"Steuer","description":"-"}`, http.StatusCreated, ""},
+		{"Rasen", "POST", "/todo", annaKey, `{"title":"Rasen","description":"mähen","list_id":1}`, http.StatusCreated, ""},
+		{"Hecke", "POST", "/todo", annaKey, `{"title":"Hecke","description":"schneiden","list_id":1}`, http.StatusCreated, ""},
+		{"Position in der Liste", "GET", "/todo/3", annaKey, "", http.StatusOK, `"list_id":1,"order":2`},
+		{"Listen", "GET", "/lists", annaKey, "", http.StatusOK, `"name":"Garten"`},
+		{"Anzahl", "GET", "/lists/1", annaKey, "", http.StatusOK, `"count":2`},
+		{"umbenennen", "PATCH", "/lists/1", annaKey, `{"name":"Balkon"}`, http.StatusOK, "Liste erfolgreich umbenannt"},
+		{"herausnehmen", "PATCH", "/todo/2", annaKey, `{"list_id":0}`, http.StatusOK, ""},
+		{"ans Ende ohne Liste", "GET", "/todo/2", annaKey, "", http.StatusOK, `"list_id":null,"order":2`},
+		{"aufgerückt", "GET", "/todo/3", annaKey, "", http.StatusOK, `"list_id":1,"order":1`},
+	})
+	expectListTitles(t, server, "/todo/me?list=1", annaKey, "Hecke")
+	expectListTitles(t, server, "/todo/me?list=0", annaKey, "Steuer", "Rasen")
+
+	runSteps(t, server, []step{
+		{"löschen", "DELETE", "/lists/1", annaKey, "", http.StatusOK, "Liste erfolgreich gelöscht"},
+		{"ToDo bleibt", "GET", "/todo/3", annaKey, "", http.StatusOK, `"list_id":null,"order":3`},
+		{"gelöscht", "GET", "/lists/1", annaKey, "", http.StatusBadRequest, "Ungültige list_id"},
+	})
+}
+
+// Mit auto_complete folgt die ToDo den Punkten, ein Mitglied mit "complete" hakt nur ab
+func TestChecklist(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Umzug","description":"Kisten","auto_complete":true}`, http.StatusCreated, ""},
+		{"ohne Titel", "POST", "/todo/1/items", annaKey, `{}`, http.StatusBadRequest, "Titel fehlt"},
+		{"Kisten", "POST", "/todo/1/items", annaKey, `{"title":"Kisten"}`, http.StatusCreated, `"order":1`},
+		{"Helfer", "POST", "/todo/1/items", annaKey, `{"title":"Helfer"}`, http.StatusCreated, `"order":2`},
+		{"verschieben", "PATCH", "/todo/1/items/2", annaKey, `{"order":1}`, http.StatusOK, "Punkt erfolgreich aktualisiert"},
+		{"Position außerhalb", "PATCH", "/todo/1/items/2", annaKey, `{"order":3}`, http.StatusBadRequest, "außerhalb"},
+		{"Checkliste", "GET", "/todo/1/items", annaKey, "", http.StatusOK, `"title":"Helfer","completed":false,"order":1`},
+		{"fremde ToDo", "GET", "/todo/1/items", benKey, "", http.StatusUnauthorized, "Nicht autorisiert"},
+		{"fremder Punkt", "PATCH", "/todo/1/items/9", annaKey, `{"completed":true}`, http.StatusBadRequest, "Ungültige item_id"},
+		{"einladen", "POST", "/todo/share/1/2", annaKey, `{"permission":"complete"}`, http.StatusCreated, ""},
+		{"annehmen", "POST", "/invitations/1/accept", benKey, "", http.StatusOK, ""},
+		{"complete fügt nicht hinzu", "POST", "/todo/1/items", benKey, `{"title":"Transporter"}`, http.StatusForbidden, "Bearbeiten"},
+		{"complete hakt ab", "PATCH", "/todo/1/items/1", benKey, `{"completed":true}`, http.StatusOK, ""},
+		{"Fortschritt", "GET", "/todo/1", annaKey, "", http.StatusOK, `"completed":false`},
+		{"letzter Punkt", "PATCH", "/todo/1/items/2", annaKey, `{"completed":true}`, http.StatusOK, ""},
+		{"automatisch erledigt", "GET", "/todo/1", annaKey, "", http.StatusOK, `"progress":{"done":2,"total":2}`},
+		{"neuer Punkt", "POST", "/todo/1/items", annaKey, `{"title":"Schlüssel"}`, http.StatusCreated, ""},
+		{"wieder offen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"completed":false`},
+		{"löschen", "DELETE", "/todo/1/items/3", annaKey, "", http.StatusOK, "Punkt erfolgreich gelöscht"},
+		{"wieder erledigt", "GET", "/todo/1", annaKey, "", http.StatusOK, `"completed":true`},
+	})
+}
+
+// Beim Erledigen einer wiederkehrenden ToDo entsteht genau ein nächster Termin
+func TestRecurrence(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	runSteps(t, server, []step{
+		{"ungültige Regel", "POST", "/todo", annaKey, `{"title":"x","description":"y","recurrence":"FREQ=HOURLY"}`, http.StatusBadRequest, "ungültige Wiederholung"},
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Müll","description":"rausbringen","due_at":"2099-06-01","recurrence":"RRULE:FREQ=WEEKLY"}`, http.StatusCreated, ""},
+		{"lesen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"recurrence":"FREQ=WEEKLY"`},
+		{"erledigen", "PATCH", "/todo/status/1", annaKey, `{"completed":true}`, http.StatusOK, `"next_id":2`},
+		{"nächster Termin", "GET", "/todo/2", annaKey, "", http.StatusOK, `"due_at":"2099-06-08`},
+		{"Wiederholung gewandert", "GET", "/todo/2", annaKey, "", http.StatusOK, `"recurrence":"FREQ=WEEKLY"`},
+		{"wieder öffnen", "PATCH", "/todo/status/1", annaKey, `{"completed":false}`, http.StatusOK, ""},
+		{"kein weiterer Termin", "PATCH", "/todo/status/1", annaKey, `{"completed":true}`, http.StatusOK, `"message":"ToDo-Status erfolgreich aktualisiert"}`},
+		{"Wiederholung entfernen", "PATCH", "/todo/2", annaKey, `{"recurrence":""}`, http.StatusOK, ""},
+		{"einmalig erledigen", "PATCH", "/todo/status/2", annaKey, `{"completed":true}`, http.StatusOK, `"message":"ToDo-Status erfolgreich aktualisiert"}`},
+	})
+	expectListTitles(t, server, "/todo/me", annaKey, "Müll", "Müll")
+}
+
+// Tauscht einen Secret Key gegen einen Access Token und liefert den Wert für den Authorization Header
+func bearer(t *testing.T, server *httptest.Server, secretKey string) string {
+	t.Helper()
+	status, body := request(t, server, "POST", "/auth/token", "", `{"grant_type":"secret_key","secret_key":"`+secretKey+`"}`)
+	access, _ := decodeTokens(t, status, body)
+	return access
+}
+
+// Access Tokens gelten nur, solange Benutzer und API Key existieren
+func TestBearerTokens(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+	readKey := createKey(t, server, annaKey, "read") // Key 3
+	readToken := bearer(t, server, readKey)
+	annaToken := bearer(t, server, annaKey)
+	benToken := bearer(t, server, benKey)
+
+	runSteps(t, server, []step{
+		{"ungültiger Header", "GET", "/todo/me", "Bearer ", "", http.StatusUnauthorized, "Ungültiger Authorization Header"},
+		{"ungültiger Token", "GET", "/todo/me", "Bearer abc", "", http.StatusUnauthorized, "Ungültiger Access Token"},
+		{"Token liest", "GET", "/todo/me", readToken, "", http.StatusOK, ""},
+		{"Scope des Keys", "POST", "/todo", readToken, `{"title":"A","description":"a"}`, http.StatusForbidden, ""},
+		{"Key widerrufen", "DELETE", "/users/me/keys/3", annaKey, "", http.StatusOK, ""},
+		{"Token des widerrufenen Keys", "GET", "/todo/me", readToken, "", http.StatusUnauthorized, "nicht mehr gültig"},
+		{"anderer Key gilt weiter", "GET", "/todo/me", annaToken, "", http.StatusOK, ""},
+		{"Ben liest", "GET", "/todo/me", benToken, "", http.StatusOK, ""},
+		{"Konto löschen", "DELETE", "/users/me", benKey, "", http.StatusOK, ""},
+		{"Token des gelöschten Kontos", "GET", "/todo/me", benToken, "", http.StatusUnauthorized, "nicht mehr gültig"},
+	})
+}
+
+// Zwei Server im selben Prozess teilen keinen Zustand
+func TestServerIsolation(t *testing.T) {
+	t.Parallel()
+	first, _ := newTestServer(t)
+	second, _ := newTestServer(t)
+
+	runSteps(t, first, []step{
+		{"anlegen", "POST", "/todo", annaKey, `{"title":"Nur hier","description":"erster Server"}`, http.StatusCreated, ""},
+		{"lesen", "GET", "/todo/1", annaKey, "", http.StatusOK, `"title":"Nur hier"`},
+	})
+	runSteps(t, second, []step{
+		{"andere Instanz", "GET", "/todo/1", annaKey, "", http.StatusBadRequest, "Ungültige todo_id"},
+		{"leere Liste", "GET", "/todo/me", annaKey, "", http.StatusOK, `{"todos":[],"next_cursor":null}`},
+		{"Suche", "GET", "/todo/search?q=hier", annaKey, "", http.StatusOK, "[]"},
+	})
+}
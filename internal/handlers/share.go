@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Antwort, wenn ein Mitglied seinen Zugriff über eine Gruppe hat und er nur dort geändert werden kann
+const groupAccessMessage = "Zugriff besteht über eine Gruppe und kann nur dort geändert werden"
+
+// /todo/share/{todoID} und /todo/share/{todoID}/{userID}
+func (s *Server) ShareHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getTodoMembers(w, r) // GET /todo/share/{todoID}: Mitglieder einer ToDo
+	case http.MethodPost:
+		s.ShareToDoByID(w, r) // POST /todo/share/{todoID}/{userID}: Benutzer zur ToDo einladen
+	case http.MethodPatch:
+		s.updateMemberPermission(w, r) // PATCH /todo/share/{todoID}/{userID}: Berechtigung eines Mitglieds ändern
+	case http.MethodDelete:
+		s.unshareTodo(w, r) // DELETE /todo/share/{todoID}/{userID}: Freigabe entfernen bzw. Einladung zurückziehen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+// Prüft ob der Benutzer die ToDo sehen darf, also Eigentümer oder Mitglied ist (todo aus store.GetTodo mit seiner ID)
+func canAccess(todo models.ToDo, userID int) bool {
+	return todo.UserID == userID || todo.Permission != ""
+}
+
+// Prüft ob der Benutzer die ToDo im Umfang required ändern darf. Der Eigentümer darf immer,
+// bei Mitgliedern entscheidet die beim Teilen vergebene Berechtigung.
+func sharedAllows(todo models.ToDo, userID int, required models.SharePermission) bool {
+	return todo.UserID == userID || todo.Permission.Allows(required)
+}
+
+// Liest die Berechtigung aus dem Request Body von POST /todo/share, ein leerer Body ergibt "complete"
+func decodeSharePermission(r *http.Request) (models.SharePermission, error) {
+	var body struct {
+		Permission string `json:"permission"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil && err != io.EOF {
+		return "", errors.New("Request Body konnte nicht decodiert werden")
+	}
+	return parseSharePermission(body.Permission)
+}
+
+// Wandelt die Berechtigung aus einem Request Body um, ohne Angabe "complete"
+func parseSharePermission(name string) (models.SharePermission, error) {
+	if name == "" {
+		return models.PermissionComplete, nil
+	}
+	permission, err := models.ParseSharePermission(name)
+	if err != nil {
+		return "", errors.New("Ungültige Berechtigung (erlaubt: view, complete, edit)")
+	}
+	return permission, nil
+}
+
+func (s *Server) getTodoMembers(w http.ResponseWriter, r *http.Request) {
+	// Parameter auslesen und prüfen
+	todoID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/todo/share/"), 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+		return
+	}
+
+	// Eigentümer und Mitglieder dürfen sehen, mit wem die ToDo geteilt ist, offene Einladungen nur der Eigentümer
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !canAccess(todo, callerID(r)) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return
+	}
+
+	members, err := s.store.GetTodoMembers(int(todoID))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var invitations []models.Invitation
+	if todo.UserID == callerID(r) {
+		invitations, err = s.store.GetInvitationsByTodo(int(todoID))
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		OwnerID     int                 `json:"owner_id"`
+		Members     []models.TodoMember `json:"members"`
+		Invitations []models.Invitation `json:"invitations,omitempty"` // nur für den Eigentümer
+	}{
+		OwnerID:     todo.UserID,
+		Members:     members,
+		Invitations: invitations,
+	})
+}
+
+// Liest {todoID}/{userID} aus dem Pfad und die ToDo aus Sicht des angemeldeten Benutzers.
+// Sendet bei ungültigen Parametern oder fehlendem Zugriff selbst die Antwort und liefert dann false.
+func (s *Server) shareTarget(w http.ResponseWriter, r *http.Request) (models.ToDo, int, bool) {
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/todo/share/"), "/") // {todoID}, {userID}
+	if len(pathSegments) != 2 {
+		sendErrorResponse(w, http.StatusBadRequest, "Fehlende Parameter")
+		return models.ToDo{}, 0, false
+	}
+	todoID, err := strconv.ParseInt(pathSegments[0], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+		return models.ToDo{}, 0, false
+	}
+	userID, err := strconv.ParseInt(pathSegments[1], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige UserID")
+		return models.ToDo{}, 0, false
+	}
+
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige TodoID")
+			return models.ToDo{}, 0, false
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return models.ToDo{}, 0, false
+	}
+	if !canAccess(todo, callerID(r)) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return models.ToDo{}, 0, false
+	}
+	return todo, int(userID), true
+}
+
+func (s *Server) updateMemberPermission(w http.ResponseWriter, r *http.Request) {
+	todo, userID, ok := s.shareTarget(w, r)
+	if !ok {
+		return
+	}
+	if todo.UserID != callerID(r) {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann Berechtigungen ändern")
+		return
+	}
+
+	// Request Body auslesen, die Berechtigung ist hier Pflicht
+	var body struct {
+		Permission string `json:"permission"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+	permission, err := models.ParseSharePermission(body.Permission)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige Berechtigung (erlaubt: view, complete, edit)")
+		return
+	}
+
+	err = s.store.SetMemberPermission(todo.ID, userID, permission)
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "ToDo ist nicht mit diesem Benutzer geteilt")
+			return
+		}
+		if err == store.ErrGroupAccess {
+			sendErrorResponse(w, http.StatusConflict, groupAccessMessage)
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Berechtigung erfolgreich geändert",
+	})
+}
+
+func (s *Server) unshareTodo(w http.ResponseWriter, r *http.Request) {
+	// Der Eigentümer kann jedes Mitglied entfernen, ein Mitglied nur sich selbst
+	todo, userID, ok := s.shareTarget(w, r)
+	if !ok {
+		return
+	}
+	if todo.UserID != callerID(r) && userID != callerID(r) {
+		sendErrorResponse(w, http.StatusForbidden, "Nur der Eigentümer kann die Freigabe für andere entfernen")
+		return
+	}
+
+	// Mitgliedschaft beenden, die Position (order) in der Liste des Mitglieds passt der Store an.
+	// Ist der Benutzer noch kein Mitglied, zieht der Eigentümer eine offene Einladung zurück.
+	err := s.store.RemoveMember(todo.ID, userID)
+	if err == store.ErrNotFound && todo.UserID == callerID(r) {
+		err = s.withdrawInvitation(todo.ID, userID)
+	}
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "ToDo ist nicht mit diesem Benutzer geteilt")
+			return
+		}
+		if err == store.ErrGroupAccess {
+			sendErrorResponse(w, http.StatusConflict, groupAccessMessage)
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Freigabe erfolgreich entfernt",
+	})
+}
+
+// Löscht die offene Einladung des Benutzers zur ToDo, store.ErrNotFound wenn keine offen ist
+func (s *Server) withdrawInvitation(todoID, userID int) error {
+	invitations, err := s.store.GetInvitationsByTodo(todoID)
+	if err != nil {
+		return err
+	}
+	for _, invitation := range invitations {
+		if invitation.UserID == userID {
+			return s.store.DeleteInvitation(invitation.ID)
+		}
+	}
+	return store.ErrNotFound
+}
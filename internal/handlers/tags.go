@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const maxTagLength = 50 // maximale Länge eines Tag-Namens
+
+// Prüft einen Tag-Namen und entfernt Leerzeichen am Rand. Komma und Schrägstrich sind nicht erlaubt,
+// da Tags im Filter (?tags=a,b) und im Pfad (/tags/user/{id}/{name}) verwendet werden.
+func normalizeTag(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("Leerer Tag")
+	}
+	if strings.ContainsAny(name, ",/") {
+		return "", errors.New("Tag darf weder Komma noch Schrägstrich enthalten")
+	}
+	if len([]rune(name)) > maxTagLength {
+		return "", errors.New("Tag ist länger als " + strconv.Itoa(maxTagLength) + " Zeichen")
+	}
+	return name, nil
+}
+
+// Prüft und normalisiert eine Liste von Tags, doppelte Einträge werden entfernt
+func normalizeTags(names []string) ([]string, error) {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, name := range names {
+		tag, err := normalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// /todo/{todoID}/tags und /todo/{todoID}/tags/{name}
+func (s *Server) TodoTagsHandler(w http.ResponseWriter, r *http.Request) {
+	// Parameter auslesen und prüfen
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/todo/"), "/") // {todoID}, "tags", {name}
+	todoID, err := strconv.ParseInt(pathSegments[0], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige todo_id")
+		return
+	}
+
+	// Prüfen ob todoID vorhanden und Berechtigung prüfen, Mitglieder vergeben ihre eigenen Tags
+	todo, err := s.store.GetTodo(int(todoID), callerID(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "Ungültige todo_id")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !canAccess(todo, callerID(r)) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && len(pathSegments) == 2:
+		s.addTodoTags(w, r, int(todoID), callerID(r)) // POST /todo/{id}/tags: Tags hinzufügen
+	case r.Method == http.MethodDelete && len(pathSegments) == 3:
+		s.removeTodoTag(w, int(todoID), callerID(r), pathSegments[2]) // DELETE /todo/{id}/tags/{name}: Tag entfernen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+func (s *Server) addTodoTags(w http.ResponseWriter, r *http.Request, todoID, userID int) {
+	// Request Body auslesen
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+
+	tags, err := normalizeTags(body.Tags)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(tags) == 0 {
+		sendErrorResponse(w, http.StatusBadRequest, "Keine Tags angegeben")
+		return
+	}
+
+	err = s.store.AddTags(todoID, userID, tags)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Tags erfolgreich hinzugefügt",
+	})
+}
+
+func (s *Server) removeTodoTag(w http.ResponseWriter, todoID, userID int, name string) {
+	err := s.store.RemoveTag(todoID, userID, name)
+	if err != nil {
+		if err == store.ErrNotFound {
+			sendErrorResponse(w, http.StatusBadRequest, "ToDo trägt diesen Tag nicht")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Tag erfolgreich entfernt",
+	})
+}
+
+// /tags/user/{userID} und /tags/user/{userID}/{name}
+func (s *Server) TagsHandler(w http.ResponseWriter, r *http.Request) {
+	// Parameter auslesen und prüfen
+	pathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/tags/user/"), "/") // {userID}, {name}
+	userID, err := strconv.ParseInt(pathSegments[0], 10, 0)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültige userID")
+		return
+	}
+
+	// Berechtigung prüfen
+	if int(userID) != callerID(r) {
+		sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(pathSegments) == 1:
+		s.getTagsByUser(w, r, int(userID)) // GET /tags/user/{id}: alle Tags mit Anzahl
+	case r.Method == http.MethodPatch && len(pathSegments) == 2:
+		s.renameTag(w, r, int(userID), pathSegments[1]) // PATCH /tags/user/{id}/{name}: umbenennen bzw. zusammenführen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+func (s *Server) getTagsByUser(w http.ResponseWriter, r *http.Request, userID int) {
+	tags, err := s.store.GetTagsByUser(userID)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (s *Server) renameTag(w http.ResponseWriter, r *http.Request, userID int, oldName string) {
+	// Request Body auslesen
+	var body struct {
+		Name string `json:"name"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+
+	newName, err := normalizeTag(body.Name)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	merged, err := s.store.RenameTag(userID, oldName, newName)
+	switch err {
+	case nil:
+	case store.ErrNotFound:
+		sendErrorResponse(w, http.StatusBadRequest, "Tag nicht vorhanden")
+		return
+	case store.ErrNoChanges:
+		sendErrorResponse(w, http.StatusBadRequest, "Der neue Name ist der gleiche wie der alte Name")
+		return
+	default:
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	message := "Tag erfolgreich umbenannt"
+	if merged {
+		message = "Tag erfolgreich mit " + newName + " zusammengeführt"
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: message,
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Arten der Anmeldung an POST /auth/token
+const (
+	grantSecretKey    = "secret_key"    // API Key gegen ein Token-Paar tauschen
+	grantRefreshToken = "refresh_token" // Refresh Token gegen ein neues Token-Paar tauschen
+)
+
+// POST /auth/token: stellt einen Access Token (JWT) und einen Refresh Token aus, ohne Anmeldung
+func (s *Server) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur Post Methode erlaubt")
+		return
+	}
+
+	// Request Body auslesen
+	var body struct {
+		GrantType    string `json:"grant_type"`
+		SecretKey    string `json:"secret_key"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+
+	var credential *store.Credential
+	switch body.GrantType {
+	case grantSecretKey:
+		if body.SecretKey == "" {
+			sendErrorResponse(w, http.StatusBadRequest, "Secret Key fehlt")
+			return
+		}
+		credential, err = s.credentialForSecretKey(body.SecretKey)
+		if err == errAmbiguousSecretKey {
+			sendErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if credential == nil {
+			sendErrorResponse(w, http.StatusUnauthorized, "Nicht autorisiert")
+			return
+		}
+
+	case grantRefreshToken:
+		if body.RefreshToken == "" {
+			sendErrorResponse(w, http.StatusBadRequest, "Refresh Token fehlt")
+			return
+		}
+		// Jeder Refresh Token ist nur einmal gültig und wird durch einen neuen ersetzt
+		c, err := s.store.UseRefreshToken(auth.LookupKey(body.RefreshToken))
+		switch err {
+		case nil:
+			credential = &c
+		case store.ErrNotFound:
+			sendErrorResponse(w, http.StatusUnauthorized, "Ungültiger Refresh Token")
+			return
+		case store.ErrTokenReused:
+			s.logger.Printf("Refresh Token erneut verwendet, alle Refresh Tokens des Keys widerrufen")
+			sendErrorResponse(w, http.StatusUnauthorized, "Refresh Token wurde bereits verwendet, bitte erneut mit dem Secret Key anmelden")
+			return
+		default:
+			sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Ungültiger grant_type (erlaubt: "+grantSecretKey+", "+grantRefreshToken+")")
+		return
+	}
+
+	// Letzte Verwendung des Keys vermerken, ein Fehler verhindert die Anmeldung nicht
+	if err := s.store.MarkAPIKeyUsed(credential.KeyID); err != nil {
+		s.logger.Printf("Verwendung von API Key %d konnte nicht gespeichert werden: %v", credential.KeyID, err)
+	}
+
+	// Token-Paar ausstellen, vom Refresh Token wird nur der Hash gespeichert
+	principal := auth.Principal{UserID: credential.UserID, KeyID: credential.KeyID, Scopes: credential.Scopes}
+	accessToken, err := s.tokens.Issue(principal, s.config.AccessTokenTTL)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	refreshToken, err := auth.GenerateSecret()
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	err = s.store.CreateRefreshToken(credential.KeyID, auth.LookupKey(refreshToken), time.Now().Add(s.config.RefreshTokenTTL))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort, Tokens dürfen nicht zwischengespeichert werden
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"` // Sekunden
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.config.AccessTokenTTL / time.Second),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(credential.Scopes, " "),
+	})
+}
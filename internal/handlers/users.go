@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+const maxDisplayNameLength = 100 // maximale Länge eines Anzeigenamens
+
+// Prüft Anzeigename und E-Mail aus einem Request Body, leere Felder bleiben leer
+func normalizeProfile(user models.User) (models.User, error) {
+	user.DisplayName = strings.TrimSpace(user.DisplayName)
+	if len([]rune(user.DisplayName)) > maxDisplayNameLength {
+		return user, errors.New("Anzeigename ist länger als " + strconv.Itoa(maxDisplayNameLength) + " Zeichen")
+	}
+
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	if user.Email != "" {
+		address, err := mail.ParseAddress(user.Email)
+		if err != nil || address.Address != user.Email {
+			return user, errors.New("Ungültige E-Mail Adresse")
+		}
+	}
+	return user, nil
+}
+
+// POST /users: Registrierung eines neuen Benutzers, ohne Anmeldung
+func (s *Server) UsersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, "Nur Post Methode erlaubt")
+		return
+	}
+
+	// Request Body auslesen
+	var body models.User
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+
+	user, err := normalizeProfile(models.User{DisplayName: body.DisplayName, Email: body.Email})
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if user.DisplayName == "" {
+		sendErrorResponse(w, http.StatusBadRequest, "Anzeigename fehlt")
+		return
+	}
+
+	// Neuen Secret Key erzeugen, gespeichert wird nur der Hash
+	secretKey, err := auth.GenerateSecret()
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	hash, err := auth.HashSecret(secretKey)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.store.CreateUser(&user, hash, auth.LookupKey(secretKey))
+	if err != nil {
+		if err == store.ErrEmailExists {
+			sendErrorResponse(w, http.StatusConflict, "E-Mail bereits vergeben")
+			return
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort, der Secret Key wird nur dieses eine Mal übermittelt
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		User      models.User `json:"user"`
+		SecretKey string      `json:"secret_key"`
+	}{
+		User:      user,
+		SecretKey: secretKey,
+	})
+}
+
+// /users/me: Profil des angemeldeten Benutzers
+func (s *Server) CurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getCurrentUser(w, r) // GET /users/me: Profil abrufen
+	case http.MethodPatch:
+		s.patchCurrentUser(w, r) // PATCH /users/me: Anzeigename bzw. E-Mail ändern
+	case http.MethodDelete:
+		s.deleteCurrentUser(w, r) // DELETE /users/me: Konto löschen
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, "Nicht unterstützte Methode")
+	}
+}
+
+func (s *Server) getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, err := s.store.GetUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (s *Server) patchCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Request Body auslesen
+	var body models.User
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "Request Body konnte nicht decodiert werden")
+		return
+	}
+
+	changes, err := normalizeProfile(models.User{DisplayName: body.DisplayName, Email: body.Email})
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.store.UpdateUser(callerID(r), changes)
+	switch err {
+	case nil:
+	case store.ErrNoChanges:
+		sendErrorResponse(w, http.StatusBadRequest, "Keine gültigen Parameter im Request Body")
+		return
+	case store.ErrEmailExists:
+		sendErrorResponse(w, http.StatusConflict, "E-Mail bereits vergeben")
+		return
+	default:
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Profil erfolgreich aktualisiert",
+	})
+}
+
+func (s *Server) deleteCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Löscht eigene ToDos und Tags, mit anderen geteilte ToDos gehen an das älteste Mitglied über
+	err := s.store.DeleteUser(callerID(r))
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Senden der Antwort
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{
+		Message: "Konto erfolgreich gelöscht",
+	})
+}
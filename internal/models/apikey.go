@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// API Key eines Benutzers. Der Key selbst wird nur beim Erstellen einmal übermittelt, gespeichert ist nur sein Hash.
+type APIKey struct {
+	ID         int        `json:"id"`                     // ID des Keys
+	Name       string     `json:"name"`                   // frei wählbarer Name, z.B. "Laptop" oder "CI"
+	Scopes     []string   `json:"scopes"`                 // Berechtigungen, z.B. ["read"] für eine reine Anzeige
+	CreatedAt  time.Time  `json:"created_at"`             // Zeitpunkt der Erstellung
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // letzter erfolgreicher Zugriff
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`   // Ablauf, ohne Angabe unbegrenzt gültig
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`   // Zeitpunkt des Widerrufs
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Punkt der Checkliste einer ToDo
+type ChecklistItem struct {
+	ID        int       `json:"id"`
+	TodoID    int       `json:"todo_id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	Order     int       `json:"order"` // Position innerhalb der Checkliste
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Fortschritt der Checkliste einer ToDo, z.B. 3 von 5 Punkten erledigt
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
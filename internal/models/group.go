@@ -0,0 +1,69 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rolle eines Benutzers in einer Gruppe
+type GroupRole string
+
+const (
+	GroupRoleOwner  GroupRole = "owner"  // hat die Gruppe angelegt, vergibt Rollen und kann sie löschen
+	GroupRoleAdmin  GroupRole = "admin"  // verwaltet Mitglieder und Freigaben der Gruppe
+	GroupRoleMember GroupRole = "member" // sieht die mit der Gruppe geteilten ToDos
+)
+
+// Gruppe (Team) von Benutzern, mit der ToDos und ganze Listen geteilt werden können
+type Group struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Role        GroupRole         `json:"role,omitempty"` // Rolle des angemeldeten Benutzers
+	CreatedAt   time.Time         `json:"created_at"`
+	Members     []GroupMember     `json:"members,omitempty"`
+	Invitations []GroupInvitation `json:"invitations,omitempty"` // offene Einladungen, nur für Eigentümer und Admins
+}
+
+// Mitglied einer Gruppe
+type GroupMember struct {
+	UserID      int       `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Role        GroupRole `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// Offene Einladung in eine Gruppe
+type GroupInvitation struct {
+	ID        int       `json:"id"`
+	GroupID   int       `json:"group_id"`
+	GroupName string    `json:"group_name"`
+	UserID    int       `json:"user_id"` // eingeladener Benutzer
+	Role      GroupRole `json:"role"`    // Rolle nach der Annahme
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Freigabe einer ToDo oder einer Liste (alle ToDos in einer Liste des Eigentümers) für eine Gruppe.
+// Neue Mitglieder der Gruppe erhalten den Zugriff automatisch, ausscheidende verlieren ihn.
+type GroupShare struct {
+	ID         int             `json:"id"`
+	GroupID    int             `json:"group_id"`
+	OwnerID    int             `json:"owner_id"`          // Eigentümer der ToDo bzw. Liste
+	TodoID     *int            `json:"todo_id,omitempty"` // geteilte ToDo
+	ListID     *int            `json:"list_id,omitempty"` // geteilte Liste, wenn keine ToDo angegeben ist
+	Permission SharePermission `json:"permission"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Wandelt einen Namen in eine vergebbare Rolle um, owner kann nicht vergeben werden
+func ParseGroupRole(name string) (GroupRole, error) {
+	switch role := GroupRole(name); role {
+	case GroupRoleAdmin, GroupRoleMember:
+		return role, nil
+	}
+	return "", fmt.Errorf("ungültige Rolle %q, erlaubt sind admin, member", name)
+}
+
+// Prüft ob die Rolle Mitglieder und Freigaben der Gruppe verwalten darf
+func (r GroupRole) CanManage() bool {
+	return r == GroupRoleOwner || r == GroupRoleAdmin
+}
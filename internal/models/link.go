@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Öffentlicher Link zum Lesen einer ToDo oder einer gefilterten Liste ohne Anmeldung.
+// Der Token wird nur beim Erstellen einmal übermittelt, gespeichert ist nur sein Hash.
+type ShareLink struct {
+	ID        int        `json:"id"`                   // ID des Links
+	TodoID    *int       `json:"todo_id,omitempty"`    // geteilte ToDo, ohne Angabe eine Liste
+	Filter    string     `json:"filter,omitempty"`     // Query-Parameter der Liste wie bei GET /todo/me, z.B. "category=Baustelle&completed=false"
+	CreatedAt time.Time  `json:"created_at"`           // Zeitpunkt der Erstellung
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Ablauf, ohne Angabe unbegrenzt gültig
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // Zeitpunkt des Widerrufs
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Liste (Projekt) eines Benutzers, fasst ToDos mit eigener Reihenfolge zusammen
+type List struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Count     int       `json:"count"` // Anzahl der ToDos in der Liste
+	CreatedAt time.Time `json:"created_at"`
+}
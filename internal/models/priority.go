@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Priorität einer ToDo, in JSON als Name ("none" bis "urgent"), in der Datenbank als Zahl
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+func (p Priority) String() string {
+	if p < PriorityNone || p > PriorityUrgent {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// Wandelt einen Namen in eine Priorität um
+func ParsePriority(name string) (Priority, error) {
+	for i, n := range priorityNames {
+		if n == name {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("ungültige Priorität %q, erlaubt sind none, low, medium, high, urgent", name)
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var name string
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return err
+	}
+
+	*p, err = ParsePriority(name)
+	return err
+}
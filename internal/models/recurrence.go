@@ -0,0 +1,250 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequenzen einer Wiederholung
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+const maxRecurrenceInterval = 999 // größtes erlaubtes INTERVAL
+
+var weekdayNames = map[string]time.Weekday{"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday}
+
+// Wiederholung einer ToDo als Teilmenge einer iCalendar RRULE (RFC 5545), z.B. "FREQ=WEEKLY;BYDAY=MO,TH".
+// Unterstützt werden FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, BYDAY (nur WEEKLY, ohne Zahl davor),
+// BYMONTHDAY (nur MONTHLY, 1 bis 31 oder -1 für den letzten Tag) und die Erweiterung X-FROM=COMPLETION,
+// mit der der nächste Termin ab der Erledigung statt ab der Fälligkeit gezählt wird.
+type Recurrence struct {
+	Freq           string         // eine der Freq... Konstanten, leer = keine Wiederholung
+	Interval       int            // alle N Tage, Wochen bzw. Monate
+	ByDay          []time.Weekday // Wochentage bei WEEKLY, Montag zuerst
+	ByMonthDay     int            // Tag im Monat bei MONTHLY, -1 = letzter Tag, 0 = Tag der Fälligkeit
+	FromCompletion bool           // Abstand ab der Erledigung (X-FROM=COMPLETION)
+}
+
+// Wandelt eine RRULE in eine Wiederholung um, ein vorangestelltes "RRULE:" ist erlaubt
+func ParseRecurrence(rule string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	invalid := func(format string, args ...interface{}) (Recurrence, error) {
+		return Recurrence{}, fmt.Errorf("ungültige Wiederholung %q: %s", rule, fmt.Sprintf(format, args...))
+	}
+
+	value := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		name, param, ok := strings.Cut(part, "=")
+		if !ok || param == "" {
+			return invalid("erwartet wird NAME=WERT, z.B. FREQ=DAILY")
+		}
+		if seen[name] {
+			return invalid("%s ist mehrfach angegeben", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if param != FreqDaily && param != FreqWeekly && param != FreqMonthly {
+				return invalid("FREQ muss DAILY, WEEKLY oder MONTHLY sein")
+			}
+			r.Freq = param
+		case "INTERVAL":
+			interval, err := strconv.Atoi(param)
+			if err != nil || interval < 1 || interval > maxRecurrenceInterval {
+				return invalid("INTERVAL muss zwischen 1 und %d liegen", maxRecurrenceInterval)
+			}
+			r.Interval = interval
+		case "BYDAY":
+			days := map[time.Weekday]bool{}
+			for _, day := range strings.Split(param, ",") {
+				weekday, ok := weekdayNames[day]
+				if !ok {
+					return invalid("BYDAY erlaubt nur MO, TU, WE, TH, FR, SA, SU")
+				}
+				days[weekday] = true
+			}
+			for weekday := range days {
+				r.ByDay = append(r.ByDay, weekday)
+			}
+			sort.Slice(r.ByDay, func(i, j int) bool { return weekdayIndex(r.ByDay[i]) < weekdayIndex(r.ByDay[j]) })
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(param)
+			if err != nil || day == 0 || day < -1 || day > 31 {
+				return invalid("BYMONTHDAY muss zwischen 1 und 31 liegen oder -1 (letzter Tag) sein")
+			}
+			r.ByMonthDay = day
+		case "X-FROM":
+			if param != "COMPLETION" {
+				return invalid("X-FROM erlaubt nur COMPLETION")
+			}
+			r.FromCompletion = true
+		default:
+			return invalid("%s wird nicht unterstützt", name)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return invalid("FREQ fehlt")
+	case r.ByDay != nil && r.Freq != FreqWeekly:
+		return invalid("BYDAY ist nur mit FREQ=WEEKLY erlaubt")
+	case r.ByMonthDay != 0 && r.Freq != FreqMonthly:
+		return invalid("BYMONTHDAY ist nur mit FREQ=MONTHLY erlaubt")
+	case r.FromCompletion && (r.ByDay != nil || r.ByMonthDay != 0):
+		return invalid("X-FROM=COMPLETION ist nicht mit BYDAY oder BYMONTHDAY kombinierbar")
+	}
+	return r, nil
+}
+
+// Leere Wiederholung, wird beim PATCH als "Wiederholung entfernen" behandelt
+func (r Recurrence) IsZero() bool {
+	return r.Freq == ""
+}
+
+// RRULE in einheitlicher Schreibweise, INTERVAL=1 entfällt
+func (r Recurrence) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := []string{}
+		for _, weekday := range r.ByDay {
+			days = append(days, strings.ToUpper(weekday.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.FromCompletion {
+		parts = append(parts, "X-FROM=COMPLETION")
+	}
+	return strings.Join(parts, ";")
+}
+
+func (r Recurrence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (r *Recurrence) UnmarshalJSON(data []byte) error {
+	var rule string
+	err := json.Unmarshal(data, &rule)
+	if err != nil {
+		return err
+	}
+
+	// Leerer String -> Nullwert
+	if rule == "" {
+		*r = Recurrence{}
+		return nil
+	}
+	*r, err = ParseRecurrence(rule)
+	return err
+}
+
+// Fälligkeit des nächsten Termins einer ToDo, die zum Zeitpunkt completedAt erledigt wurde. Gezählt wird ab der
+// Fälligkeit due, bei X-FROM=COMPLETION oder ohne Fälligkeit ab dem Tag der Erledigung (Serverzeit). Die Uhrzeit der
+// Fälligkeit bleibt erhalten, ohne Fälligkeit gilt das Ende des Tages. Termine, die bei der Erledigung schon
+// vorbei sind, werden übersprungen.
+func (r Recurrence) Next(due *time.Time, completedAt time.Time) time.Time {
+	var base time.Time
+	if due != nil && !r.FromCompletion {
+		base = due.In(time.Local)
+	} else {
+		day := completedAt.In(time.Local)
+		hour, minute, second := 23, 59, 59
+		if due != nil {
+			hour, minute, second = due.In(time.Local).Clock()
+		}
+		base = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, time.Local)
+	}
+
+	next := r.step(base)
+	if r.FromCompletion {
+		return next
+	}
+	for !next.After(completedAt) {
+		next = r.step(next)
+	}
+	return next
+}
+
+// Nächster Termin nach t
+func (r Recurrence) step(t time.Time) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch r.Freq {
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*interval)
+		}
+		// Nur Wochen im Abstand INTERVAL zur Woche von t zählen, Wochen beginnen am Montag
+		for days := 1; ; days++ {
+			candidate := t.AddDate(0, 0, days)
+			weeks := (dayNumber(weekStart(candidate)) - dayNumber(weekStart(t))) / 7
+			if weeks%interval == 0 && r.onDay(candidate.Weekday()) {
+				return candidate
+			}
+		}
+	case FreqMonthly:
+		day := r.ByMonthDay
+		if day == 0 {
+			day = t.Day()
+		}
+		// Kürzere Monate erhalten den Termin an ihrem letzten Tag
+		hour, minute, second := t.Clock()
+		for months := 0; ; months += interval {
+			first := time.Date(t.Year(), t.Month()+time.Month(months), 1, hour, minute, second, 0, t.Location())
+			last := first.AddDate(0, 1, -1).Day()
+			candidate := first.AddDate(0, 0, last-1)
+			if day > 0 && day < last {
+				candidate = first.AddDate(0, 0, day-1)
+			}
+			if candidate.After(t) {
+				return candidate
+			}
+		}
+	default:
+		return t.AddDate(0, 0, interval)
+	}
+}
+
+func (r Recurrence) onDay(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Position in der Woche, Montag = 0
+func weekdayIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// Montag der Woche von t
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -weekdayIndex(t.Weekday()))
+}
+
+// Fortlaufende Nummer des Kalendertags, unabhängig von Zeitzone und Sommerzeit
+func dayNumber(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// Zeitpunkt in Serverzeit, mit der Next rechnet
+func localTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestParseRecurrenceInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"leer", ""},
+		{"ohne FREQ", "INTERVAL=2"},
+		{"unbekannte FREQ", "FREQ=YEARLY"},
+		{"doppelter Schlüssel", "FREQ=DAILY;FREQ=WEEKLY"},
+		{"doppeltes INTERVAL", "FREQ=DAILY;INTERVAL=2;INTERVAL=3"},
+		{"ohne Wert", "FREQ=DAILY;INTERVAL="},
+		{"INTERVAL 0", "FREQ=DAILY;INTERVAL=0"},
+		{"INTERVAL 1000", "FREQ=DAILY;INTERVAL=1000"},
+		{"BYDAY mit MONTHLY", "FREQ=MONTHLY;BYDAY=MO"},
+		{"BYDAY mit Zahl", "FREQ=WEEKLY;BYDAY=1MO"},
+		{"BYMONTHDAY mit WEEKLY", "FREQ=WEEKLY;BYMONTHDAY=1"},
+		{"BYMONTHDAY 0", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"BYMONTHDAY -2", "FREQ=MONTHLY;BYMONTHDAY=-2"},
+		{"BYMONTHDAY 32", "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{"X-FROM mit BYDAY", "FREQ=WEEKLY;BYDAY=MO;X-FROM=COMPLETION"},
+		{"X-FROM mit BYMONTHDAY", "FREQ=MONTHLY;BYMONTHDAY=15;X-FROM=COMPLETION"},
+		{"X-FROM unbekannt", "FREQ=DAILY;X-FROM=DUE"},
+		{"nicht unterstützt", "FREQ=DAILY;COUNT=3"},
+	}
+	for _, tt := range tests {
+		if r, err := ParseRecurrence(tt.rule); err == nil {
+			t.Errorf("%s: ParseRecurrence(%q) = %v, erwartet Fehler", tt.name, tt.rule, r)
+		}
+	}
+}
+
+func TestRecurrenceString(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		{"rrule:freq=weekly;byday=th,mo,mo", "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{"FREQ=WEEKLY;BYDAY=SU,SA;INTERVAL=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU"},
+		{"BYMONTHDAY=-1;FREQ=MONTHLY;INTERVAL=3", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=-1"},
+		{"FREQ=DAILY;X-FROM=COMPLETION;INTERVAL=2", "FREQ=DAILY;INTERVAL=2;X-FROM=COMPLETION"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q): %v", tt.rule, err)
+		}
+		if r.String() != tt.want {
+			t.Errorf("ParseRecurrence(%q).String() = %q, erwartet %q", tt.rule, r.String(), tt.want)
+		}
+
+		// Die einheitliche Schreibweise ergibt wieder dieselbe Wiederholung
+		again, err := ParseRecurrence(r.String())
+		if err != nil || again.String() != tt.want {
+			t.Errorf("ParseRecurrence(%q) = %q, %v", r.String(), again.String(), err)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		due       string // leer = ohne Fälligkeit
+		completed string
+		want      string
+	}{
+		{"täglich", "FREQ=DAILY", "2024-01-01 09:00:00", "2023-12-31 12:00:00", "2024-01-02 09:00:00"},
+		{"verpasste Termine überspringen", "FREQ=DAILY", "2024-01-01 09:00:00", "2024-01-03 12:00:00", "2024-01-04 09:00:00"},
+		{"BYDAY in derselben Woche", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "2024-01-01 10:00:00", "2023-12-31 12:00:00", "2024-01-05 10:00:00"},
+		{"BYDAY über die Woche hinaus", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "2024-01-05 10:00:00", "2023-12-31 12:00:00", "2024-01-15 10:00:00"},
+		{"BYDAY über den Sonntag", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", "2024-01-07 10:00:00", "2023-12-31 12:00:00", "2024-01-21 10:00:00"},
+		{"BYMONTHDAY 31 im Februar", "FREQ=MONTHLY;BYMONTHDAY=31", "2023-01-31 09:00:00", "2023-01-01 12:00:00", "2023-02-28 09:00:00"},
+		{"BYMONTHDAY 31 im Schaltjahr", "FREQ=MONTHLY;BYMONTHDAY=31", "2024-01-31 09:00:00", "2024-01-01 12:00:00", "2024-02-29 09:00:00"},
+		{"BYMONTHDAY 31 nach dem Februar", "FREQ=MONTHLY;BYMONTHDAY=31", "2023-02-28 09:00:00", "2023-01-01 12:00:00", "2023-03-31 09:00:00"},
+		{"letzter Tag im Februar", "FREQ=MONTHLY;BYMONTHDAY=-1", "2023-01-31 09:00:00", "2023-01-01 12:00:00", "2023-02-28 09:00:00"},
+		{"letzter Tag im Schaltjahr", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-31 09:00:00", "2024-01-01 12:00:00", "2024-02-29 09:00:00"},
+		{"letzter Tag nach dem Februar", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-02-29 09:00:00", "2024-01-01 12:00:00", "2024-03-31 09:00:00"},
+		{"Tag der Fälligkeit", "FREQ=MONTHLY", "2024-01-31 09:00:00", "2024-01-01 12:00:00", "2024-02-29 09:00:00"},
+		{"ab Erledigung mit Fälligkeit", "FREQ=DAILY;INTERVAL=2;X-FROM=COMPLETION", "2024-01-10 18:00:00", "2024-01-15 08:00:00", "2024-01-17 18:00:00"},
+		{"ab Erledigung vor der Fälligkeit", "FREQ=WEEKLY;X-FROM=COMPLETION", "2024-01-20 18:00:00", "2024-01-15 08:00:00", "2024-01-22 18:00:00"},
+		{"ab Erledigung ohne Fälligkeit", "FREQ=DAILY;INTERVAL=2;X-FROM=COMPLETION", "", "2024-01-15 08:00:00", "2024-01-17 23:59:59"},
+		{"ohne Fälligkeit", "FREQ=WEEKLY", "", "2024-01-15 08:00:00", "2024-01-22 23:59:59"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: ParseRecurrence(%q): %v", tt.name, tt.rule, err)
+		}
+		var due *time.Time
+		if tt.due != "" {
+			parsed := localTime(t, tt.due)
+			due = &parsed
+		}
+		got := r.Next(due, localTime(t, tt.completed))
+		if want := localTime(t, tt.want); !got.Equal(want) {
+			t.Errorf("%s: Next = %v, erwartet %v", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+// Treffer der Volltextsuche. Fundstellen in Title und Snippet sind mit <mark>...</mark> markiert.
+type SearchResult struct {
+	Todo    ToDo   `json:"todo"`    // gefundene ToDo
+	Title   string `json:"title"`   // Titel mit markierten Fundstellen
+	Snippet string `json:"snippet"` // Ausschnitt der Beschreibung mit markierten Fundstellen
+}
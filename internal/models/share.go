@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Berechtigung des Empfängers einer geteilten ToDo, jede Stufe umfasst die vorherigen
+type SharePermission string
+
+const (
+	PermissionView     SharePermission = "view"     // nur lesen
+	PermissionComplete SharePermission = "complete" // zusätzlich erledigt/offen setzen, auch Punkte der Checkliste
+	PermissionEdit     SharePermission = "edit"     // zusätzlich Titel, Beschreibung, Fälligkeit, Priorität und Checkliste ändern
+)
+
+// Mitglied einer geteilten ToDo
+type TodoMember struct {
+	UserID      int             `json:"user_id"`
+	DisplayName string          `json:"display_name"`
+	Permission  SharePermission `json:"permission"`
+	SharedAt    time.Time       `json:"shared_at"`          // Zeitpunkt, seit dem der Benutzer Zugriff hat
+	GroupID     *int            `json:"group_id,omitempty"` // Zugriff über diese Gruppe statt einer direkten Freigabe
+}
+
+// Offene Einladung zu einer geteilten ToDo
+type Invitation struct {
+	ID         int             `json:"id"`
+	TodoID     int             `json:"todo_id"`
+	TodoTitle  string          `json:"todo_title"`
+	OwnerID    int             `json:"owner_id"` // Eigentümer der ToDo, von ihm stammt die Einladung
+	OwnerName  string          `json:"owner_name"`
+	UserID     int             `json:"user_id"` // eingeladener Benutzer
+	Permission SharePermission `json:"permission"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+var sharePermissions = []SharePermission{PermissionView, PermissionComplete, PermissionEdit}
+
+// Wandelt einen Namen in eine Berechtigung um
+func ParseSharePermission(name string) (SharePermission, error) {
+	for _, p := range sharePermissions {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("ungültige Berechtigung %q, erlaubt sind view, complete, edit", name)
+}
+
+// Prüft ob die Berechtigung die Stufe required umfasst
+func (p SharePermission) Allows(required SharePermission) bool {
+	return p.level() >= required.level()
+}
+
+func (p SharePermission) level() int {
+	for i, known := range sharePermissions {
+		if p == known {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,7 @@
+package models
+
+type Tag struct {
+	ID    int    `json:"id"`    // ID des Tags
+	Name  string `json:"name"`  // Name, je Benutzer eindeutig
+	Count int    `json:"count"` // Anzahl der ToDos mit diesem Tag
+}
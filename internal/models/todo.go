@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -10,9 +12,49 @@ type ToDo struct {
 	Title 		string 		`json:"title"`			// Titel der ToDo
 	Description string 		`json:"description"`	// Beschreibung der ToDo
 	Category	string		`json:"category"`		// Kategorie
-	Order		int			`json:"order"`			// Postion der Todo -> User abhängig
+	ListID		*int		`json:"list_id"`		// Liste des Benutzers, null = ohne Liste, 0 im PATCH entfernt die ToDo aus ihrer Liste
+	Order		int			`json:"order"`			// Postion der Todo -> User und Liste abhängig
 	CreatedAt 	time.Time 	`json:"created_at"`		// Erstellungsdatum
 	UpdatedAt 	time.Time 	`json:"updated_at"`		// Datum der letzten Änderung
 	Completed 	bool 		`json:"completed"`		// Status ob Todo erledigt
-	OriginalID	int  		`json:"original_id"`	// Original ID der ToDo falls es sich um eine Kopie handelt
+	Permission	SharePermission	`json:"permission,omitempty"`	// Berechtigung des abrufenden Benutzers, nur bei mit ihm geteilten ToDos gesetzt
+	DueAt		*DueTime	`json:"due_at,omitempty"`	// Fälligkeit (optional), "" im PATCH entfernt die Fälligkeit
+	Priority	*Priority	`json:"priority,omitempty"`	// Priorität, beim Lesen immer gesetzt, nil im Request = keine Änderung
+	Tags		[]string	`json:"tags"`			// Tags des Benutzers an dieser ToDo
+	AutoComplete *bool		`json:"auto_complete,omitempty"`	// ToDo ist erledigt, sobald alle Punkte der Checkliste erledigt sind, beim Lesen immer gesetzt, nil im Request = keine Änderung
+	Progress	*Progress	`json:"progress,omitempty"`	// Fortschritt der Checkliste, nur bei ToDos mit Checkliste
+	Recurrence	*Recurrence	`json:"recurrence,omitempty"`	// Wiederholung (optional), "" im PATCH entfernt die Wiederholung
+}
+
+// Fälligkeitszeitpunkt einer ToDo. Akzeptiert Datum mit Uhrzeit und Zeitzone (RFC3339, z.B. "2024-01-31T18:00:00+01:00")
+// oder ein reines Datum ("2024-01-31"), das als fällig zum Ende dieses Tages (Serverzeit) gilt.
+type DueTime struct {
+	time.Time
+}
+
+func (d *DueTime) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	// Leerer String -> Nullwert, wird beim PATCH als "Fälligkeit entfernen" behandelt
+	if value == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		d.Time = t
+		return nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return fmt.Errorf("ungültiges Datum %q, erwartet wird \"2006-01-02\" oder RFC3339", value)
+	}
+	d.Time = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, time.Local) // Ende des Tages
+	return nil
 }
\ No newline at end of file
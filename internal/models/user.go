@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type User struct {
+	ID          int       `json:"id"`              // ID des Benutzers
+	DisplayName string    `json:"display_name"`    // Anzeigename
+	Email       string    `json:"email,omitempty"` // E-Mail Adresse, je Benutzer eindeutig, optional
+	CreatedAt   time.Time `json:"created_at"`      // Zeitpunkt der Registrierung
+}
@@ -0,0 +1,2021 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Store-Implementierung im Arbeitsspeicher, z.B. für Tests ohne Datenbankdatei
+type MemoryStore struct {
+	mu         sync.Mutex
+	todos      map[int]models.ToDo           // ToDos nach ID, Kategorie, Liste und Position des Eigentümers
+	lists      map[int]memoryList            // Listen nach ID
+	members    map[int]map[int]memoryMember  // Mitgliedschaften je TodoID und UserID
+	invites    map[int]memoryInvitation      // Offene Einladungen nach ID
+	groups     map[int]memoryGroup           // Gruppen nach ID
+	grpInvites map[int]memoryGroupInvitation // Offene Einladungen in Gruppen nach ID
+	shares     map[int]models.GroupShare     // Freigaben für Gruppen nach ID
+	links      map[int]memoryShareLink       // Öffentliche Links nach ID
+	users      map[int]models.User           // Benutzer nach ID
+	apiKeys    map[int]memoryAPIKey          // API Keys nach ID
+	tokens     map[string]memoryRefreshToken // Refresh Tokens nach Hash
+	identities map[memoryIdentity]int        // UserID je Identität eines OIDC Providers
+	tags       map[int]memoryTag             // Tags nach ID
+	todoTags   map[int]map[int]bool          // TagIDs je TodoID
+	items      map[int]models.ChecklistItem  // Punkte der Checklisten nach ID
+	nextTodoID int
+	nextListID int
+	nextInvID  int
+	nextGrpID  int
+	nextGInvID int
+	nextShrID  int
+	nextLinkID int
+	nextUserID int
+	nextKeyID  int
+	nextTagID  int
+	nextItemID int
+}
+
+type memoryMember struct {
+	Permission models.SharePermission
+	Category   string
+	ListID     int // 0 = ohne Liste
+	Order      int
+	CreatedAt  time.Time
+	GroupID    *int // gesetzt, wenn der Zugriff über eine Gruppe besteht
+}
+
+type memoryList struct {
+	UserID int
+	List   models.List // ohne Count, wird beim Lesen gezählt
+}
+
+type memoryGroup struct {
+	Name      string
+	CreatedAt time.Time
+	Members   map[int]models.GroupMember // Mitglieder je UserID
+}
+
+type memoryInvitation struct {
+	TodoID     int
+	UserID     int
+	Permission models.SharePermission
+	CreatedAt  time.Time
+}
+
+type memoryGroupInvitation struct {
+	GroupID   int
+	UserID    int
+	Role      models.GroupRole
+	CreatedAt time.Time
+}
+
+type memoryShareLink struct {
+	Link   models.ShareLink
+	UserID int
+	Hash   string
+}
+
+type memoryAPIKey struct {
+	Key        models.APIKey
+	Credential Credential
+}
+
+type memoryRefreshToken struct {
+	KeyID     int
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+type memoryIdentity struct {
+	Issuer  string
+	Subject string
+}
+
+type memoryTag struct {
+	UserID int
+	Name   string
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		todos:      map[int]models.ToDo{},
+		lists:      map[int]memoryList{},
+		members:    map[int]map[int]memoryMember{},
+		invites:    map[int]memoryInvitation{},
+		groups:     map[int]memoryGroup{},
+		grpInvites: map[int]memoryGroupInvitation{},
+		shares:     map[int]models.GroupShare{},
+		links:      map[int]memoryShareLink{},
+		users:      map[int]models.User{},
+		apiKeys:    map[int]memoryAPIKey{},
+		tokens:     map[string]memoryRefreshToken{},
+		identities: map[memoryIdentity]int{},
+		tags:       map[int]memoryTag{},
+		todoTags:   map[int]map[int]bool{},
+		items:      map[int]models.ChecklistItem{},
+		nextTodoID: 1,
+		nextListID: 1,
+		nextInvID:  1,
+		nextGrpID:  1,
+		nextGInvID: 1,
+		nextShrID:  1,
+		nextLinkID: 1,
+		nextUserID: 1,
+		nextKeyID:  1,
+		nextTagID:  1,
+		nextItemID: 1,
+	}
+}
+
+// Legt einen Benutzer mit dem angegebenen Secret Key an und gibt seine ID zurück
+func (s *MemoryStore) AddUser(secretKey string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextUserID
+	s.nextUserID++
+	s.users[id] = models.User{ID: id, CreatedAt: time.Now().UTC()}
+	s.addAPIKey(id, models.APIKey{Name: "default", Scopes: []string{auth.ScopeAdmin}}, secretKey, "") // Klartext, wird wie bei älteren Datenbanken beim ersten Zugriff gehasht
+	return id
+}
+
+// Ermittelt die nächste freie Position (order) in einer Liste des Benutzers über eigene ToDos und Mitgliedschaften, Aufrufer hält s.mu
+func (s *MemoryStore) nextOrder(userID, listID int) int {
+	maxOrder := 0
+	for _, todo := range s.todos {
+		if todo.UserID == userID && listValue(todo.ListID) == listID && todo.Order > maxOrder {
+			maxOrder = todo.Order
+		}
+	}
+	for _, members := range s.members {
+		if member, ok := members[userID]; ok && member.ListID == listID && member.Order > maxOrder {
+			maxOrder = member.Order
+		}
+	}
+	return maxOrder + 1
+}
+
+// Verschiebt die Positionen from bis to (einschließlich) in einer Liste des Benutzers um delta, Aufrufer hält s.mu
+func (s *MemoryStore) shiftOrders(userID, listID, from, to, delta int) {
+	for id, todo := range s.todos {
+		if todo.UserID == userID && listValue(todo.ListID) == listID && todo.Order >= from && todo.Order <= to {
+			todo.Order += delta
+			s.todos[id] = todo
+		}
+	}
+	for _, members := range s.members {
+		if member, ok := members[userID]; ok && member.ListID == listID && member.Order >= from && member.Order <= to {
+			member.Order += delta
+			members[userID] = member
+		}
+	}
+}
+
+// Schließt die Lücke, die eine entfernte ToDo an Position order in einer Liste des Benutzers hinterlässt, Aufrufer hält s.mu
+func (s *MemoryStore) closeOrderGap(userID, listID, order int) {
+	s.shiftOrders(userID, listID, order+1, math.MaxInt32, -1)
+}
+
+// ToDo aus Sicht eines Benutzers inkl. seiner Tags, false wenn er weder Eigentümer noch Mitglied ist, Aufrufer hält s.mu
+func (s *MemoryStore) todoFor(todo models.ToDo, userID int) (models.ToDo, bool) {
+	visible := todo.UserID == userID
+	if member, ok := s.members[todo.ID][userID]; ok {
+		todo.Category, todo.ListID, todo.Order, todo.Permission = member.Category, listPtr(member.ListID), member.Order, member.Permission
+		visible = true
+	}
+	todo.Tags = s.tagNames(todo.ID, userID)
+	todo.Progress = s.checklistProgress(todo.ID)
+	return todo, visible
+}
+
+func (s *MemoryStore) GetTodo(id, userID int) (models.ToDo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	todo, ok := s.todos[id]
+	if !ok {
+		return models.ToDo{}, ErrNotFound
+	}
+	todo, _ = s.todoFor(todo, userID)
+	return todo, nil
+}
+
+func (s *MemoryStore) GetTodosByUser(userID int, filter TodoFilter) ([]models.ToDo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	todos := []models.ToDo{}
+	for _, todo := range s.todos {
+		todo, visible := s.todoFor(todo, userID)
+		if visible && matchesFilter(todo, filter) {
+			todos = append(todos, todo)
+		}
+	}
+	// Sortierung und Keyset-Pagination wie in der Datenbank
+	keys := sortKeys(filter.Sort, filter.Descending)
+	sort.Slice(todos, func(i, j int) bool { return compareByKeys(keys, todos[i], todos[j]) < 0 })
+
+	if filter.After != nil {
+		after := models.ToDo{ID: filter.After.ID, ListID: listPtr(filter.After.ListID), Order: filter.After.Order, Priority: &filter.After.Priority,
+			Title: filter.After.Title, CreatedAt: filter.After.CreatedAt, UpdatedAt: filter.After.UpdatedAt}
+		start := sort.Search(len(todos), func(i int) bool { return compareByKeys(keys, todos[i], after) > 0 })
+		todos = todos[start:]
+	}
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+	}
+
+	return todos, nil
+}
+
+func (s *MemoryStore) SearchTodos(userID int, query string, limit int) ([]models.SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []models.SearchResult{}
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	scores := map[int]int{}
+	for _, todo := range s.todos {
+		todo, visible := s.todoFor(todo, userID)
+		if !visible {
+			continue
+		}
+		result, score := searchResult(todo, terms)
+		if score == 0 {
+			continue
+		}
+		scores[todo.ID] = score
+		results = append(results, result)
+	}
+
+	// Beste Treffer zuerst, bei Gleichstand nach ID
+	return rankResults(results, scores, limit), nil
+}
+
+// Prüft ob t im Zeitraum [from, until) liegt, nicht gesetzte Grenzen schränken nicht ein
+func inRange(t time.Time, from, until *time.Time) bool {
+	return (from == nil || !t.Before(*from)) && (until == nil || t.Before(*until))
+}
+
+// Prüft ob eine ToDo alle Bedingungen des Filters erfüllt
+func matchesFilter(todo models.ToDo, filter TodoFilter) bool {
+	if filter.Completed != nil && todo.Completed != *filter.Completed {
+		return false
+	}
+	if filter.Category != "" && todo.Category != filter.Category {
+		return false
+	}
+	if filter.ListID != nil && listValue(todo.ListID) != *filter.ListID {
+		return false
+	}
+	if filter.Shared != nil && (todo.Permission != "") != *filter.Shared {
+		return false
+	}
+	if !inRange(todo.CreatedAt, filter.CreatedFrom, filter.CreatedUntil) || !inRange(todo.UpdatedAt, filter.UpdatedFrom, filter.UpdatedUntil) {
+		return false
+	}
+	if filter.DueFrom != nil || filter.DueUntil != nil {
+		if todo.DueAt == nil {
+			return false // ohne Fälligkeit nie in einem Zeitraum
+		}
+		if filter.DueFrom != nil && todo.DueAt.Before(*filter.DueFrom) {
+			return false
+		}
+		if filter.DueUntil != nil && !todo.DueAt.Before(*filter.DueUntil) {
+			return false
+		}
+	}
+	if len(filter.Tags) > 0 {
+		found := 0
+		for _, wanted := range filter.Tags {
+			for _, tag := range todo.Tags {
+				if tag == wanted {
+					found++
+					break
+				}
+			}
+		}
+		if found == 0 || (filter.TagMode != TagModeAny && found < len(filter.Tags)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MemoryStore) CreateTodo(todo *models.ToDo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	todo.ID = s.nextTodoID
+	s.nextTodoID++
+	todo.ListID = listPtr(listValue(todo.ListID))
+	todo.Order = s.nextOrder(todo.UserID, listValue(todo.ListID))
+	if todo.Priority == nil {
+		none := models.PriorityNone
+		todo.Priority = &none
+	}
+	autoComplete := todo.AutoComplete != nil && *todo.AutoComplete
+	todo.AutoComplete = &autoComplete
+	now := time.Now()
+	todo.CreatedAt, todo.UpdatedAt = now, now
+
+	s.todos[todo.ID] = *todo
+
+	// Ist ihre Liste (list_id) mit einer Gruppe geteilt, erhalten deren Mitglieder Zugriff
+	s.syncGroupAccess(todo.ID)
+	return nil
+}
+
+func (s *MemoryStore) UpdateTodo(id, userID int, changes models.ToDo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	todo, ok := s.todos[id]
+	if !ok {
+		return ErrNotFound
+	}
+	current, _ := s.todoFor(todo, userID)
+	currentList, newList := listValue(current.ListID), listValue(current.ListID)
+	if changes.ListID != nil {
+		newList = *changes.ListID
+	}
+	if changes.Title == "" && changes.Description == "" && changes.Category == "" && newList == currentList && changes.Order == 0 && changes.DueAt == nil && changes.Priority == nil &&
+		changes.AutoComplete == nil && changes.Recurrence == nil {
+		return ErrNoChanges
+	}
+
+	// Wechselt die ToDo die Liste, schließt sich die Lücke in der alten Liste und sie rückt ans Ende bzw. an die angegebene Position der neuen
+	if newList != currentList {
+		next := s.nextOrder(userID, newList)
+		if changes.Order == 0 {
+			changes.Order = next
+		}
+		if changes.Order < 1 || changes.Order > next {
+			return ErrOrderOutOfRange
+		}
+
+		s.closeOrderGap(userID, currentList, current.Order)
+		s.shiftOrders(userID, newList, changes.Order, math.MaxInt32, 1)
+	} else if changes.Order != 0 {
+		// Wenn Position sich verändert, dann die anderen ToDos der Liste verschieben
+		if changes.Order < 1 || changes.Order >= s.nextOrder(userID, currentList) {
+			return ErrOrderOutOfRange
+		}
+		if changes.Order == current.Order {
+			return ErrOrderUnchanged
+		}
+
+		if changes.Order > current.Order {
+			s.shiftOrders(userID, currentList, current.Order+1, changes.Order, -1)
+		} else {
+			s.shiftOrders(userID, currentList, changes.Order, current.Order-1, 1)
+		}
+	}
+
+	// Der Inhalt gilt für alle Mitglieder, Kategorie, Liste und Position nur für den Benutzer
+	now := time.Now()
+	todo = s.todos[id]
+	if todo.UserID == userID {
+		if changes.Category != "" {
+			todo.Category = changes.Category
+		}
+		todo.ListID = listPtr(newList)
+		if changes.Order != 0 {
+			todo.Order = changes.Order
+		}
+		if changes.Category != "" || newList != currentList || changes.Order != 0 {
+			todo.UpdatedAt = now
+		}
+	} else if member, ok := s.members[id][userID]; ok {
+		if changes.Category != "" {
+			member.Category = changes.Category
+		}
+		member.ListID = newList
+		if changes.Order != 0 {
+			member.Order = changes.Order
+		}
+		s.members[id][userID] = member
+	}
+
+	if changes.Title != "" || changes.Description != "" || changes.DueAt != nil || changes.Priority != nil || changes.AutoComplete != nil || changes.Recurrence != nil {
+		if changes.Title != "" {
+			todo.Title = changes.Title
+		}
+		if changes.Description != "" {
+			todo.Description = changes.Description
+		}
+		if changes.DueAt != nil {
+			todo.DueAt = changes.DueAt
+			if changes.DueAt.IsZero() {
+				todo.DueAt = nil // Nullwert entfernt die Fälligkeit
+			}
+		}
+		if changes.Priority != nil {
+			priority := *changes.Priority
+			todo.Priority = &priority
+		}
+		if changes.AutoComplete != nil {
+			autoComplete := *changes.AutoComplete
+			todo.AutoComplete = &autoComplete
+		}
+		if changes.Recurrence != nil {
+			todo.Recurrence = changes.Recurrence
+			if changes.Recurrence.IsZero() {
+				todo.Recurrence = nil // leere Wiederholung entfernt sie
+			}
+		}
+		todo.UpdatedAt = now
+	}
+
+	s.todos[id] = todo
+
+	// Mit auto_complete übernimmt die ToDo sofort den Stand ihrer Checkliste
+	if changes.AutoComplete != nil {
+		s.syncChecklistStatus(id)
+	}
+
+	// Verschiebt der Eigentümer die ToDo in eine andere Liste, ändern sich die Freigaben für Gruppen
+	if todo.UserID == userID && newList != currentList {
+		s.syncGroupAccess(id)
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTodo(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.todos[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// Positionen (order) der nachfolgenden ToDos beim Eigentümer und bei allen Mitgliedern in ihrer jeweiligen Liste anpassen
+	s.closeOrderGap(current.UserID, listValue(current.ListID), current.Order)
+	for userID, member := range s.members[id] {
+		s.closeOrderGap(userID, member.ListID, member.Order)
+	}
+
+	delete(s.todos, id)
+	delete(s.members, id)
+	delete(s.todoTags, id)
+	s.deleteChecklist(id)
+	for invitationID, invitation := range s.invites {
+		if invitation.TodoID == id {
+			delete(s.invites, invitationID)
+		}
+	}
+	for linkID, link := range s.links {
+		if link.Link.TodoID != nil && *link.Link.TodoID == id {
+			delete(s.links, linkID)
+		}
+	}
+	for shareID, share := range s.shares {
+		if share.TodoID != nil && *share.TodoID == id {
+			delete(s.shares, shareID)
+		}
+	}
+	return nil
+}
+
+// Nimmt den Benutzer am Ende seiner ToDos ohne Liste in der Kategorie "shared" als Mitglied auf, Aufrufer hält s.mu.
+// groupID ist gesetzt, wenn der Zugriff über eine Gruppe besteht.
+func (s *MemoryStore) addMember(todoID, userID int, permission models.SharePermission, groupID *int) {
+	if s.members[todoID] == nil {
+		s.members[todoID] = map[int]memoryMember{}
+	}
+	s.members[todoID][userID] = memoryMember{Permission: permission, Category: "shared", Order: s.nextOrder(userID, 0), CreatedAt: time.Now().UTC(), GroupID: groupID}
+}
+
+// Entfernt die Mitgliedschaft und die Tags des Benutzers an der ToDo und schließt die Lücke in der Reihenfolge seiner Liste, Aufrufer hält s.mu
+func (s *MemoryStore) removeMember(todoID, userID int) {
+	member := s.members[todoID][userID]
+	delete(s.members[todoID], userID)
+	for tagID := range s.todoTags[todoID] {
+		if s.tags[tagID].UserID == userID {
+			delete(s.todoTags[todoID], tagID)
+		}
+	}
+	s.closeOrderGap(userID, member.ListID, member.Order)
+}
+
+func (s *MemoryStore) SetMemberPermission(todoID, userID int, permission models.SharePermission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[todoID][userID]
+	if !ok {
+		return ErrNotFound
+	}
+	if member.GroupID != nil {
+		return ErrGroupAccess // die Berechtigung ergibt sich aus der Freigabe für die Gruppe
+	}
+	member.Permission = permission
+	s.members[todoID][userID] = member
+	return nil
+}
+
+func (s *MemoryStore) RemoveMember(todoID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[todoID][userID]
+	if !ok {
+		return ErrNotFound
+	}
+	if member.GroupID != nil {
+		return ErrGroupAccess
+	}
+
+	// Mitgliedschaft beenden, besteht zusätzlich Zugriff über eine Gruppe, wird der Benutzer darüber wieder aufgenommen
+	s.removeMember(todoID, userID)
+	s.syncGroupAccess(todoID)
+	return nil
+}
+
+func (s *MemoryStore) GetTodoMembers(todoID int) ([]models.TodoMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := []models.TodoMember{}
+	for userID, member := range s.members[todoID] {
+		members = append(members, models.TodoMember{
+			UserID:      userID,
+			DisplayName: s.users[userID].DisplayName,
+			Permission:  member.Permission,
+			SharedAt:    member.CreatedAt,
+			GroupID:     member.GroupID,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].SharedAt.Equal(members[j].SharedAt) {
+			return members[i].SharedAt.Before(members[j].SharedAt)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members, nil
+}
+
+func (s *MemoryStore) SetTodoStatus(id int, completed bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.todos[id]; !ok {
+		return 0, ErrNotFound
+	}
+	return s.setTodoStatus(id, completed), nil
+}
+
+// Setzt den Status einer ToDo für alle Mitglieder. Wird ein wiederkehrender Termin erledigt, folgt der nächste, dessen ID
+// zurückgegeben wird, Aufrufer hält s.mu
+func (s *MemoryStore) setTodoStatus(id int, completed bool) int {
+	todo := s.todos[id]
+	if completed == todo.Completed {
+		return 0
+	}
+	todo.Completed = completed
+	todo.UpdatedAt = time.Now()
+	s.todos[id] = todo
+
+	if completed && todo.Recurrence != nil {
+		return s.createNextOccurrence(id)
+	}
+	return 0
+}
+
+// Legt den nächsten Termin einer wiederkehrenden ToDo an, die gerade erledigt wurde, und liefert dessen ID.
+// Übernimmt Inhalt, Tags, Checkliste (offen), Mitglieder und Freigaben für Gruppen wie SQLStore, Aufrufer hält s.mu
+func (s *MemoryStore) createNextOccurrence(id int) int {
+	current := s.todos[id]
+	now := time.Now()
+	var due *time.Time
+	if current.DueAt != nil {
+		due = &current.DueAt.Time
+	}
+
+	next := current
+	next.ID = s.nextTodoID
+	s.nextTodoID++
+	next.Completed = false
+	next.DueAt = &models.DueTime{Time: current.Recurrence.Next(due, now)}
+	next.Order = s.nextOrder(next.UserID, listValue(next.ListID))
+	next.CreatedAt, next.UpdatedAt = now, now
+	s.todos[next.ID] = next
+
+	current.Recurrence = nil
+	s.todos[id] = current
+
+	for tagID := range s.todoTags[id] {
+		if s.todoTags[next.ID] == nil {
+			s.todoTags[next.ID] = map[int]bool{}
+		}
+		s.todoTags[next.ID][tagID] = true
+	}
+	for _, item := range s.checklist(id) {
+		item.ID = s.nextItemID
+		s.nextItemID++
+		item.TodoID, item.Completed, item.CreatedAt = next.ID, false, now
+		s.items[item.ID] = item
+	}
+	shareIDs := []int{}
+	for shareID, share := range s.shares {
+		if share.TodoID != nil && *share.TodoID == id {
+			shareIDs = append(shareIDs, shareID)
+		}
+	}
+	sort.Ints(shareIDs)
+	for _, shareID := range shareIDs {
+		share := s.shares[shareID]
+		share.ID = s.nextShrID
+		s.nextShrID++
+		share.TodoID = &next.ID
+		s.shares[share.ID] = share
+	}
+
+	// Mitglieder behalten Berechtigung, Kategorie und Liste
+	userIDs := []int{}
+	for userID := range s.members[id] {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Ints(userIDs)
+	for _, userID := range userIDs {
+		member := s.members[id][userID]
+		member.Order = s.nextOrder(userID, member.ListID)
+		if s.members[next.ID] == nil {
+			s.members[next.ID] = map[int]memoryMember{}
+		}
+		s.members[next.ID][userID] = member
+	}
+
+	// Mitgliedschaften über Gruppen mit den übernommenen Freigaben abgleichen
+	s.syncGroupAccess(next.ID)
+	return next.ID
+}
+
+func (s *MemoryStore) UserExists(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.users[id]
+	return ok, nil
+}
+
+// Prüft ob die E-Mail bereits von einem anderen Benutzer verwendet wird, Aufrufer hält s.mu
+func (s *MemoryStore) emailTaken(email string, exceptUserID int) bool {
+	for id, user := range s.users {
+		if id != exceptUserID && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// Legt den Benutzer an und setzt ID und CreatedAt, Aufrufer hält s.mu
+func (s *MemoryStore) insertUser(user *models.User) error {
+	if user.Email != "" && s.emailTaken(user.Email, 0) {
+		return ErrEmailExists
+	}
+
+	user.ID = s.nextUserID
+	s.nextUserID++
+	user.CreatedAt = time.Now().UTC()
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *MemoryStore) CreateUser(user *models.User, hash, lookup string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.insertUser(user); err != nil {
+		return err
+	}
+	s.addAPIKey(user.ID, models.APIKey{Name: "default", Scopes: []string{auth.ScopeAdmin}, CreatedAt: user.CreatedAt}, hash, lookup)
+	return nil
+}
+
+func (s *MemoryStore) UserForIdentity(issuer, subject string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userID, ok := s.identities[memoryIdentity{issuer, subject}]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return userID, nil
+}
+
+func (s *MemoryStore) CreateUserWithIdentity(user *models.User, issuer, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identity := memoryIdentity{issuer, subject}
+	if _, ok := s.identities[identity]; ok {
+		return errors.New("identität bereits vorhanden")
+	}
+	if err := s.insertUser(user); err != nil {
+		return err
+	}
+	s.identities[identity] = user.ID
+	return nil
+}
+
+func (s *MemoryStore) GetUser(id int) (models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) UpdateUser(id int, changes models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if changes.DisplayName == "" && changes.Email == "" {
+		return ErrNoChanges
+	}
+	if changes.DisplayName != "" {
+		user.DisplayName = changes.DisplayName
+	}
+	if changes.Email != "" {
+		if s.emailTaken(changes.Email, id) {
+			return ErrEmailExists
+		}
+		user.Email = changes.Email
+	}
+	s.users[id] = user
+	return nil
+}
+
+func (s *MemoryStore) DeleteUser(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return ErrNotFound
+	}
+
+	// Mitgliedschaften, Einladungen (auch in Gruppen) und öffentliche Links des Benutzers beenden, auch die von ihm versendeten Einladungen
+	for _, members := range s.members {
+		delete(members, id)
+	}
+	for invitationID, invitation := range s.invites {
+		if invitation.UserID == id || s.todos[invitation.TodoID].UserID == id {
+			delete(s.invites, invitationID)
+		}
+	}
+	for invitationID, invitation := range s.grpInvites {
+		if invitation.UserID == id {
+			delete(s.grpInvites, invitationID)
+		}
+	}
+	for linkID, link := range s.links {
+		if link.UserID == id {
+			delete(s.links, linkID)
+		}
+	}
+
+	// Freigaben des Benutzers für Gruppen enden, deren Mitglieder verlieren den Zugriff auf seine ToDos
+	for todoID, todo := range s.todos {
+		if todo.UserID != id {
+			continue
+		}
+		for userID, member := range s.members[todoID] {
+			if member.GroupID != nil {
+				s.removeMember(todoID, userID)
+			}
+		}
+	}
+	for shareID, share := range s.shares {
+		if share.OwnerID == id {
+			delete(s.shares, shareID)
+		}
+	}
+
+	// Gruppen des Benutzers gehen an den ältesten Admin bzw. das älteste Mitglied über, Gruppen ohne weitere Mitglieder werden gelöscht
+	for groupID, group := range s.groups {
+		member, ok := group.Members[id]
+		if !ok {
+			continue
+		}
+		delete(group.Members, id)
+		if len(group.Members) == 0 {
+			delete(s.groups, groupID)
+			for shareID, share := range s.shares {
+				if share.GroupID == groupID {
+					delete(s.shares, shareID)
+				}
+			}
+			for invitationID, invitation := range s.grpInvites {
+				if invitation.GroupID == groupID {
+					delete(s.grpInvites, invitationID)
+				}
+			}
+			continue
+		}
+		if member.Role == models.GroupRoleOwner {
+			members := sortedGroupMembers(group) // Admins vor Mitgliedern, jeweils ältestes zuerst
+			heir := members[0]
+			heir.Role = models.GroupRoleOwner
+			group.Members[heir.UserID] = heir
+		}
+	}
+
+	// Geteilte ToDos des Benutzers gehen an das älteste (direkte) Mitglied über, mit dessen Kategorie, Liste und Position
+	for todoID, todo := range s.todos {
+		if todo.UserID != id || len(s.members[todoID]) == 0 {
+			continue
+		}
+		heir := 0
+		for userID, member := range s.members[todoID] {
+			oldest := s.members[todoID][heir]
+			if heir == 0 || member.CreatedAt.Before(oldest.CreatedAt) || (member.CreatedAt.Equal(oldest.CreatedAt) && userID < heir) {
+				heir = userID
+			}
+		}
+		member := s.members[todoID][heir]
+		todo.UserID, todo.Category, todo.ListID, todo.Order = heir, member.Category, listPtr(member.ListID), member.Order
+		s.todos[todoID] = todo
+		delete(s.members[todoID], heir)
+	}
+
+	// Eigene ToDos samt Checklisten, Listen, Tags, Identitäten, API Keys und Refresh Tokens löschen
+	for todoID, todo := range s.todos {
+		if todo.UserID == id {
+			delete(s.todos, todoID)
+			delete(s.members, todoID)
+			delete(s.todoTags, todoID)
+			s.deleteChecklist(todoID)
+		}
+	}
+	for listID, list := range s.lists {
+		if list.UserID == id {
+			delete(s.lists, listID)
+		}
+	}
+	for tagID, tag := range s.tags {
+		if tag.UserID == id {
+			delete(s.tags, tagID)
+			for _, tagIDs := range s.todoTags {
+				delete(tagIDs, tagID)
+			}
+		}
+	}
+	for identity, userID := range s.identities {
+		if userID == id {
+			delete(s.identities, identity)
+		}
+	}
+	for hash, token := range s.tokens {
+		if s.apiKeys[token.KeyID].Credential.UserID == id {
+			delete(s.tokens, hash)
+		}
+	}
+	for keyID, key := range s.apiKeys {
+		if key.Credential.UserID == id {
+			delete(s.apiKeys, keyID)
+		}
+	}
+
+	delete(s.users, id)
+	return nil
+}
+
+// Legt einen API Key an und gibt seine ID zurück, Aufrufer hält s.mu
+func (s *MemoryStore) addAPIKey(userID int, key models.APIKey, hash, lookup string) int {
+	key.ID = s.nextKeyID
+	s.nextKeyID++
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+	s.apiKeys[key.ID] = memoryAPIKey{
+		Key:        key,
+		Credential: Credential{KeyID: key.ID, UserID: userID, Hash: hash, Lookup: lookup, Scopes: key.Scopes},
+	}
+	return key.ID
+}
+
+// Prüft ob ein Key weder widerrufen noch abgelaufen ist
+func (k memoryAPIKey) active(now time.Time) bool {
+	return k.Key.RevokedAt == nil && (k.Key.ExpiresAt == nil || k.Key.ExpiresAt.After(now))
+}
+
+func (s *MemoryStore) FindCredentials(lookup, secretKey string) ([]Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	credentials := []Credential{}
+	for _, key := range s.apiKeys {
+		c := key.Credential
+		if !key.active(now) {
+			continue
+		}
+		if c.Lookup == lookup || (c.Lookup == "" && (c.Hash == secretKey || strings.HasPrefix(c.Hash, "$2"))) {
+			credentials = append(credentials, c)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].KeyID < credentials[j].KeyID })
+	return credentials, nil
+}
+
+func (s *MemoryStore) UpdateAPIKeyHash(keyID int, hash, lookup string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return ErrNotFound
+	}
+	key.Credential.Hash = hash
+	key.Credential.Lookup = lookup
+	s.apiKeys[keyID] = key
+	return nil
+}
+
+func (s *MemoryStore) MarkAPIKeyUsed(keyID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	key.Key.LastUsedAt = &now
+	s.apiKeys[keyID] = key
+	return nil
+}
+
+func (s *MemoryStore) APIKeyActive(userID, keyID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	return ok && key.Credential.UserID == userID && key.active(time.Now()), nil
+}
+
+func (s *MemoryStore) CreateAPIKey(userID int, key *models.APIKey, hash, lookup string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key.CreatedAt = time.Now().UTC()
+	key.ID = s.addAPIKey(userID, *key, hash, lookup)
+	return nil
+}
+
+func (s *MemoryStore) GetAPIKeysByUser(userID int) ([]models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []models.APIKey{}
+	for _, key := range s.apiKeys {
+		if key.Credential.UserID == userID {
+			keys = append(keys, key.Key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (s *MemoryStore) RevokeAPIKey(userID, keyID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok || key.Credential.UserID != userID || key.Key.RevokedAt != nil {
+		return ErrNotFound
+	}
+
+	// Mindestens ein weiterer gültiger Key mit Scope admin muss bleiben, sonst kann der Benutzer seine Keys nicht mehr verwalten.
+	// Benutzer mit einer Identität des OIDC Providers melden sich dort mit allen Berechtigungen an und brauchen keinen Key.
+	now := time.Now().UTC()
+	others := 0
+	for id, other := range s.apiKeys {
+		if id != keyID && other.Credential.UserID == userID && other.active(now) && auth.HasScope(other.Key.Scopes, auth.ScopeAdmin) {
+			others++
+		}
+	}
+	for _, identityUserID := range s.identities {
+		if identityUserID == userID {
+			others++
+		}
+	}
+	if others == 0 {
+		return ErrLastAPIKey
+	}
+
+	key.Key.RevokedAt = &now
+	s.apiKeys[keyID] = key
+	return nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(keyID int, hash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[hash] = memoryRefreshToken{KeyID: keyID, ExpiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) UseRefreshToken(hash string) (Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Nur gültige Tokens eines aktiven API Keys, ein widerrufener Key beendet auch seine Refresh Tokens
+	now := time.Now()
+	token, ok := s.tokens[hash]
+	if !ok || token.Revoked || !token.ExpiresAt.After(now) {
+		return Credential{}, ErrNotFound
+	}
+	key, ok := s.apiKeys[token.KeyID]
+	if !ok || !key.active(now) {
+		return Credential{}, ErrNotFound
+	}
+
+	// Ein bereits getauschter Token wurde vermutlich entwendet, daher alle Refresh Tokens des Keys widerrufen
+	if token.Used {
+		for otherHash, other := range s.tokens {
+			if other.KeyID == token.KeyID {
+				other.Revoked = true
+				s.tokens[otherHash] = other
+			}
+		}
+		return Credential{}, ErrTokenReused
+	}
+
+	token.Used = true
+	s.tokens[hash] = token
+	return key.Credential, nil
+}
+
+// Namen der Tags eines Benutzers an einer ToDo, alphabetisch sortiert, Aufrufer hält s.mu
+func (s *MemoryStore) tagNames(todoID, userID int) []string {
+	names := []string{}
+	for tagID := range s.todoTags[todoID] {
+		if s.tags[tagID].UserID == userID {
+			names = append(names, s.tags[tagID].Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sucht einen Tag eines Benutzers nach Namen, Aufrufer hält s.mu
+func (s *MemoryStore) findTag(userID int, name string) (int, bool) {
+	for id, tag := range s.tags {
+		if tag.UserID == userID && tag.Name == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (s *MemoryStore) AddTags(todoID, userID int, names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.todos[todoID]; !ok {
+		return ErrNotFound
+	}
+
+	for _, name := range names {
+		tagID, ok := s.findTag(userID, name)
+		if !ok {
+			tagID = s.nextTagID
+			s.nextTagID++
+			s.tags[tagID] = memoryTag{UserID: userID, Name: name}
+		}
+		if s.todoTags[todoID] == nil {
+			s.todoTags[todoID] = map[int]bool{}
+		}
+		s.todoTags[todoID][tagID] = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) RemoveTag(todoID, userID int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tagID, ok := s.findTag(userID, name)
+	if !ok || !s.todoTags[todoID][tagID] {
+		return ErrNotFound
+	}
+
+	delete(s.todoTags[todoID], tagID)
+	return nil
+}
+
+func (s *MemoryStore) GetTagsByUser(userID int) ([]models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tags := []models.Tag{}
+	for id, tag := range s.tags {
+		if tag.UserID != userID {
+			continue
+		}
+		count := 0
+		for _, tagIDs := range s.todoTags {
+			if tagIDs[id] {
+				count++
+			}
+		}
+		tags = append(tags, models.Tag{ID: id, Name: tag.Name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	return tags, nil
+}
+
+func (s *MemoryStore) RenameTag(userID int, oldName, newName string) (bool, error) {
+	if oldName == newName {
+		return false, ErrNoChanges
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldID, ok := s.findTag(userID, oldName)
+	if !ok {
+		return false, ErrNotFound
+	}
+
+	newID, exists := s.findTag(userID, newName)
+	if !exists {
+		// Zielname noch frei -> einfach umbenennen
+		s.tags[oldID] = memoryTag{UserID: userID, Name: newName}
+		return false, nil
+	}
+
+	// Zielname existiert -> alle Zuordnungen übernehmen und den alten Tag löschen
+	for _, tagIDs := range s.todoTags {
+		if tagIDs[oldID] {
+			delete(tagIDs, oldID)
+			tagIDs[newID] = true
+		}
+	}
+	delete(s.tags, oldID)
+	return true, nil
+}
+
+// Liste mit Anzahl ihrer ToDos, Aufrufer hält s.mu
+func (s *MemoryStore) listWithCount(id int) models.List {
+	list := s.lists[id]
+	list.List.Count = 0
+	for _, todo := range s.todos {
+		if todo.UserID == list.UserID && listValue(todo.ListID) == id {
+			list.List.Count++
+		}
+	}
+	for _, members := range s.members {
+		if member, ok := members[list.UserID]; ok && member.ListID == id {
+			list.List.Count++
+		}
+	}
+	return list.List
+}
+
+func (s *MemoryStore) CreateList(userID int, list *models.List) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list.ID = s.nextListID
+	s.nextListID++
+	list.CreatedAt = time.Now().UTC()
+	list.Count = 0
+	s.lists[list.ID] = memoryList{UserID: userID, List: *list}
+	return nil
+}
+
+func (s *MemoryStore) GetList(id, userID int) (models.List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, ok := s.lists[id]
+	if !ok || list.UserID != userID {
+		return models.List{}, ErrNotFound
+	}
+	return s.listWithCount(id), nil
+}
+
+func (s *MemoryStore) GetListsByUser(userID int) ([]models.List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists := []models.List{}
+	for id, list := range s.lists {
+		if list.UserID == userID {
+			lists = append(lists, s.listWithCount(id))
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if !lists[i].CreatedAt.Equal(lists[j].CreatedAt) {
+			return lists[i].CreatedAt.Before(lists[j].CreatedAt)
+		}
+		return lists[i].ID < lists[j].ID
+	})
+	return lists, nil
+}
+
+func (s *MemoryStore) RenameList(id, userID int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, ok := s.lists[id]
+	if !ok || list.UserID != userID {
+		return ErrNotFound
+	}
+	list.List.Name = name
+	s.lists[id] = list
+	return nil
+}
+
+func (s *MemoryStore) DeleteList(id, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, ok := s.lists[id]
+	if !ok || list.UserID != userID {
+		return ErrNotFound
+	}
+
+	// Die ToDos der Liste behalten ihre Reihenfolge und werden hinter den ToDos ohne Liste angehängt
+	offset := s.nextOrder(userID, 0) - 1
+	for todoID, todo := range s.todos {
+		if todo.UserID == userID && listValue(todo.ListID) == id {
+			todo.ListID, todo.Order = nil, todo.Order+offset
+			s.todos[todoID] = todo
+		}
+	}
+	for _, members := range s.members {
+		if member, ok := members[userID]; ok && member.ListID == id {
+			member.ListID, member.Order = 0, member.Order+offset
+			members[userID] = member
+		}
+	}
+	delete(s.lists, id)
+
+	// Freigaben der Liste für Gruppen enden, deren Mitglieder verlieren den Zugriff über die Liste
+	for shareID, share := range s.shares {
+		if share.ListID != nil && *share.ListID == id {
+			delete(s.shares, shareID)
+			s.syncGroup(share.GroupID)
+		}
+	}
+	return nil
+}
+
+// Punkte der Checkliste einer ToDo in ihrer Reihenfolge, Aufrufer hält s.mu
+func (s *MemoryStore) checklist(todoID int) []models.ChecklistItem {
+	items := []models.ChecklistItem{}
+	for _, item := range s.items {
+		if item.TodoID == todoID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Order < items[j].Order })
+	return items
+}
+
+// Fortschritt der Checkliste, nil für ToDos ohne Punkte, Aufrufer hält s.mu
+func (s *MemoryStore) checklistProgress(todoID int) *models.Progress {
+	progress := models.Progress{}
+	for _, item := range s.items {
+		if item.TodoID == todoID {
+			progress.Total++
+			if item.Completed {
+				progress.Done++
+			}
+		}
+	}
+	if progress.Total == 0 {
+		return nil
+	}
+	return &progress
+}
+
+// Setzt den Status einer ToDo mit auto_complete auf erledigt, wenn alle Punkte ihrer Checkliste erledigt sind, sonst auf offen.
+// ToDos ohne auto_complete oder ohne Punkte bleiben unverändert, ein erledigter wiederkehrender Termin legt den nächsten an.
+// Aufrufer hält s.mu
+func (s *MemoryStore) syncChecklistStatus(todoID int) {
+	todo, ok := s.todos[todoID]
+	if !ok || todo.AutoComplete == nil || !*todo.AutoComplete {
+		return
+	}
+	progress := s.checklistProgress(todoID)
+	if progress == nil {
+		return
+	}
+	s.setTodoStatus(todoID, progress.Done == progress.Total)
+}
+
+// Löscht alle Punkte der Checkliste einer ToDo, Aufrufer hält s.mu
+func (s *MemoryStore) deleteChecklist(todoID int) {
+	for itemID, item := range s.items {
+		if item.TodoID == todoID {
+			delete(s.items, itemID)
+		}
+	}
+}
+
+// Verschiebt die Positionen from bis to (einschließlich) in der Checkliste einer ToDo um delta, Aufrufer hält s.mu
+func (s *MemoryStore) shiftChecklistOrders(todoID, from, to, delta int) {
+	for itemID, item := range s.items {
+		if item.TodoID == todoID && item.Order >= from && item.Order <= to {
+			item.Order += delta
+			s.items[itemID] = item
+		}
+	}
+}
+
+func (s *MemoryStore) GetChecklist(todoID int) ([]models.ChecklistItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checklist(todoID), nil
+}
+
+func (s *MemoryStore) AddChecklistItem(item *models.ChecklistItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.ID = s.nextItemID
+	s.nextItemID++
+	item.Order = len(s.checklist(item.TodoID)) + 1
+	item.Completed = false
+	item.CreatedAt = time.Now()
+	s.items[item.ID] = *item
+
+	// Ein neuer offener Punkt öffnet eine automatisch erledigte ToDo wieder
+	s.syncChecklistStatus(item.TodoID)
+	return nil
+}
+
+func (s *MemoryStore) UpdateChecklistItem(todoID, itemID int, changes models.ChecklistItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.items[itemID]
+	if !ok || current.TodoID != todoID {
+		return ErrNotFound
+	}
+	if changes.Title == "" && changes.Order == 0 {
+		return ErrNoChanges
+	}
+
+	// Bei einer neuen Position rücken die Punkte dazwischen um eine Stelle
+	if changes.Order != 0 {
+		if changes.Order < 1 || changes.Order > len(s.checklist(todoID)) {
+			return ErrOrderOutOfRange
+		}
+		if changes.Order == current.Order {
+			return ErrOrderUnchanged
+		}
+
+		if changes.Order > current.Order {
+			s.shiftChecklistOrders(todoID, current.Order+1, changes.Order, -1)
+		} else {
+			s.shiftChecklistOrders(todoID, changes.Order, current.Order-1, 1)
+		}
+		current.Order = changes.Order
+	}
+	if changes.Title != "" {
+		current.Title = changes.Title
+	}
+	s.items[itemID] = current
+	return nil
+}
+
+func (s *MemoryStore) SetChecklistItemStatus(todoID, itemID int, completed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemID]
+	if !ok || item.TodoID != todoID {
+		return ErrNotFound
+	}
+	item.Completed = completed
+	s.items[itemID] = item
+
+	s.syncChecklistStatus(todoID)
+	return nil
+}
+
+func (s *MemoryStore) DeleteChecklistItem(todoID, itemID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.items[itemID]
+	if !ok || current.TodoID != todoID {
+		return ErrNotFound
+	}
+	delete(s.items, itemID)
+	s.shiftChecklistOrders(todoID, current.Order+1, math.MaxInt32, -1)
+
+	// Fehlt nur noch der gelöschte offene Punkt, gilt die ToDo als erledigt
+	s.syncChecklistStatus(todoID)
+	return nil
+}
+
+// Einladung mit Titel und Eigentümer der ToDo, Aufrufer hält s.mu
+func (s *MemoryStore) invitation(id int, invitation memoryInvitation) models.Invitation {
+	todo := s.todos[invitation.TodoID]
+	return models.Invitation{
+		ID:         id,
+		TodoID:     invitation.TodoID,
+		TodoTitle:  todo.Title,
+		OwnerID:    todo.UserID,
+		OwnerName:  s.users[todo.UserID].DisplayName,
+		UserID:     invitation.UserID,
+		Permission: invitation.Permission,
+		CreatedAt:  invitation.CreatedAt,
+	}
+}
+
+// Offene Einladungen, die match erfüllen, älteste zuerst, Aufrufer hält s.mu
+func (s *MemoryStore) findInvitations(match func(memoryInvitation) bool) []models.Invitation {
+	invitations := []models.Invitation{}
+	for id, invitation := range s.invites {
+		if match(invitation) {
+			invitations = append(invitations, s.invitation(id, invitation))
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].CreatedAt.Equal(invitations[j].CreatedAt) {
+			return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+		}
+		return invitations[i].ID < invitations[j].ID
+	})
+	return invitations
+}
+
+func (s *MemoryStore) CreateInvitation(invitation *models.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[invitation.TodoID][invitation.UserID]; ok {
+		return ErrAlreadyMember
+	}
+	for _, other := range s.invites {
+		if other.TodoID == invitation.TodoID && other.UserID == invitation.UserID {
+			return ErrInvitationOpen
+		}
+	}
+
+	invitation.ID = s.nextInvID
+	s.nextInvID++
+	invitation.CreatedAt = time.Now().UTC()
+	s.invites[invitation.ID] = memoryInvitation{TodoID: invitation.TodoID, UserID: invitation.UserID, Permission: invitation.Permission, CreatedAt: invitation.CreatedAt}
+	return nil
+}
+
+func (s *MemoryStore) GetInvitation(id int) (models.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation, ok := s.invites[id]
+	if !ok {
+		return models.Invitation{}, ErrNotFound
+	}
+	return s.invitation(id, invitation), nil
+}
+
+func (s *MemoryStore) GetInvitationsByUser(userID int) ([]models.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findInvitations(func(invitation memoryInvitation) bool { return invitation.UserID == userID }), nil
+}
+
+func (s *MemoryStore) GetInvitationsByTodo(todoID int) ([]models.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findInvitations(func(invitation memoryInvitation) bool { return invitation.TodoID == todoID }), nil
+}
+
+func (s *MemoryStore) AcceptInvitation(id int) (models.ToDo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation, ok := s.invites[id]
+	if !ok {
+		return models.ToDo{}, ErrNotFound
+	}
+
+	// Einladung einlösen und den Empfänger als Mitglied aufnehmen
+	delete(s.invites, id)
+	s.addMember(invitation.TodoID, invitation.UserID, invitation.Permission, nil)
+
+	todo, _ := s.todoFor(s.todos[invitation.TodoID], invitation.UserID)
+	return todo, nil
+}
+
+func (s *MemoryStore) DeleteInvitation(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.invites[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.invites, id)
+	return nil
+}
+
+func (s *MemoryStore) CreateShareLink(userID int, link *models.ShareLink, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link.ID = s.nextLinkID
+	s.nextLinkID++
+	link.CreatedAt = time.Now().UTC()
+	s.links[link.ID] = memoryShareLink{Link: *link, UserID: userID, Hash: hash}
+	return nil
+}
+
+func (s *MemoryStore) GetShareLinksByUser(userID int) ([]models.ShareLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := []models.ShareLink{}
+	for _, link := range s.links {
+		if link.UserID == userID {
+			links = append(links, link.Link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID < links[j].ID })
+	return links, nil
+}
+
+func (s *MemoryStore) RevokeShareLink(userID, linkID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.links[linkID]
+	if !ok || link.UserID != userID || link.Link.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	link.Link.RevokedAt = &now
+	s.links[linkID] = link
+	return nil
+}
+
+func (s *MemoryStore) ResolveShareLink(hash string) (models.ShareLink, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, link := range s.links {
+		if link.Hash == hash && link.Link.RevokedAt == nil && (link.Link.ExpiresAt == nil || link.Link.ExpiresAt.After(now)) {
+			return link.Link, link.UserID, nil
+		}
+	}
+	return models.ShareLink{}, 0, ErrNotFound
+}
+
+// Rang einer Rolle in Mitgliederlisten: Eigentümer, Admins, Mitglieder
+func groupRoleRank(role models.GroupRole) int {
+	switch role {
+	case models.GroupRoleOwner:
+		return 0
+	case models.GroupRoleAdmin:
+		return 1
+	}
+	return 2
+}
+
+// Mitglieder einer Gruppe, Eigentümer zuerst, dann Admins und Mitglieder jeweils nach Beitritt
+func sortedGroupMembers(group memoryGroup) []models.GroupMember {
+	members := []models.GroupMember{}
+	for _, member := range group.Members {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if groupRoleRank(members[i].Role) != groupRoleRank(members[j].Role) {
+			return groupRoleRank(members[i].Role) < groupRoleRank(members[j].Role)
+		}
+		if !members[i].JoinedAt.Equal(members[j].JoinedAt) {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members
+}
+
+// Prüft ob die Freigabe die ToDo umfasst, selbst oder über ihre Liste
+func shareCovers(share models.GroupShare, todoID int, todo models.ToDo) bool {
+	if share.TodoID != nil {
+		return *share.TodoID == todoID
+	}
+	return share.ListID != nil && share.OwnerID == todo.UserID && *share.ListID == listValue(todo.ListID)
+}
+
+// Gleicht die Mitgliedschaften einer ToDo, die über Gruppen bestehen, mit den Freigaben für Gruppen ab (siehe SQLStore), Aufrufer hält s.mu
+func (s *MemoryStore) syncGroupAccess(todoID int) {
+	todo, ok := s.todos[todoID]
+	if !ok {
+		return
+	}
+
+	// Soll: Mitglieder aller Gruppen, mit denen die ToDo selbst oder ihre Liste geteilt ist, die höchste Berechtigung zählt
+	shareIDs := []int{}
+	for shareID := range s.shares {
+		shareIDs = append(shareIDs, shareID)
+	}
+	sort.Ints(shareIDs)
+	wanted := map[int]models.GroupShare{}
+	for _, shareID := range shareIDs {
+		share := s.shares[shareID]
+		if !shareCovers(share, todoID, todo) {
+			continue
+		}
+		for userID := range s.groups[share.GroupID].Members {
+			if current, ok := wanted[userID]; userID != todo.UserID && (!ok || !current.Permission.Allows(share.Permission)) {
+				wanted[userID] = share
+			}
+		}
+	}
+
+	// Ist: bestehende Mitgliedschaften, direkte Freigaben bleiben unverändert
+	for userID, member := range s.members[todoID] {
+		share, ok := wanted[userID]
+		delete(wanted, userID)
+		switch {
+		case member.GroupID == nil:
+		case !ok:
+			s.removeMember(todoID, userID)
+		default:
+			groupID := share.GroupID
+			member.GroupID, member.Permission = &groupID, share.Permission
+			s.members[todoID][userID] = member
+		}
+	}
+
+	// Neue Mitglieder in fester Reihenfolge, eine offene Einladung ist damit hinfällig
+	userIDs := []int{}
+	for userID := range wanted {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Ints(userIDs)
+	for _, userID := range userIDs {
+		share := wanted[userID]
+		for invitationID, invitation := range s.invites {
+			if invitation.TodoID == todoID && invitation.UserID == userID {
+				delete(s.invites, invitationID)
+			}
+		}
+		groupID := share.GroupID
+		s.addMember(todoID, userID, share.Permission, &groupID)
+	}
+}
+
+// Gleicht alle ToDos ab, die mit der Gruppe geteilt sind oder Mitglieder über sie haben, Aufrufer hält s.mu
+func (s *MemoryStore) syncGroup(groupID int) {
+	todoIDs := map[int]bool{}
+	for todoID, members := range s.members {
+		for _, member := range members {
+			if member.GroupID != nil && *member.GroupID == groupID {
+				todoIDs[todoID] = true
+			}
+		}
+	}
+	for _, share := range s.shares {
+		if share.GroupID != groupID {
+			continue
+		}
+		for todoID, todo := range s.todos {
+			if shareCovers(share, todoID, todo) {
+				todoIDs[todoID] = true
+			}
+		}
+	}
+	for todoID := range todoIDs {
+		s.syncGroupAccess(todoID)
+	}
+}
+
+func (s *MemoryStore) CreateGroup(group *models.Group, ownerID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group.ID = s.nextGrpID
+	s.nextGrpID++
+	group.CreatedAt = time.Now().UTC()
+	group.Role = models.GroupRoleOwner
+	s.groups[group.ID] = memoryGroup{
+		Name:      group.Name,
+		CreatedAt: group.CreatedAt,
+		Members: map[int]models.GroupMember{
+			ownerID: {UserID: ownerID, Role: models.GroupRoleOwner, JoinedAt: group.CreatedAt},
+		},
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetGroup(id int) (models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[id]
+	if !ok {
+		return models.Group{}, ErrNotFound
+	}
+	members := sortedGroupMembers(group)
+	for i := range members {
+		members[i].DisplayName = s.users[members[i].UserID].DisplayName
+	}
+	return models.Group{ID: id, Name: group.Name, CreatedAt: group.CreatedAt, Members: members}, nil
+}
+
+func (s *MemoryStore) GetGroupsByUser(userID int) ([]models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := []models.Group{}
+	for id, group := range s.groups {
+		if member, ok := group.Members[userID]; ok {
+			groups = append(groups, models.Group{ID: id, Name: group.Name, Role: member.Role, CreatedAt: group.CreatedAt})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].ID < groups[j].ID
+	})
+	return groups, nil
+}
+
+func (s *MemoryStore) RenameGroup(id int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[id]
+	if !ok {
+		return ErrNotFound
+	}
+	group.Name = name
+	s.groups[id] = group
+	return nil
+}
+
+func (s *MemoryStore) DeleteGroup(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[id]; !ok {
+		return ErrNotFound
+	}
+
+	// Freigaben, Einladungen und Mitglieder entfernen, danach verlieren die Mitglieder den Zugriff über die Gruppe
+	delete(s.groups, id)
+	for shareID, share := range s.shares {
+		if share.GroupID == id {
+			delete(s.shares, shareID)
+		}
+	}
+	for invitationID, invitation := range s.grpInvites {
+		if invitation.GroupID == id {
+			delete(s.grpInvites, invitationID)
+		}
+	}
+	s.syncGroup(id)
+	return nil
+}
+
+func (s *MemoryStore) GetGroupRole(groupID, userID int) (models.GroupRole, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.groups[groupID].Members[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return member.Role, nil
+}
+
+func (s *MemoryStore) SetGroupRole(groupID, userID int, role models.GroupRole) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.groups[groupID].Members[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	member.Role = role
+	s.groups[groupID].Members[userID] = member
+	return nil
+}
+
+func (s *MemoryStore) RemoveGroupMember(groupID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[groupID].Members[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.groups[groupID].Members, userID)
+
+	// Seine Freigaben für die Gruppe enden mit der Mitgliedschaft
+	for shareID, share := range s.shares {
+		if share.GroupID == groupID && share.OwnerID == userID {
+			delete(s.shares, shareID)
+		}
+	}
+	s.syncGroup(groupID)
+	return nil
+}
+
+func (s *MemoryStore) CreateGroupShare(share *models.GroupShare) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Eine ToDo bzw. Liste wird je Gruppe nur einmal geteilt
+	for _, other := range s.shares {
+		if other.GroupID != share.GroupID {
+			continue
+		}
+		if share.TodoID != nil && other.TodoID != nil && *other.TodoID == *share.TodoID {
+			return ErrAlreadyShared
+		}
+		if share.TodoID == nil && other.ListID != nil && share.ListID != nil && *other.ListID == *share.ListID {
+			return ErrAlreadyShared
+		}
+	}
+
+	share.ID = s.nextShrID
+	s.nextShrID++
+	share.CreatedAt = time.Now().UTC()
+	if share.TodoID != nil {
+		share.ListID = nil
+	}
+	s.shares[share.ID] = *share
+	s.syncGroup(share.GroupID)
+	return nil
+}
+
+func (s *MemoryStore) GetGroupShare(id int) (models.GroupShare, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	share, ok := s.shares[id]
+	if !ok {
+		return models.GroupShare{}, ErrNotFound
+	}
+	return share, nil
+}
+
+func (s *MemoryStore) GetGroupShares(groupID int) ([]models.GroupShare, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shares := []models.GroupShare{}
+	for _, share := range s.shares {
+		if share.GroupID == groupID {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	return shares, nil
+}
+
+func (s *MemoryStore) DeleteGroupShare(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	share, ok := s.shares[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.shares, id)
+	s.syncGroup(share.GroupID)
+	return nil
+}
+
+// Einladung in eine Gruppe mit deren Namen, Aufrufer hält s.mu
+func (s *MemoryStore) groupInvitation(id int, invitation memoryGroupInvitation) models.GroupInvitation {
+	return models.GroupInvitation{
+		ID:        id,
+		GroupID:   invitation.GroupID,
+		GroupName: s.groups[invitation.GroupID].Name,
+		UserID:    invitation.UserID,
+		Role:      invitation.Role,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
+
+// Offene Einladungen in Gruppen, die match erfüllen, älteste zuerst, Aufrufer hält s.mu
+func (s *MemoryStore) findGroupInvitations(match func(memoryGroupInvitation) bool) []models.GroupInvitation {
+	invitations := []models.GroupInvitation{}
+	for id, invitation := range s.grpInvites {
+		if match(invitation) {
+			invitations = append(invitations, s.groupInvitation(id, invitation))
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].CreatedAt.Equal(invitations[j].CreatedAt) {
+			return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+		}
+		return invitations[i].ID < invitations[j].ID
+	})
+	return invitations
+}
+
+func (s *MemoryStore) CreateGroupInvitation(invitation *models.GroupInvitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[invitation.GroupID].Members[invitation.UserID]; ok {
+		return ErrAlreadyMember
+	}
+	for _, other := range s.grpInvites {
+		if other.GroupID == invitation.GroupID && other.UserID == invitation.UserID {
+			return ErrInvitationOpen
+		}
+	}
+
+	invitation.ID = s.nextGInvID
+	s.nextGInvID++
+	invitation.CreatedAt = time.Now().UTC()
+	s.grpInvites[invitation.ID] = memoryGroupInvitation{GroupID: invitation.GroupID, UserID: invitation.UserID, Role: invitation.Role, CreatedAt: invitation.CreatedAt}
+	return nil
+}
+
+func (s *MemoryStore) GetGroupInvitation(id int) (models.GroupInvitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation, ok := s.grpInvites[id]
+	if !ok {
+		return models.GroupInvitation{}, ErrNotFound
+	}
+	return s.groupInvitation(id, invitation), nil
+}
+
+func (s *MemoryStore) GetGroupInvitationsByUser(userID int) ([]models.GroupInvitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findGroupInvitations(func(invitation memoryGroupInvitation) bool { return invitation.UserID == userID }), nil
+}
+
+func (s *MemoryStore) GetGroupInvitationsByGroup(groupID int) ([]models.GroupInvitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findGroupInvitations(func(invitation memoryGroupInvitation) bool { return invitation.GroupID == groupID }), nil
+}
+
+func (s *MemoryStore) AcceptGroupInvitation(id int) (models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invitation, ok := s.grpInvites[id]
+	if !ok {
+		return models.Group{}, ErrNotFound
+	}
+
+	// Einladung einlösen, erst jetzt erhält der Empfänger Zugriff auf die Freigaben der Gruppe
+	delete(s.grpInvites, id)
+	group := s.groups[invitation.GroupID]
+	group.Members[invitation.UserID] = models.GroupMember{UserID: invitation.UserID, Role: invitation.Role, JoinedAt: time.Now().UTC()}
+	s.syncGroup(invitation.GroupID)
+	return models.Group{ID: invitation.GroupID, Name: group.Name, Role: invitation.Role, CreatedAt: group.CreatedAt}, nil
+}
+
+func (s *MemoryStore) DeleteGroupInvitation(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.grpInvites[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.grpInvites, id)
+	return nil
+}
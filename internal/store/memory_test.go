@@ -0,0 +1,14 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/Paul-frank/todo-api/internal/store"
+	"github.com/Paul-frank/todo-api/internal/store/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return store.NewMemoryStore()
+	})
+}
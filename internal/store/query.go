@@ -0,0 +1,203 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Verknüpfung mehrerer Tags im Filter
+const (
+	TagModeAll = "and" // ToDo muss alle Tags tragen
+	TagModeAny = "or"  // ToDo muss mindestens einen der Tags tragen
+)
+
+// Sortierungen für GetTodosByUser
+const (
+	SortByOrder     = ""           // manuelle Reihenfolge (order) je Liste, ToDos ohne Liste zuerst
+	SortByPriority  = "priority"   // höchste Priorität zuerst, danach Liste und order
+	SortByCreatedAt = "created_at" // älteste zuerst
+	SortByUpdatedAt = "updated_at" // zuletzt geänderte zuletzt
+	SortByTitle     = "title"      // alphabetisch
+)
+
+// Einschränkungen, Sortierung und Seitengröße für GetTodosByUser, nicht gesetzte Felder schränken nicht ein
+type TodoFilter struct {
+	Completed    *bool      // nur erledigte bzw. nur offene ToDos
+	Category     string     // nur ToDos dieser Kategorie
+	ListID       *int       // nur ToDos dieser Liste, 0 = ohne Liste
+	Shared       *bool      // nur mit dem Benutzer geteilte (true) bzw. nur eigene ToDos (false)
+	CreatedFrom  *time.Time // erstellt ab (einschließlich)
+	CreatedUntil *time.Time // erstellt vor (ausschließlich)
+	UpdatedFrom  *time.Time // geändert ab (einschließlich)
+	UpdatedUntil *time.Time // geändert vor (ausschließlich)
+	DueFrom      *time.Time // fällig ab (einschließlich)
+	DueUntil     *time.Time // fällig vor (ausschließlich)
+	Tags         []string   // nur ToDos mit diesen Tags
+	TagMode      string     // TagModeAll (Standard) oder TagModeAny
+	Sort         string     // eine der SortBy... Konstanten
+	Descending   bool       // Sortierung umkehren
+	After        *Cursor    // nur ToDos, die in der Sortierung nach dieser Position kommen
+	Limit        int        // maximale Anzahl, 0 = unbegrenzt
+}
+
+// Position einer ToDo in einer sortierten Liste, Grundlage der Cursor-Pagination.
+// Enthält alle Werte, nach denen sortiert werden kann, die ID dient bei Gleichstand als letztes Kriterium.
+type Cursor struct {
+	ID        int             `json:"id"`
+	ListID    int             `json:"list_id"`
+	Order     int             `json:"order"`
+	Priority  models.Priority `json:"priority"`
+	Title     string          `json:"title"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Cursor, der direkt hinter der ToDo steht
+func CursorFor(todo models.ToDo) Cursor {
+	cursor := Cursor{
+		ID:        todo.ID,
+		ListID:    listValue(todo.ListID),
+		Order:     todo.Order,
+		Title:     todo.Title,
+		CreatedAt: todo.CreatedAt,
+		UpdatedAt: todo.UpdatedAt,
+	}
+	if todo.Priority != nil {
+		cursor.Priority = *todo.Priority
+	}
+	return cursor
+}
+
+// Wert für die Spalte list_id, 0 für ToDos ohne Liste
+func listValue(listID *int) int {
+	if listID == nil {
+		return 0
+	}
+	return *listID
+}
+
+// ListID einer ToDo, nil für ToDos ohne Liste
+func listPtr(listID int) *int {
+	if listID == 0 {
+		return nil
+	}
+	return &listID
+}
+
+// Ein Sortierkriterium: Spalte in der Datenbank und Vergleich im Arbeitsspeicher
+type sortKey struct {
+	Column  string                     // Spalte im SQLite-Stil
+	Desc    bool                       // absteigend sortieren
+	Value   func(c Cursor) interface{} // Wert des Cursors für den Vergleich in SQL
+	Compare func(a, b models.ToDo) int // <0 wenn a vor b (aufsteigend)
+}
+
+func compareInts(a, b int) int {
+	return a - b
+}
+
+func compareTimes(a, b time.Time) int {
+	return a.Compare(b)
+}
+
+var (
+	keyList = sortKey{Column: "list_id",
+		Value:   func(c Cursor) interface{} { return c.ListID },
+		Compare: func(a, b models.ToDo) int { return compareInts(listValue(a.ListID), listValue(b.ListID)) }}
+	keyOrder = sortKey{Column: "`order`",
+		Value:   func(c Cursor) interface{} { return c.Order },
+		Compare: func(a, b models.ToDo) int { return compareInts(a.Order, b.Order) }}
+	keyPriority = sortKey{Column: "priority",
+		Value:   func(c Cursor) interface{} { return c.Priority },
+		Compare: func(a, b models.ToDo) int { return compareInts(int(*a.Priority), int(*b.Priority)) }}
+	keyTitle = sortKey{Column: "title",
+		Value:   func(c Cursor) interface{} { return c.Title },
+		Compare: func(a, b models.ToDo) int { return strings.Compare(a.Title, b.Title) }}
+	keyCreatedAt = sortKey{Column: "created_at",
+		Value:   func(c Cursor) interface{} { return c.CreatedAt.UTC() },
+		Compare: func(a, b models.ToDo) int { return compareTimes(a.CreatedAt, b.CreatedAt) }}
+	keyUpdatedAt = sortKey{Column: "updated_at",
+		Value:   func(c Cursor) interface{} { return c.UpdatedAt.UTC() },
+		Compare: func(a, b models.ToDo) int { return compareTimes(a.UpdatedAt, b.UpdatedAt) }}
+	keyID = sortKey{Column: "id",
+		Value:   func(c Cursor) interface{} { return c.ID },
+		Compare: func(a, b models.ToDo) int { return compareInts(a.ID, b.ID) }}
+)
+
+// Liefert die Sortierkriterien für eine Sortierung, die ID ist immer das letzte Kriterium,
+// damit die Reihenfolge eindeutig ist und der Cursor genau eine Position beschreibt
+func sortKeys(sort string, descending bool) []sortKey {
+	var keys []sortKey
+	switch sort {
+	case SortByPriority:
+		priority := keyPriority
+		priority.Desc = true // höchste Priorität zuerst
+		keys = []sortKey{priority, keyList, keyOrder, keyID}
+	case SortByCreatedAt:
+		keys = []sortKey{keyCreatedAt, keyID}
+	case SortByUpdatedAt:
+		keys = []sortKey{keyUpdatedAt, keyID}
+	case SortByTitle:
+		keys = []sortKey{keyTitle, keyID}
+	default:
+		keys = []sortKey{keyList, keyOrder, keyID}
+	}
+
+	if descending {
+		for i := range keys {
+			keys[i].Desc = !keys[i].Desc
+		}
+	}
+	return keys
+}
+
+// Vergleicht zwei ToDos nach den Sortierkriterien, <0 wenn a vor b kommt
+func compareByKeys(keys []sortKey, a, b models.ToDo) int {
+	for _, key := range keys {
+		c := key.Compare(a, b)
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Bildet die ORDER BY Klausel für die Sortierkriterien
+func orderByClause(keys []sortKey) string {
+	parts := []string{}
+	for _, key := range keys {
+		if key.Desc {
+			parts = append(parts, key.Column+" DESC")
+		} else {
+			parts = append(parts, key.Column)
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// Bildet die Bedingung "kommt nach dem Cursor" für die Sortierkriterien (Keyset-Pagination):
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func afterCursorClause(keys []sortKey, cursor Cursor) (string, []interface{}) {
+	alternatives := []string{}
+	args := []interface{}{}
+	for i, key := range keys {
+		conditions := []string{}
+		for _, previous := range keys[:i] {
+			conditions = append(conditions, previous.Column+" = ?")
+			args = append(args, previous.Value(cursor))
+		}
+		operator := " > ?"
+		if key.Desc {
+			operator = " < ?"
+		}
+		conditions = append(conditions, key.Column+operator)
+		args = append(args, key.Value(cursor))
+		alternatives = append(alternatives, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
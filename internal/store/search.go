@@ -0,0 +1,171 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Markierung der Fundstellen in Titel und Ausschnitt eines Suchtreffers
+const (
+	markStart = "<mark>"
+	markEnd   = "</mark>"
+)
+
+const snippetWords = 12 // Anzahl der Wörter im Ausschnitt der Beschreibung
+
+// Zerlegt eine Suchanfrage in Suchbegriffe (Buchstaben und Ziffern, klein geschrieben). Sonderzeichen
+// werden verworfen, damit Benutzereingaben nicht als Operatoren der Suchsyntax interpretiert werden.
+// Jeder Begriff muss vorkommen und passt auch als Wortanfang ("rech" findet "Rechnung").
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), isNotWordRune)
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Start- und Endposition (Bytes) aller Wörter eines Textes
+func wordSpans(text string) [][2]int {
+	spans := [][2]int{}
+	start := -1
+	for i, r := range text {
+		if isNotWordRune(r) {
+			if start >= 0 {
+				spans = append(spans, [2]int{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+// Prüft ob ein Wort mit einem der Suchbegriffe beginnt
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// Markiert alle Wörter aus spans[from:to], die zu einem Suchbegriff passen
+func highlightSpans(text string, spans [][2]int, from, to int, terms []string) string {
+	var b strings.Builder
+	pos := spans[from][0]
+	for _, span := range spans[from:to] {
+		b.WriteString(text[pos:span[0]])
+		word := text[span[0]:span[1]]
+		if matchesTerm(word, terms) {
+			b.WriteString(markStart + word + markEnd)
+		} else {
+			b.WriteString(word)
+		}
+		pos = span[1]
+	}
+	return b.String()
+}
+
+// Markiert alle Fundstellen im gesamten Text
+func highlightText(text string, terms []string) string {
+	spans := wordSpans(text)
+	if len(spans) == 0 {
+		return text
+	}
+	return text[:spans[0][0]] + highlightSpans(text, spans, 0, len(spans), terms) + text[spans[len(spans)-1][1]:]
+}
+
+// Ausschnitt von snippetWords Wörtern um die erste Fundstelle, gekürzte Enden werden mit "…" markiert
+func snippetText(text string, terms []string) string {
+	spans := wordSpans(text)
+	if len(spans) <= snippetWords {
+		return highlightText(text, terms)
+	}
+
+	first := 0
+	for i, span := range spans {
+		if matchesTerm(text[span[0]:span[1]], terms) {
+			first = i
+			break
+		}
+	}
+	from := first - 2 // etwas Kontext vor der Fundstelle
+	if from < 0 {
+		from = 0
+	}
+	if from > len(spans)-snippetWords {
+		from = len(spans) - snippetWords
+	}
+	to := from + snippetWords
+
+	snippet := highlightSpans(text, spans, from, to, terms)
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(spans) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// Bewertung eines Treffers: Fundstellen im Titel zählen mehr als in der Beschreibung, 0 wenn nicht
+// jeder Suchbegriff vorkommt
+func searchScore(title, description string, terms []string) int {
+	score := 0
+	for _, term := range terms {
+		titleHits := countTermHits(title, term)
+		descriptionHits := countTermHits(description, term)
+		if titleHits+descriptionHits == 0 {
+			return 0
+		}
+		score += 10*titleHits + descriptionHits
+	}
+	return score
+}
+
+func countTermHits(text, term string) int {
+	hits := 0
+	for _, span := range wordSpans(text) {
+		if strings.HasPrefix(strings.ToLower(text[span[0]:span[1]]), term) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// Treffer mit Markierung der Fundstellen und seine Bewertung, 0 wenn nicht jeder Suchbegriff vorkommt
+func searchResult(todo models.ToDo, terms []string) (models.SearchResult, int) {
+	score := searchScore(todo.Title, todo.Description, terms)
+	if score == 0 {
+		return models.SearchResult{}, 0
+	}
+	return models.SearchResult{
+		Todo:    todo,
+		Title:   highlightText(todo.Title, terms),
+		Snippet: snippetText(todo.Description, terms),
+	}, score
+}
+
+// Sortiert die Treffer nach Bewertung, beste zuerst, bei Gleichstand nach ID, und kürzt auf limit (0 = unbegrenzt)
+func rankResults(results []models.SearchResult, scores map[int]int, limit int) []models.SearchResult {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i].Todo.ID, results[j].Todo.ID
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return a < b
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,665 @@
+package store
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/database"
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Spaltenliste für alle Abfragen, die eine vollständige ToDo aus todoView einlesen (Reihenfolge wie in scanTodo)
+const todoColumns = "id, user_id, title, description, category, `order`, created_at, updated_at, completed, due_at, priority, permission, list_id, auto_complete, items_done, items_total, recurrence"
+
+// ToDos aus Sicht eines Benutzers als abgeleitete Tabelle todos, der Platzhalter ist die UserID. Bei mit ihm
+// geteilten ToDos gelten Kategorie, Liste und Position (order) aus todo_members und permission ist gesetzt, sonst leer.
+// items_done und items_total zählen die Punkte der Checkliste.
+// Zwischen todoViewSelect und todoViewFrom können weitere Spalten von todos ergänzt werden.
+const (
+	todoViewSelect = "(SELECT todos.id, todos.user_id, todos.title, todos.description, " +
+		"COALESCE(todo_members.category, todos.category) AS category, COALESCE(todo_members.`order`, todos.`order`) AS `order`, " +
+		"todos.created_at, todos.updated_at, todos.completed, todos.due_at, todos.priority, COALESCE(todo_members.permission, '') AS permission, " +
+		"COALESCE(todo_members.list_id, todos.list_id) AS list_id, todos.auto_complete, " +
+		"(SELECT COUNT(*) FROM checklist_items WHERE checklist_items.todo_id = todos.id AND checklist_items.completed) AS items_done, " +
+		"(SELECT COUNT(*) FROM checklist_items WHERE checklist_items.todo_id = todos.id) AS items_total, todos.recurrence"
+	todoViewFrom = " FROM todos LEFT JOIN todo_members ON todo_members.todo_id = todos.id AND todo_members.user_id = ?) AS todos"
+	todoView     = todoViewSelect + todoViewFrom
+)
+
+// Bedingung auf todoView für eigene und mit dem Benutzer geteilte ToDos, der Platzhalter ist die UserID
+const visibleCondition = "(user_id = ? OR permission != '')"
+
+// Store-Implementierung auf Basis einer SQL Datenbank (SQLite oder PostgreSQL). Alle Abfragen werden im
+// SQLite-Stil geschrieben und vor der Ausführung mit Rebind in den Dialekt der Verbindung übersetzt.
+type SQLStore struct {
+	db      *sql.DB            // offene Datenbankverbindung
+	dialect *database.Database // für Rebind
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func NewSQLStore(db *database.Database) *SQLStore {
+	return &SQLStore{db: db.Connection, dialect: db}
+}
+
+// Übersetzt eine Abfrage in den Dialekt der Verbindung
+func (s *SQLStore) q(query string) string {
+	return s.dialect.Rebind(query)
+}
+
+// Gemeinsame Schnittstelle von *sql.DB und *sql.Tx für die Hilfsfunktionen
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Gemeinsame Schnittstelle von *sql.Row und *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Liest eine Zeile mit den Spalten aus todoColumns in eine ToDo ein
+func scanTodo(row scanner) (models.ToDo, error) {
+	var todo models.ToDo
+	var description, category sql.NullString // Spalten dürfen NULL sein
+	var dueAt sql.NullTime
+	var priority models.Priority
+	var listID int
+	var autoComplete bool
+	var progress models.Progress
+	var recurrence string
+	err := row.Scan(&todo.ID, &todo.UserID, &todo.Title, &description, &category, &todo.Order, &todo.CreatedAt, &todo.UpdatedAt, &todo.Completed, &dueAt, &priority, &todo.Permission,
+		&listID, &autoComplete, &progress.Done, &progress.Total, &recurrence)
+	todo.Priority = &priority
+	todo.ListID = listPtr(listID)
+	todo.AutoComplete = &autoComplete
+	if progress.Total > 0 {
+		todo.Progress = &progress
+	}
+	todo.Description = description.String
+	todo.Category = category.String
+	if dueAt.Valid {
+		todo.DueAt = &models.DueTime{Time: dueAt.Time}
+	}
+	if err == nil && recurrence != "" {
+		rule, parseErr := models.ParseRecurrence(recurrence)
+		todo.Recurrence, err = &rule, parseErr
+	}
+	return todo, err
+}
+
+// Wert für die Spalte recurrence, leer für ToDos ohne Wiederholung
+func recurrenceValue(recurrence *models.Recurrence) string {
+	if recurrence == nil {
+		return ""
+	}
+	return recurrence.String()
+}
+
+// Wert für die Spalte priority, ohne Angabe keine Priorität
+func priorityValue(priority *models.Priority) models.Priority {
+	if priority == nil {
+		return models.PriorityNone
+	}
+	return *priority
+}
+
+// Wert für die Spalte due_at. Zeitpunkte werden in UTC gespeichert, damit SQLite sie als Text korrekt vergleicht.
+func dueValue(due *models.DueTime) interface{} {
+	if due == nil || due.IsZero() {
+		return nil
+	}
+	return due.Time.UTC()
+}
+
+// Ermittelt die nächste freie Position (order) in einer Liste des Benutzers über eigene ToDos und Mitgliedschaften, listID 0 = ohne Liste
+func (s *SQLStore) nextOrder(q queryer, userID, listID int) (int, error) {
+	var maxOrder int
+	err := q.QueryRow(s.q("SELECT COALESCE(MAX(`order`), 0) FROM (SELECT `order` FROM todos WHERE user_id = ? AND list_id = ? "+
+		"UNION ALL SELECT `order` FROM todo_members WHERE user_id = ? AND list_id = ?) AS orders"), userID, listID, userID, listID).Scan(&maxOrder) // COALESCE -> 0 wenn die Liste noch leer ist
+	return maxOrder + 1, err
+}
+
+// Verschiebt die Positionen from bis to (einschließlich) in einer Liste des Benutzers um delta, in eigenen ToDos und Mitgliedschaften
+func (s *SQLStore) shiftOrders(tx *sql.Tx, userID, listID, from, to, delta int) error {
+	for _, table := range []string{"todos", "todo_members"} {
+		_, err := tx.Exec(s.q("UPDATE "+table+" SET `order` = `order` + ? WHERE user_id = ? AND list_id = ? AND `order` >= ? AND `order` <= ?"), delta, userID, listID, from, to)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schließt die Lücke, die eine entfernte ToDo an Position order in einer Liste des Benutzers hinterlässt
+func (s *SQLStore) closeOrderGap(tx *sql.Tx, userID, listID, order int) error {
+	return s.shiftOrders(tx, userID, listID, order+1, math.MaxInt32, -1)
+}
+
+// Liest eine ToDo aus Sicht des Benutzers userID inkl. seiner Tags
+func (s *SQLStore) getTodo(q queryer, id, userID int) (models.ToDo, error) {
+	todo, err := scanTodo(q.QueryRow(s.q("SELECT "+todoColumns+" FROM "+todoView+" WHERE id = ?"), userID, id))
+	if err == sql.ErrNoRows {
+		return todo, ErrNotFound
+	}
+	if err != nil {
+		return todo, err
+	}
+
+	todo.Tags, err = s.tagsForTodo(q, id, userID)
+	return todo, err
+}
+
+func (s *SQLStore) GetTodo(id, userID int) (models.ToDo, error) {
+	return s.getTodo(s.db, id, userID)
+}
+
+func (s *SQLStore) GetTodosByUser(userID int, filter TodoFilter) ([]models.ToDo, error) {
+	query := "SELECT " + todoColumns + " FROM " + todoView + " WHERE " + visibleCondition
+	args := []interface{}{userID, userID}
+
+	if filter.Completed != nil {
+		query += " AND completed = ?"
+		args = append(args, *filter.Completed)
+	}
+	if filter.Category != "" {
+		query += " AND category = ?"
+		args = append(args, filter.Category)
+	}
+	if filter.ListID != nil {
+		query += " AND list_id = ?"
+		args = append(args, *filter.ListID)
+	}
+	if filter.Shared != nil {
+		if *filter.Shared {
+			query += " AND permission != ''"
+		} else {
+			query += " AND permission = ''"
+		}
+	}
+
+	// Zeiträume, Zeitpunkte werden in UTC verglichen (siehe dueValue)
+	ranges := []struct {
+		condition string
+		value     *time.Time
+	}{
+		{" AND created_at >= ?", filter.CreatedFrom},
+		{" AND created_at < ?", filter.CreatedUntil},
+		{" AND updated_at >= ?", filter.UpdatedFrom},
+		{" AND updated_at < ?", filter.UpdatedUntil},
+		{" AND due_at >= ?", filter.DueFrom},
+		{" AND due_at < ?", filter.DueUntil},
+	}
+	for _, r := range ranges {
+		if r.value != nil {
+			query += r.condition
+			args = append(args, r.value.UTC())
+		}
+	}
+	if len(filter.Tags) > 0 {
+		// ToDos, die mindestens einen (or) bzw. alle (and) der gesuchten Tags tragen
+		query += " AND id IN (SELECT todo_tags.todo_id FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id WHERE tags.user_id = ? AND tags.name IN (?" + strings.Repeat(", ?", len(filter.Tags)-1) + ") GROUP BY todo_tags.todo_id"
+		args = append(args, userID)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		if filter.TagMode != TagModeAny {
+			query += " HAVING COUNT(DISTINCT tags.id) = ?"
+			args = append(args, len(filter.Tags))
+		}
+		query += ")"
+	}
+
+	// Sortierung und Keyset-Pagination
+	keys := sortKeys(filter.Sort, filter.Descending)
+	if filter.After != nil {
+		condition, cursorArgs := afterCursorClause(keys, *filter.After)
+		query += " AND " + condition
+		args = append(args, cursorArgs...)
+	}
+	query += orderByClause(keys)
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(s.q(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.ToDo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Tags der ToDos dieser Seite in einer Abfrage nachladen
+	ids := make([]int, len(todos))
+	for i := range todos {
+		ids[i] = todos[i].ID
+	}
+	tags, err := s.tagsForTodos(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range todos {
+		todos[i].Tags = tags[todos[i].ID]
+		if todos[i].Tags == nil {
+			todos[i].Tags = []string{}
+		}
+	}
+
+	return todos, nil
+}
+
+func (s *SQLStore) CreateTodo(todo *models.ToDo) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // ohne Wirkung nach erfolgreichem Commit
+
+	if err = s.insertTodo(tx, todo); err != nil {
+		return err
+	}
+
+	// Ist ihre Liste (list_id) mit einer Gruppe geteilt, erhalten deren Mitglieder Zugriff
+	if err = s.syncGroupAccess(tx, todo.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Fügt die ToDo am Ende ihrer Liste (ListID) ein und setzt ID, Order und Zeitstempel
+func (s *SQLStore) insertTodo(tx *sql.Tx, todo *models.ToDo) error {
+	var err error
+	todo.ListID = listPtr(listValue(todo.ListID))
+	todo.Order, err = s.nextOrder(tx, todo.UserID, listValue(todo.ListID))
+	if err != nil {
+		return err
+	}
+
+	priority := priorityValue(todo.Priority)
+	todo.Priority = &priority
+	autoComplete := todo.AutoComplete != nil && *todo.AutoComplete
+	todo.AutoComplete = &autoComplete
+
+	now := time.Now().UTC() // UTC, damit SQLite die Zeitpunkte als Text korrekt vergleicht und sortiert
+	todo.CreatedAt, todo.UpdatedAt = now, now
+
+	return tx.QueryRow(s.q("INSERT INTO todos (user_id, title, description, category, list_id, `order`, created_at, updated_at, completed, due_at, priority, auto_complete, recurrence) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id"),
+		todo.UserID, todo.Title, todo.Description, todo.Category, listValue(todo.ListID), todo.Order, todo.CreatedAt, todo.UpdatedAt, todo.Completed, dueValue(todo.DueAt), priorityValue(todo.Priority), autoComplete,
+		recurrenceValue(todo.Recurrence)).Scan(&todo.ID) // RETURNING statt LastInsertId, da PostgreSQL diese nicht unterstützt
+}
+
+func (s *SQLStore) UpdateTodo(id, userID int, changes models.ToDo) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := scanTodo(tx.QueryRow(s.q("SELECT "+todoColumns+" FROM "+todoView+" WHERE id = ?"), userID, id))
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Wechselt die ToDo die Liste, schließt sich die Lücke in der alten Liste und sie rückt ans Ende bzw. an die angegebene Position der neuen
+	currentList, newList := listValue(current.ListID), listValue(current.ListID)
+	if changes.ListID != nil {
+		newList = *changes.ListID
+	}
+	if newList != currentList {
+		next, err := s.nextOrder(tx, userID, newList)
+		if err != nil {
+			return err
+		}
+		if changes.Order == 0 {
+			changes.Order = next
+		}
+		if changes.Order < 1 || changes.Order > next {
+			return ErrOrderOutOfRange
+		}
+
+		if err = s.closeOrderGap(tx, userID, currentList, current.Order); err != nil {
+			return err
+		}
+		if err = s.shiftOrders(tx, userID, newList, changes.Order, math.MaxInt32, 1); err != nil {
+			return err
+		}
+	} else if changes.Order != 0 {
+		// Wenn Position sich verändert, dann die anderen ToDos der Liste verschieben
+		next, err := s.nextOrder(tx, userID, currentList)
+		if err != nil {
+			return err
+		}
+		if changes.Order < 1 || changes.Order >= next {
+			return ErrOrderOutOfRange
+		}
+		if changes.Order == current.Order {
+			return ErrOrderUnchanged
+		}
+
+		if changes.Order > current.Order {
+			err = s.shiftOrders(tx, userID, currentList, current.Order+1, changes.Order, -1)
+		} else {
+			err = s.shiftOrders(tx, userID, currentList, changes.Order, current.Order-1, 1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Bilden der SQL Strings für die Aktualisierung. Der Inhalt gilt für alle Mitglieder,
+	// Kategorie, Liste und Position nur für den Benutzer (Eigentümer in todos, Mitglied in todo_members).
+	contentArgs := []interface{}{} // -> Slice vom Typ Interface um Argumente der unterschiedlichen Typen aufzunehmen
+	contentQuery := "UPDATE todos SET "
+	if changes.Title != "" {
+		contentQuery += "title = ?, "
+		contentArgs = append(contentArgs, changes.Title)
+	}
+	if changes.Description != "" {
+		contentQuery += "description = ?, "
+		contentArgs = append(contentArgs, changes.Description)
+	}
+	if changes.DueAt != nil {
+		contentQuery += "due_at = ?, " // Nullwert entfernt die Fälligkeit
+		contentArgs = append(contentArgs, dueValue(changes.DueAt))
+	}
+	if changes.Priority != nil {
+		contentQuery += "priority = ?, "
+		contentArgs = append(contentArgs, *changes.Priority)
+	}
+	if changes.AutoComplete != nil {
+		contentQuery += "auto_complete = ?, "
+		contentArgs = append(contentArgs, *changes.AutoComplete)
+	}
+	if changes.Recurrence != nil {
+		contentQuery += "recurrence = ?, " // leere Wiederholung entfernt sie
+		contentArgs = append(contentArgs, recurrenceValue(changes.Recurrence))
+	}
+
+	ownAssignments := []string{}
+	ownArgs := []interface{}{}
+	if changes.Category != "" {
+		ownAssignments = append(ownAssignments, "category = ?")
+		ownArgs = append(ownArgs, changes.Category)
+	}
+	if newList != currentList {
+		ownAssignments = append(ownAssignments, "list_id = ?")
+		ownArgs = append(ownArgs, newList)
+	}
+	if changes.Order != 0 {
+		ownAssignments = append(ownAssignments, "`order` = ?")
+		ownArgs = append(ownArgs, changes.Order)
+	}
+
+	if len(contentArgs) == 0 && len(ownArgs) == 0 {
+		return ErrNoChanges
+	}
+
+	now := time.Now().UTC()
+	if len(contentArgs) > 0 {
+		contentQuery += "updated_at = ? WHERE id = ?"
+		contentArgs = append(contentArgs, now, id)
+		_, err = tx.Exec(s.q(contentQuery), contentArgs...)
+		if err != nil {
+			return err
+		}
+	}
+	if len(ownArgs) > 0 {
+		if current.UserID == userID {
+			ownAssignments = append(ownAssignments, "updated_at = ?")
+			_, err = tx.Exec(s.q("UPDATE todos SET "+strings.Join(ownAssignments, ", ")+" WHERE id = ?"), append(ownArgs, now, id)...)
+		} else {
+			_, err = tx.Exec(s.q("UPDATE todo_members SET "+strings.Join(ownAssignments, ", ")+" WHERE todo_id = ? AND user_id = ?"), append(ownArgs, id, userID)...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Mit auto_complete übernimmt die ToDo sofort den Stand ihrer Checkliste
+	if changes.AutoComplete != nil {
+		if err = s.syncChecklistStatus(tx, id); err != nil {
+			return err
+		}
+	}
+
+	// Verschiebt der Eigentümer die ToDo in eine andere Liste, ändern sich die Freigaben für Gruppen
+	if current.UserID == userID && newList != currentList {
+		if err = s.syncGroupAccess(tx, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) DeleteTodo(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Positionen (order) der nachfolgenden ToDos beim Eigentümer und bei allen Mitgliedern in ihrer jeweiligen Liste anpassen
+	type position struct{ userID, listID, order int }
+	rows, err := tx.Query(s.q("SELECT user_id, list_id, `order` FROM todos WHERE id = ? UNION ALL SELECT user_id, list_id, `order` FROM todo_members WHERE todo_id = ?"), id, id)
+	if err != nil {
+		return err
+	}
+	var positions []position
+	for rows.Next() {
+		var p position
+		if err := rows.Scan(&p.userID, &p.listID, &p.order); err != nil {
+			rows.Close()
+			return err
+		}
+		positions = append(positions, p)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(positions) == 0 {
+		return ErrNotFound
+	}
+	for _, p := range positions {
+		if err = s.closeOrderGap(tx, p.userID, p.listID, p.order); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(s.q("DELETE FROM todo_tags WHERE todo_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM checklist_items WHERE todo_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todo_members WHERE todo_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM share_invitations WHERE todo_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM share_links WHERE todo_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE todo_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todos WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Nimmt den Benutzer als Mitglied auf, die ToDo erscheint am Ende seiner ToDos ohne Liste in der Kategorie "shared".
+// groupID ist gesetzt, wenn der Zugriff über eine Gruppe besteht.
+func (s *SQLStore) addMember(tx *sql.Tx, todoID, userID int, permission models.SharePermission, groupID *int) error {
+	order, err := s.nextOrder(tx, userID, 0)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("INSERT INTO todo_members (todo_id, user_id, permission, category, `order`, created_at, group_id) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		todoID, userID, permission, "shared", order, time.Now().UTC(), intValue(groupID))
+	return err
+}
+
+// Entfernt die Mitgliedschaft und die Tags des Benutzers an der ToDo und schließt die Lücke in der Reihenfolge seiner Liste
+func (s *SQLStore) removeMember(tx *sql.Tx, todoID, userID int) error {
+	var listID, order int
+	err := tx.QueryRow(s.q("SELECT list_id, `order` FROM todo_members WHERE todo_id = ? AND user_id = ?"), todoID, userID).Scan(&listID, &order)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todo_members WHERE todo_id = ? AND user_id = ?"), todoID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todo_tags WHERE todo_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ?)"), todoID, userID)
+	if err != nil {
+		return err
+	}
+	return s.closeOrderGap(tx, userID, listID, order)
+}
+
+// Liest die Gruppe einer Mitgliedschaft, ErrNotFound wenn userID kein Mitglied ist
+func (s *SQLStore) membership(q queryer, todoID, userID int) (groupID sql.NullInt64, err error) {
+	err = q.QueryRow(s.q("SELECT group_id FROM todo_members WHERE todo_id = ? AND user_id = ?"), todoID, userID).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		err = ErrNotFound
+	}
+	return groupID, err
+}
+
+func (s *SQLStore) SetMemberPermission(todoID, userID int, permission models.SharePermission) error {
+	groupID, err := s.membership(s.db, todoID, userID)
+	if err != nil {
+		return err
+	}
+	if groupID.Valid {
+		return ErrGroupAccess // die Berechtigung ergibt sich aus der Freigabe für die Gruppe
+	}
+
+	_, err = s.db.Exec(s.q("UPDATE todo_members SET permission = ? WHERE todo_id = ? AND user_id = ?"), permission, todoID, userID)
+	return err
+}
+
+func (s *SQLStore) RemoveMember(todoID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	groupID, err := s.membership(tx, todoID, userID)
+	if err != nil {
+		return err
+	}
+	if groupID.Valid {
+		return ErrGroupAccess
+	}
+
+	// Mitgliedschaft beenden, besteht zusätzlich Zugriff über eine Gruppe, wird der Benutzer darüber wieder aufgenommen
+	if err = s.removeMember(tx, todoID, userID); err != nil {
+		return err
+	}
+	if err = s.syncGroupAccess(tx, todoID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) SetTodoStatus(id int, completed bool) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	current, err := s.ownerTodo(tx, id)
+	if err != nil {
+		return 0, err
+	}
+	nextID, err := s.setTodoStatus(tx, current, completed)
+	if err != nil {
+		return 0, err
+	}
+
+	return nextID, tx.Commit()
+}
+
+// Liest eine ToDo aus Sicht des Eigentümers, seine Kategorie, Liste und Tags übernimmt ein nächster Termin
+func (s *SQLStore) ownerTodo(tx *sql.Tx, id int) (models.ToDo, error) {
+	var ownerID int
+	err := tx.QueryRow(s.q("SELECT user_id FROM todos WHERE id = ?"), id).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return models.ToDo{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ToDo{}, err
+	}
+	return s.getTodo(tx, id, ownerID)
+}
+
+// Setzt den Status einer ToDo für alle Mitglieder. Wird ein wiederkehrender Termin erledigt, folgt der nächste, dessen ID zurückgegeben wird.
+func (s *SQLStore) setTodoStatus(tx *sql.Tx, current models.ToDo, completed bool) (int, error) {
+	if completed == current.Completed {
+		return 0, nil
+	}
+	_, err := tx.Exec(s.q("UPDATE todos SET completed = ?, updated_at = ? WHERE id = ?"), completed, time.Now().UTC(), current.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if completed && current.Recurrence != nil {
+		return s.createNextOccurrence(tx, current)
+	}
+	return 0, nil
+}
+
+func (s *SQLStore) GetTodoMembers(todoID int) ([]models.TodoMember, error) {
+	rows, err := s.db.Query(s.q("SELECT todo_members.user_id, COALESCE(users.display_name, ''), todo_members.permission, todo_members.created_at, todo_members.group_id FROM todo_members "+
+		"LEFT JOIN users ON users.id = todo_members.user_id WHERE todo_members.todo_id = ? ORDER BY todo_members.created_at, todo_members.user_id"), todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.TodoMember{}
+	for rows.Next() {
+		var member models.TodoMember
+		var groupID sql.NullInt64
+		if err := rows.Scan(&member.UserID, &member.DisplayName, &member.Permission, &member.SharedAt, &groupID); err != nil {
+			return nil, err
+		}
+		member.GroupID = nullIntPtr(groupID)
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
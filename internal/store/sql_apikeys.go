@@ -0,0 +1,175 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Spaltenliste für die Anzeige eines API Keys (Reihenfolge wie in scanAPIKey)
+const apiKeyColumns = "id, name, scopes, created_at, last_used_at, expires_at, revoked_at"
+
+// Bedingung für Keys mit Scope admin (Spalte scopes ist durch Leerzeichen getrennt)
+const adminScopeCondition = "(' ' || scopes || ' ') LIKE '% " + auth.ScopeAdmin + " %'"
+
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &scopes, &key.CreatedAt, &lastUsedAt, &expiresAt, &revokedAt)
+	key.Scopes = strings.Fields(scopes)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return key, err
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Wert für eine optionale Zeitspalte, gespeichert in UTC
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func nullIntPtr(i sql.NullInt64) *int {
+	if !i.Valid {
+		return nil
+	}
+	value := int(i.Int64)
+	return &value
+}
+
+// Wert für eine optionale Spalte mit einer ID
+func intValue(i *int) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
+
+func (s *SQLStore) insertAPIKey(q queryer, userID int, key models.APIKey, hash, lookup string) (int, error) {
+	var id int
+	err := q.QueryRow(s.q("INSERT INTO api_keys (user_id, name, scopes, key_hash, key_lookup, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id"),
+		userID, key.Name, strings.Join(key.Scopes, " "), hash, lookup, key.CreatedAt, timeValue(key.ExpiresAt)).Scan(&id)
+	return id, err
+}
+
+// Kandidaten sind aktive Keys (nicht widerrufen, nicht abgelaufen) mit passendem Lookup sowie Keys, die noch ohne
+// Lookup gespeichert sind: Klartext-Keys aus älteren Datenbanken (gleicher Wert) und bereits gehashte Keys (bcrypt, beginnen mit $2)
+func (s *SQLStore) FindCredentials(lookup, secretKey string) ([]Credential, error) {
+	rows, err := s.db.Query(s.q("SELECT id, user_id, key_hash, key_lookup, scopes FROM api_keys "+
+		"WHERE (key_lookup = ? OR (key_lookup = '' AND (key_hash = ? OR key_hash LIKE '$2%'))) "+
+		"AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)"), lookup, secretKey, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []Credential{}
+	for rows.Next() {
+		var c Credential
+		var scopes string
+		if err := rows.Scan(&c.KeyID, &c.UserID, &c.Hash, &c.Lookup, &scopes); err != nil {
+			return nil, err
+		}
+		c.Scopes = strings.Fields(scopes)
+		credentials = append(credentials, c)
+	}
+	return credentials, rows.Err()
+}
+
+func (s *SQLStore) UpdateAPIKeyHash(keyID int, hash, lookup string) error {
+	_, err := s.db.Exec(s.q("UPDATE api_keys SET key_hash = ?, key_lookup = ? WHERE id = ?"), hash, lookup, keyID)
+	return err
+}
+
+func (s *SQLStore) MarkAPIKeyUsed(keyID int) error {
+	_, err := s.db.Exec(s.q("UPDATE api_keys SET last_used_at = ? WHERE id = ?"), time.Now().UTC(), keyID)
+	return err
+}
+
+func (s *SQLStore) APIKeyActive(userID, keyID int) (bool, error) {
+	var active bool
+	err := s.db.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM api_keys WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?))"),
+		keyID, userID, time.Now().UTC()).Scan(&active)
+	return active, err
+}
+
+func (s *SQLStore) CreateAPIKey(userID int, key *models.APIKey, hash, lookup string) error {
+	key.CreatedAt = time.Now().UTC()
+	id, err := s.insertAPIKey(s.db, userID, *key, hash, lookup)
+	key.ID = id
+	return err
+}
+
+func (s *SQLStore) GetAPIKeysByUser(userID int) ([]models.APIKey, error) {
+	rows, err := s.db.Query(s.q("SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = ? ORDER BY id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLStore) RevokeAPIKey(userID, keyID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	var active bool
+	err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM api_keys WHERE id = ? AND user_id = ? AND revoked_at IS NULL)"), keyID, userID).Scan(&active)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrNotFound
+	}
+
+	// Mindestens ein weiterer gültiger Key mit Scope admin muss bleiben, sonst kann der Benutzer seine Keys nicht mehr verwalten.
+	// Benutzer mit einer Identität des OIDC Providers melden sich dort mit allen Berechtigungen an und brauchen keinen Key.
+	var others int
+	err = tx.QueryRow(s.q("SELECT COUNT(*) FROM api_keys WHERE user_id = ? AND id != ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?) AND "+adminScopeCondition), userID, keyID, now).Scan(&others)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		var linked bool
+		err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM user_identities WHERE user_id = ?)"), userID).Scan(&linked)
+		if err != nil {
+			return err
+		}
+		if !linked {
+			return ErrLastAPIKey
+		}
+	}
+
+	_, err = tx.Exec(s.q("UPDATE api_keys SET revoked_at = ? WHERE id = ?"), now, keyID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
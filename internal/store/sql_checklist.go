@@ -0,0 +1,191 @@
+package store
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+func scanChecklistItem(row scanner) (models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	err := row.Scan(&item.ID, &item.TodoID, &item.Title, &item.Completed, &item.Order, &item.CreatedAt)
+	return item, err
+}
+
+// Liest einen Punkt der Checkliste, ErrNotFound wenn er nicht zur ToDo gehört
+func (s *SQLStore) checklistItem(q queryer, todoID, itemID int) (models.ChecklistItem, error) {
+	item, err := scanChecklistItem(q.QueryRow(s.q("SELECT id, todo_id, title, completed, `order`, created_at FROM checklist_items WHERE id = ? AND todo_id = ?"), itemID, todoID))
+	if err == sql.ErrNoRows {
+		return item, ErrNotFound
+	}
+	return item, err
+}
+
+// Verschiebt die Positionen from bis to (einschließlich) in der Checkliste einer ToDo um delta
+func (s *SQLStore) shiftChecklistOrders(tx *sql.Tx, todoID, from, to, delta int) error {
+	_, err := tx.Exec(s.q("UPDATE checklist_items SET `order` = `order` + ? WHERE todo_id = ? AND `order` >= ? AND `order` <= ?"), delta, todoID, from, to)
+	return err
+}
+
+// Setzt den Status einer ToDo mit auto_complete auf erledigt, wenn alle Punkte ihrer Checkliste erledigt sind, sonst auf offen.
+// ToDos ohne auto_complete oder ohne Punkte bleiben unverändert, ein erledigter wiederkehrender Termin legt den nächsten an.
+func (s *SQLStore) syncChecklistStatus(tx *sql.Tx, todoID int) error {
+	current, err := s.ownerTodo(tx, todoID)
+	if err != nil {
+		return err
+	}
+	if !*current.AutoComplete || current.Progress == nil {
+		return nil
+	}
+	_, err = s.setTodoStatus(tx, current, current.Progress.Done == current.Progress.Total)
+	return err
+}
+
+func (s *SQLStore) GetChecklist(todoID int) ([]models.ChecklistItem, error) {
+	rows, err := s.db.Query(s.q("SELECT id, todo_id, title, completed, `order`, created_at FROM checklist_items WHERE todo_id = ? ORDER BY `order`"), todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) AddChecklistItem(item *models.ChecklistItem) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(s.q("SELECT COALESCE(MAX(`order`), 0) + 1 FROM checklist_items WHERE todo_id = ?"), item.TodoID).Scan(&item.Order)
+	if err != nil {
+		return err
+	}
+	item.Completed = false
+	item.CreatedAt = time.Now().UTC()
+	err = tx.QueryRow(s.q("INSERT INTO checklist_items (todo_id, title, completed, `order`, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id"),
+		item.TodoID, item.Title, item.Completed, item.Order, item.CreatedAt).Scan(&item.ID)
+	if err != nil {
+		return err
+	}
+
+	// Ein neuer offener Punkt öffnet eine automatisch erledigte ToDo wieder
+	if err = s.syncChecklistStatus(tx, item.TodoID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) UpdateChecklistItem(todoID, itemID int, changes models.ChecklistItem) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.checklistItem(tx, todoID, itemID)
+	if err != nil {
+		return err
+	}
+	if changes.Title == "" && changes.Order == 0 {
+		return ErrNoChanges
+	}
+
+	// Bei einer neuen Position rücken die Punkte dazwischen um eine Stelle
+	if changes.Order != 0 {
+		var count int
+		err = tx.QueryRow(s.q("SELECT COUNT(*) FROM checklist_items WHERE todo_id = ?"), todoID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if changes.Order < 1 || changes.Order > count {
+			return ErrOrderOutOfRange
+		}
+		if changes.Order == current.Order {
+			return ErrOrderUnchanged
+		}
+
+		if changes.Order > current.Order {
+			err = s.shiftChecklistOrders(tx, todoID, current.Order+1, changes.Order, -1)
+		} else {
+			err = s.shiftChecklistOrders(tx, todoID, changes.Order, current.Order-1, 1)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.q("UPDATE checklist_items SET `order` = ? WHERE id = ?"), changes.Order, itemID)
+		if err != nil {
+			return err
+		}
+	}
+	if changes.Title != "" {
+		_, err = tx.Exec(s.q("UPDATE checklist_items SET title = ? WHERE id = ?"), changes.Title, itemID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) SetChecklistItemStatus(todoID, itemID int, completed bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(s.q("UPDATE checklist_items SET completed = ? WHERE id = ? AND todo_id = ?"), completed, itemID, todoID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	if err = s.syncChecklistStatus(tx, todoID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) DeleteChecklistItem(todoID, itemID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.checklistItem(tx, todoID, itemID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM checklist_items WHERE id = ?"), itemID)
+	if err != nil {
+		return err
+	}
+	if err = s.shiftChecklistOrders(tx, todoID, current.Order+1, math.MaxInt32, -1); err != nil {
+		return err
+	}
+
+	// Fehlt nur noch der gelöschte offene Punkt, gilt die ToDo als erledigt
+	if err = s.syncChecklistStatus(tx, todoID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Spalten für Abfragen, die eine vollständige Einladung in eine Gruppe einlesen (Reihenfolge wie in scanGroupInvitation)
+const groupInvitationQuery = "SELECT group_invitations.id, group_invitations.group_id, groups.name, group_invitations.user_id, " +
+	"group_invitations.role, group_invitations.created_at FROM group_invitations JOIN groups ON groups.id = group_invitations.group_id"
+
+func scanGroupInvitation(row scanner) (models.GroupInvitation, error) {
+	var invitation models.GroupInvitation
+	err := row.Scan(&invitation.ID, &invitation.GroupID, &invitation.GroupName, &invitation.UserID, &invitation.Role, &invitation.CreatedAt)
+	return invitation, err
+}
+
+func (s *SQLStore) queryGroupInvitations(condition string, arg interface{}) ([]models.GroupInvitation, error) {
+	rows, err := s.db.Query(s.q(groupInvitationQuery+" WHERE "+condition+" ORDER BY group_invitations.created_at, group_invitations.id"), arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.GroupInvitation{}
+	for rows.Next() {
+		invitation, err := scanGroupInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+func (s *SQLStore) CreateGroupInvitation(invitation *models.GroupInvitation) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var member, open bool
+	err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?), EXISTS(SELECT 1 FROM group_invitations WHERE group_id = ? AND user_id = ?)"),
+		invitation.GroupID, invitation.UserID, invitation.GroupID, invitation.UserID).Scan(&member, &open)
+	if err != nil {
+		return err
+	}
+	if member {
+		return ErrAlreadyMember
+	}
+	if open {
+		return ErrInvitationOpen
+	}
+
+	invitation.CreatedAt = time.Now().UTC()
+	err = tx.QueryRow(s.q("INSERT INTO group_invitations (group_id, user_id, role, created_at) VALUES (?, ?, ?, ?) RETURNING id"),
+		invitation.GroupID, invitation.UserID, invitation.Role, invitation.CreatedAt).Scan(&invitation.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetGroupInvitation(id int) (models.GroupInvitation, error) {
+	invitation, err := scanGroupInvitation(s.db.QueryRow(s.q(groupInvitationQuery+" WHERE group_invitations.id = ?"), id))
+	if err == sql.ErrNoRows {
+		return invitation, ErrNotFound
+	}
+	return invitation, err
+}
+
+func (s *SQLStore) GetGroupInvitationsByUser(userID int) ([]models.GroupInvitation, error) {
+	return s.queryGroupInvitations("group_invitations.user_id = ?", userID)
+}
+
+func (s *SQLStore) GetGroupInvitationsByGroup(groupID int) ([]models.GroupInvitation, error) {
+	return s.queryGroupInvitations("group_invitations.group_id = ?", groupID)
+}
+
+func (s *SQLStore) AcceptGroupInvitation(id int) (models.Group, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Group{}, err
+	}
+	defer tx.Rollback()
+
+	invitation, err := scanGroupInvitation(tx.QueryRow(s.q(groupInvitationQuery+" WHERE group_invitations.id = ?"), id))
+	if err == sql.ErrNoRows {
+		return models.Group{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	// Einladung einlösen, erst jetzt erhält der Empfänger Zugriff auf die Freigaben der Gruppe
+	_, err = tx.Exec(s.q("DELETE FROM group_invitations WHERE id = ?"), id)
+	if err != nil {
+		return models.Group{}, err
+	}
+	_, err = tx.Exec(s.q("INSERT INTO group_members (group_id, user_id, role, created_at) VALUES (?, ?, ?, ?)"), invitation.GroupID, invitation.UserID, invitation.Role, time.Now().UTC())
+	if err != nil {
+		return models.Group{}, err
+	}
+	if err = s.syncGroup(tx, invitation.GroupID); err != nil {
+		return models.Group{}, err
+	}
+
+	group := models.Group{ID: invitation.GroupID, Name: invitation.GroupName, Role: invitation.Role}
+	err = tx.QueryRow(s.q("SELECT created_at FROM groups WHERE id = ?"), group.ID).Scan(&group.CreatedAt)
+	if err != nil {
+		return models.Group{}, err
+	}
+	return group, tx.Commit()
+}
+
+func (s *SQLStore) DeleteGroupInvitation(id int) error {
+	result, err := s.db.Exec(s.q("DELETE FROM group_invitations WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
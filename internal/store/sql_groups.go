@@ -0,0 +1,422 @@
+package store
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+const groupShareColumns = "id, group_id, owner_id, todo_id, list_id, permission, created_at"
+
+// Reihenfolge der Rollen in Mitgliederlisten: Eigentümer, Admins, Mitglieder
+const groupRoleOrder = "CASE role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END"
+
+func scanGroupShare(row scanner) (models.GroupShare, error) {
+	var share models.GroupShare
+	var todoID, listID sql.NullInt64
+	err := row.Scan(&share.ID, &share.GroupID, &share.OwnerID, &todoID, &listID, &share.Permission, &share.CreatedAt)
+	share.TodoID = nullIntPtr(todoID)
+	share.ListID = nullIntPtr(listID)
+	return share, err
+}
+
+// Gleicht die Mitgliedschaften einer ToDo, die über Gruppen bestehen, mit den Freigaben für Gruppen ab: Mitglieder
+// der Gruppen ohne Zugriff werden aufgenommen, Mitgliedschaften ohne passende Freigabe entfernt. Direkte Freigaben
+// bleiben unverändert und haben Vorrang. Gilt eine ToDo über mehrere Gruppen, zählt die höchste Berechtigung.
+func (s *SQLStore) syncGroupAccess(tx *sql.Tx, todoID int) error {
+	var ownerID, listID int
+	err := tx.QueryRow(s.q("SELECT user_id, list_id FROM todos WHERE id = ?"), todoID).Scan(&ownerID, &listID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Soll: Mitglieder aller Gruppen, mit denen die ToDo selbst oder ihre Liste geteilt ist
+	type grant struct {
+		groupID    int
+		permission models.SharePermission
+	}
+	wanted := map[int]grant{}
+	rows, err := tx.Query(s.q("SELECT group_members.user_id, group_shares.group_id, group_shares.permission FROM group_shares "+
+		"JOIN group_members ON group_members.group_id = group_shares.group_id "+
+		"WHERE (group_shares.todo_id = ? OR (group_shares.todo_id IS NULL AND group_shares.owner_id = ? AND group_shares.list_id = ?)) "+
+		"AND group_members.user_id != ? ORDER BY group_shares.id"), todoID, ownerID, listID, ownerID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var userID int
+		var g grant
+		if err := rows.Scan(&userID, &g.groupID, &g.permission); err != nil {
+			rows.Close()
+			return err
+		}
+		if current, ok := wanted[userID]; !ok || !current.permission.Allows(g.permission) {
+			wanted[userID] = g
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// Ist: bestehende Mitgliedschaften
+	type member struct {
+		userID     int
+		groupID    sql.NullInt64
+		permission models.SharePermission
+	}
+	var members []member
+	rows, err = tx.Query(s.q("SELECT user_id, group_id, permission FROM todo_members WHERE todo_id = ? ORDER BY user_id"), todoID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var m member
+		if err := rows.Scan(&m.userID, &m.groupID, &m.permission); err != nil {
+			rows.Close()
+			return err
+		}
+		members = append(members, m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		g, ok := wanted[m.userID]
+		delete(wanted, m.userID)
+		switch {
+		case !m.groupID.Valid: // direkte Freigabe
+		case !ok:
+			err = s.removeMember(tx, todoID, m.userID)
+		case int(m.groupID.Int64) != g.groupID || m.permission != g.permission:
+			_, err = tx.Exec(s.q("UPDATE todo_members SET group_id = ?, permission = ? WHERE todo_id = ? AND user_id = ?"), g.groupID, g.permission, todoID, m.userID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Neue Mitglieder in fester Reihenfolge, eine offene Einladung ist damit hinfällig
+	userIDs := []int{}
+	for userID := range wanted {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Ints(userIDs)
+	for _, userID := range userIDs {
+		g := wanted[userID]
+		_, err = tx.Exec(s.q("DELETE FROM share_invitations WHERE todo_id = ? AND user_id = ?"), todoID, userID)
+		if err != nil {
+			return err
+		}
+		groupID := g.groupID
+		if err = s.addMember(tx, todoID, userID, g.permission, &groupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gleicht alle ToDos ab, die mit der Gruppe geteilt sind oder Mitglieder über sie haben
+func (s *SQLStore) syncGroup(tx *sql.Tx, groupID int) error {
+	rows, err := tx.Query(s.q("SELECT id FROM todos WHERE id IN (SELECT todo_id FROM todo_members WHERE group_id = ?) "+
+		"OR id IN (SELECT todo_id FROM group_shares WHERE group_id = ?) "+
+		"OR EXISTS (SELECT 1 FROM group_shares WHERE group_shares.group_id = ? AND group_shares.todo_id IS NULL "+
+		"AND group_shares.owner_id = todos.user_id AND group_shares.list_id = todos.list_id) ORDER BY id"), groupID, groupID, groupID)
+	if err != nil {
+		return err
+	}
+	var todoIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		todoIDs = append(todoIDs, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range todoIDs {
+		if err = s.syncGroupAccess(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) CreateGroup(group *models.Group, ownerID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	group.CreatedAt = time.Now().UTC()
+	group.Role = models.GroupRoleOwner
+	err = tx.QueryRow(s.q("INSERT INTO groups (name, created_at) VALUES (?, ?) RETURNING id"), group.Name, group.CreatedAt).Scan(&group.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("INSERT INTO group_members (group_id, user_id, role, created_at) VALUES (?, ?, ?, ?)"), group.ID, ownerID, group.Role, group.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetGroup(id int) (models.Group, error) {
+	var group models.Group
+	err := s.db.QueryRow(s.q("SELECT id, name, created_at FROM groups WHERE id = ?"), id).Scan(&group.ID, &group.Name, &group.CreatedAt)
+	if err == sql.ErrNoRows {
+		return group, ErrNotFound
+	}
+	if err != nil {
+		return group, err
+	}
+
+	rows, err := s.db.Query(s.q("SELECT group_members.user_id, COALESCE(users.display_name, ''), group_members.role, group_members.created_at FROM group_members "+
+		"LEFT JOIN users ON users.id = group_members.user_id WHERE group_members.group_id = ? ORDER BY "+groupRoleOrder+", group_members.created_at, group_members.user_id"), id)
+	if err != nil {
+		return group, err
+	}
+	defer rows.Close()
+
+	group.Members = []models.GroupMember{}
+	for rows.Next() {
+		var member models.GroupMember
+		if err := rows.Scan(&member.UserID, &member.DisplayName, &member.Role, &member.JoinedAt); err != nil {
+			return group, err
+		}
+		group.Members = append(group.Members, member)
+	}
+	return group, rows.Err()
+}
+
+func (s *SQLStore) GetGroupsByUser(userID int) ([]models.Group, error) {
+	rows, err := s.db.Query(s.q("SELECT groups.id, groups.name, group_members.role, groups.created_at FROM groups "+
+		"JOIN group_members ON group_members.group_id = groups.id WHERE group_members.user_id = ? ORDER BY groups.name, groups.id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		var group models.Group
+		if err := rows.Scan(&group.ID, &group.Name, &group.Role, &group.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func (s *SQLStore) RenameGroup(id int, name string) error {
+	result, err := s.db.Exec(s.q("UPDATE groups SET name = ? WHERE id = ?"), name, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteGroup(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(s.q("DELETE FROM groups WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	// Freigaben, Einladungen und Mitglieder entfernen, danach verlieren die Mitglieder den Zugriff über die Gruppe
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE group_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_invitations WHERE group_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_members WHERE group_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	if err = s.syncGroup(tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetGroupRole(groupID, userID int) (models.GroupRole, error) {
+	var role models.GroupRole
+	err := s.db.QueryRow(s.q("SELECT role FROM group_members WHERE group_id = ? AND user_id = ?"), groupID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return role, err
+}
+
+func (s *SQLStore) SetGroupRole(groupID, userID int, role models.GroupRole) error {
+	result, err := s.db.Exec(s.q("UPDATE group_members SET role = ? WHERE group_id = ? AND user_id = ?"), role, groupID, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) RemoveGroupMember(groupID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(s.q("DELETE FROM group_members WHERE group_id = ? AND user_id = ?"), groupID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	// Seine Freigaben für die Gruppe enden mit der Mitgliedschaft
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE group_id = ? AND owner_id = ?"), groupID, userID)
+	if err != nil {
+		return err
+	}
+	if err = s.syncGroup(tx, groupID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) CreateGroupShare(share *models.GroupShare) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Eine ToDo bzw. Liste wird je Gruppe nur einmal geteilt
+	var exists bool
+	if share.TodoID != nil {
+		share.ListID = nil
+		err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM group_shares WHERE group_id = ? AND todo_id = ?)"), share.GroupID, *share.TodoID).Scan(&exists)
+	} else {
+		err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM group_shares WHERE group_id = ? AND list_id = ?)"), share.GroupID, intValue(share.ListID)).Scan(&exists)
+	}
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrAlreadyShared
+	}
+
+	share.CreatedAt = time.Now().UTC()
+	err = tx.QueryRow(s.q("INSERT INTO group_shares (group_id, owner_id, todo_id, list_id, permission, created_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id"),
+		share.GroupID, share.OwnerID, intValue(share.TodoID), intValue(share.ListID), share.Permission, share.CreatedAt).Scan(&share.ID)
+	if err != nil {
+		return err
+	}
+	if err = s.syncGroup(tx, share.GroupID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetGroupShare(id int) (models.GroupShare, error) {
+	share, err := scanGroupShare(s.db.QueryRow(s.q("SELECT "+groupShareColumns+" FROM group_shares WHERE id = ?"), id))
+	if err == sql.ErrNoRows {
+		return share, ErrNotFound
+	}
+	return share, err
+}
+
+func (s *SQLStore) GetGroupShares(groupID int) ([]models.GroupShare, error) {
+	rows, err := s.db.Query(s.q("SELECT "+groupShareColumns+" FROM group_shares WHERE group_id = ? ORDER BY created_at, id"), groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []models.GroupShare{}
+	for rows.Next() {
+		share, err := scanGroupShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (s *SQLStore) DeleteGroupShare(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var groupID int
+	err = tx.QueryRow(s.q("SELECT group_id FROM group_shares WHERE id = ?"), id).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	if err = s.syncGroup(tx, groupID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
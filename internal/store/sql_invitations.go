@@ -0,0 +1,133 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Spalten für Abfragen, die eine vollständige Einladung einlesen (Reihenfolge wie in scanInvitation)
+const invitationQuery = "SELECT share_invitations.id, share_invitations.todo_id, todos.title, todos.user_id, COALESCE(users.display_name, ''), " +
+	"share_invitations.user_id, share_invitations.permission, share_invitations.created_at FROM share_invitations " +
+	"JOIN todos ON todos.id = share_invitations.todo_id LEFT JOIN users ON users.id = todos.user_id"
+
+func scanInvitation(row scanner) (models.Invitation, error) {
+	var invitation models.Invitation
+	err := row.Scan(&invitation.ID, &invitation.TodoID, &invitation.TodoTitle, &invitation.OwnerID, &invitation.OwnerName,
+		&invitation.UserID, &invitation.Permission, &invitation.CreatedAt)
+	return invitation, err
+}
+
+func (s *SQLStore) queryInvitations(condition string, arg interface{}) ([]models.Invitation, error) {
+	rows, err := s.db.Query(s.q(invitationQuery+" WHERE "+condition+" ORDER BY share_invitations.created_at, share_invitations.id"), arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+func (s *SQLStore) CreateInvitation(invitation *models.Invitation) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var member, open bool
+	err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM todo_members WHERE todo_id = ? AND user_id = ?), EXISTS(SELECT 1 FROM share_invitations WHERE todo_id = ? AND user_id = ?)"),
+		invitation.TodoID, invitation.UserID, invitation.TodoID, invitation.UserID).Scan(&member, &open)
+	if err != nil {
+		return err
+	}
+	if member {
+		return ErrAlreadyMember
+	}
+	if open {
+		return ErrInvitationOpen
+	}
+
+	invitation.CreatedAt = time.Now().UTC()
+	err = tx.QueryRow(s.q("INSERT INTO share_invitations (todo_id, user_id, permission, created_at) VALUES (?, ?, ?, ?) RETURNING id"),
+		invitation.TodoID, invitation.UserID, invitation.Permission, invitation.CreatedAt).Scan(&invitation.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetInvitation(id int) (models.Invitation, error) {
+	invitation, err := scanInvitation(s.db.QueryRow(s.q(invitationQuery+" WHERE share_invitations.id = ?"), id))
+	if err == sql.ErrNoRows {
+		return invitation, ErrNotFound
+	}
+	return invitation, err
+}
+
+func (s *SQLStore) GetInvitationsByUser(userID int) ([]models.Invitation, error) {
+	return s.queryInvitations("share_invitations.user_id = ?", userID)
+}
+
+func (s *SQLStore) GetInvitationsByTodo(todoID int) ([]models.Invitation, error) {
+	return s.queryInvitations("share_invitations.todo_id = ?", todoID)
+}
+
+func (s *SQLStore) AcceptInvitation(id int) (models.ToDo, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.ToDo{}, err
+	}
+	defer tx.Rollback()
+
+	var todoID, userID int
+	var permission models.SharePermission
+	err = tx.QueryRow(s.q("SELECT todo_id, user_id, permission FROM share_invitations WHERE id = ?"), id).Scan(&todoID, &userID, &permission)
+	if err == sql.ErrNoRows {
+		return models.ToDo{}, ErrNotFound
+	}
+	if err != nil {
+		return models.ToDo{}, err
+	}
+
+	// Einladung einlösen und den Empfänger als Mitglied aufnehmen
+	_, err = tx.Exec(s.q("DELETE FROM share_invitations WHERE id = ?"), id)
+	if err != nil {
+		return models.ToDo{}, err
+	}
+	if err = s.addMember(tx, todoID, userID, permission, nil); err != nil {
+		return models.ToDo{}, err
+	}
+
+	todo, err := s.getTodo(tx, todoID, userID)
+	if err != nil {
+		return models.ToDo{}, err
+	}
+	return todo, tx.Commit()
+}
+
+func (s *SQLStore) DeleteInvitation(id int) error {
+	result, err := s.db.Exec(s.q("DELETE FROM share_invitations WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
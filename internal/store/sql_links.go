@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+const shareLinkColumns = "id, todo_id, filter, created_at, expires_at, revoked_at"
+
+// Liest eine Zeile mit den Spalten aus shareLinkColumns ein, extra nimmt weitere Spalten dahinter auf
+func scanShareLink(row scanner, extra ...interface{}) (models.ShareLink, error) {
+	var link models.ShareLink
+	var todoID sql.NullInt64
+	var expiresAt, revokedAt sql.NullTime
+	err := row.Scan(append([]interface{}{&link.ID, &todoID, &link.Filter, &link.CreatedAt, &expiresAt, &revokedAt}, extra...)...)
+	link.TodoID = nullIntPtr(todoID)
+	link.ExpiresAt = nullTimePtr(expiresAt)
+	link.RevokedAt = nullTimePtr(revokedAt)
+	return link, err
+}
+
+func (s *SQLStore) CreateShareLink(userID int, link *models.ShareLink, hash string) error {
+	link.CreatedAt = time.Now().UTC()
+	return s.db.QueryRow(s.q("INSERT INTO share_links (user_id, todo_id, filter, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id"),
+		userID, intValue(link.TodoID), link.Filter, hash, link.CreatedAt, timeValue(link.ExpiresAt)).Scan(&link.ID)
+}
+
+func (s *SQLStore) GetShareLinksByUser(userID int) ([]models.ShareLink, error) {
+	rows, err := s.db.Query(s.q("SELECT "+shareLinkColumns+" FROM share_links WHERE user_id = ? ORDER BY id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.ShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (s *SQLStore) RevokeShareLink(userID, linkID int) error {
+	result, err := s.db.Exec(s.q("UPDATE share_links SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL"), time.Now().UTC(), linkID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) ResolveShareLink(hash string) (models.ShareLink, int, error) {
+	var userID int
+	link, err := scanShareLink(s.db.QueryRow(s.q("SELECT "+shareLinkColumns+", user_id FROM share_links WHERE token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)"),
+		hash, time.Now().UTC()), &userID)
+	if err == sql.ErrNoRows {
+		return models.ShareLink{}, 0, ErrNotFound
+	}
+	return link, userID, err
+}
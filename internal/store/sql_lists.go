@@ -0,0 +1,129 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Liste mit Anzahl der ToDos ihres Benutzers, eigene und geteilte, die Platzhalter sind die Bedingungen auf lists
+const listSelect = "SELECT lists.id, lists.name, lists.created_at, " +
+	"(SELECT COUNT(*) FROM todos WHERE todos.user_id = lists.user_id AND todos.list_id = lists.id) + " +
+	"(SELECT COUNT(*) FROM todo_members WHERE todo_members.user_id = lists.user_id AND todo_members.list_id = lists.id) FROM lists"
+
+func scanList(row scanner) (models.List, error) {
+	var list models.List
+	err := row.Scan(&list.ID, &list.Name, &list.CreatedAt, &list.Count)
+	return list, err
+}
+
+func (s *SQLStore) CreateList(userID int, list *models.List) error {
+	list.CreatedAt = time.Now().UTC()
+	list.Count = 0
+	return s.db.QueryRow(s.q("INSERT INTO lists (user_id, name, created_at) VALUES (?, ?, ?) RETURNING id"), userID, list.Name, list.CreatedAt).Scan(&list.ID)
+}
+
+func (s *SQLStore) GetList(id, userID int) (models.List, error) {
+	list, err := scanList(s.db.QueryRow(s.q(listSelect+" WHERE lists.id = ? AND lists.user_id = ?"), id, userID))
+	if err == sql.ErrNoRows {
+		return list, ErrNotFound
+	}
+	return list, err
+}
+
+func (s *SQLStore) GetListsByUser(userID int) ([]models.List, error) {
+	rows, err := s.db.Query(s.q(listSelect+" WHERE lists.user_id = ? ORDER BY lists.created_at, lists.id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []models.List{}
+	for rows.Next() {
+		list, err := scanList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+func (s *SQLStore) RenameList(id, userID int, name string) error {
+	result, err := s.db.Exec(s.q("UPDATE lists SET name = ? WHERE id = ? AND user_id = ?"), name, id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteList(id, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM lists WHERE id = ? AND user_id = ?)"), id, userID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	// Die ToDos der Liste behalten ihre Reihenfolge und werden hinter den ToDos ohne Liste angehängt
+	next, err := s.nextOrder(tx, userID, 0)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"todos", "todo_members"} {
+		_, err = tx.Exec(s.q("UPDATE "+table+" SET list_id = 0, `order` = `order` + ? WHERE user_id = ? AND list_id = ?"), next-1, userID, id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(s.q("DELETE FROM lists WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	// Freigaben der Liste für Gruppen enden, deren Mitglieder verlieren den Zugriff über die Liste
+	var groupIDs []int
+	rows, err := tx.Query(s.q("SELECT group_id FROM group_shares WHERE list_id = ? ORDER BY group_id"), id)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var groupID int
+		if err := rows.Scan(&groupID); err != nil {
+			rows.Close()
+			return err
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE list_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	for _, groupID := range groupIDs {
+		if err = s.syncGroup(tx, groupID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
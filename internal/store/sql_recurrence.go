@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Legt den nächsten Termin einer wiederkehrenden ToDo an, die gerade erledigt wurde, und liefert dessen ID.
+// Der neue Termin übernimmt Inhalt, Tags, Checkliste (offen), Mitglieder und Freigaben für Gruppen und reiht sich beim
+// Eigentümer und bei jedem Mitglied am Ende seiner Liste ein. Die Wiederholung wandert vom erledigten auf den neuen
+// Termin, ein erneutes Öffnen und Erledigen erzeugt daher keinen weiteren.
+func (s *SQLStore) createNextOccurrence(tx *sql.Tx, current models.ToDo) (int, error) {
+	now := time.Now().UTC()
+	var due *time.Time
+	if current.DueAt != nil {
+		due = &current.DueAt.Time
+	}
+
+	next := current
+	next.ID = 0
+	next.Completed = false
+	next.DueAt = &models.DueTime{Time: current.Recurrence.Next(due, now)}
+	if err := s.insertTodo(tx, &next); err != nil {
+		return 0, err
+	}
+
+	_, err := tx.Exec(s.q("UPDATE todos SET recurrence = '' WHERE id = ?"), current.ID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(s.q("INSERT INTO todo_tags (todo_id, tag_id) SELECT ?, tag_id FROM todo_tags WHERE todo_id = ?"), next.ID, current.ID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(s.q("INSERT INTO checklist_items (todo_id, title, completed, `order`, created_at) SELECT ?, title, ?, `order`, ? FROM checklist_items WHERE todo_id = ?"),
+		next.ID, false, now, current.ID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(s.q("INSERT INTO group_shares (group_id, owner_id, todo_id, permission, created_at) "+
+		"SELECT group_id, owner_id, ?, permission, created_at FROM group_shares WHERE todo_id = ?"), next.ID, current.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Mitglieder behalten Berechtigung, Kategorie und Liste
+	type member struct {
+		userID, listID int
+		permission     models.SharePermission
+		category       string
+		createdAt      time.Time
+		groupID        sql.NullInt64
+	}
+	var members []member
+	rows, err := tx.Query(s.q("SELECT user_id, list_id, permission, category, created_at, group_id FROM todo_members WHERE todo_id = ? ORDER BY created_at, user_id"), current.ID)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var m member
+		if err := rows.Scan(&m.userID, &m.listID, &m.permission, &m.category, &m.createdAt, &m.groupID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		members = append(members, m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	for _, m := range members {
+		order, err := s.nextOrder(tx, m.userID, m.listID)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(s.q("INSERT INTO todo_members (todo_id, user_id, permission, category, `order`, created_at, group_id, list_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+			next.ID, m.userID, m.permission, m.category, order, m.createdAt, m.groupID, m.listID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Mitgliedschaften über Gruppen mit den übernommenen Freigaben abgleichen
+	if err = s.syncGroupAccess(tx, next.ID); err != nil {
+		return 0, err
+	}
+	return next.ID, nil
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Paul-frank/todo-api/internal/database"
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Ergänzt scanTodo um weitere Spalten hinter todoColumns
+type extraScanner struct {
+	scanner
+	extra []interface{}
+}
+
+func (e extraScanner) Scan(dest ...interface{}) error {
+	return e.scanner.Scan(append(dest, e.extra...)...)
+}
+
+// SQLite: FTS5 Index todos_fts, Rangfolge nach bm25 mit stärker gewichtetem Titel
+const sqliteSearchQuery = "SELECT " + todoColumns + ", title_match, description_match FROM " + todoView + " " +
+	"JOIN (SELECT rowid AS match_id, " +
+	"COALESCE(highlight(todos_fts, 0, '" + markStart + "', '" + markEnd + "'), '') AS title_match, " +
+	"COALESCE(snippet(todos_fts, 1, '" + markStart + "', '" + markEnd + "', '…', 12), '') AS description_match, " +
+	"bm25(todos_fts, 10.0, 1.0) AS score " +
+	"FROM todos_fts WHERE todos_fts MATCH ?) matches ON matches.match_id = todos.id " +
+	"WHERE " + visibleCondition + " ORDER BY score, id LIMIT ?"
+
+// PostgreSQL: generierte Spalte search_vector (zusätzlich in todoView), Rangfolge nach ts_rank
+const postgresSearchQuery = "SELECT " + todoColumns + ", " +
+	"ts_headline('simple', title, search_query, 'HighlightAll=true, StartSel=" + markStart + ", StopSel=" + markEnd + "'), " +
+	"ts_headline('simple', COALESCE(description, ''), search_query, 'MaxWords=12, MinWords=6, StartSel=" + markStart + ", StopSel=" + markEnd + "') " +
+	"FROM " + todoViewSelect + ", todos.search_vector" + todoViewFrom + ", to_tsquery('simple', ?) AS search_query " +
+	"WHERE " + visibleCondition + " AND search_vector @@ search_query ORDER BY ts_rank(search_vector, search_query) DESC, id LIMIT ?"
+
+func (s *SQLStore) SearchTodos(userID int, query string, limit int) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	var err error
+	if s.dialect.FullTextSearch {
+		results, err = s.searchFullText(userID, terms, limit)
+	} else {
+		results, err = s.searchLike(userID, terms, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags der Treffer nachladen
+	ids := make([]int, len(results))
+	for i := range results {
+		ids[i] = results[i].Todo.ID
+	}
+	tags, err := s.tagsForTodos(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Todo.Tags = tags[results[i].Todo.ID]
+		if results[i].Todo.Tags == nil {
+			results[i].Todo.Tags = []string{}
+		}
+	}
+
+	return results, nil
+}
+
+// Suche über den Volltextindex des Dialekts, die Datenbank bewertet und markiert die Treffer
+func (s *SQLStore) searchFullText(userID int, terms []string, limit int) ([]models.SearchResult, error) {
+	// Suchbegriffe als Präfixsuche in der Syntax des Dialekts, alle Begriffe müssen vorkommen
+	var match, searchQuery string
+	if s.dialect.Dialect == database.DialectPostgres {
+		match = strings.Join(terms, ":* & ") + ":*"
+		searchQuery = postgresSearchQuery
+	} else {
+		match = `"` + strings.Join(terms, `"* "`) + `"*`
+		searchQuery = sqliteSearchQuery
+	}
+
+	rows, err := s.db.Query(s.q(searchQuery), userID, match, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		result.Todo, err = scanTodo(extraScanner{rows, []interface{}{&result.Title, &result.Snippet}})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Suche ohne Volltextindex (SQLite-Treiber ohne FTS5): Vorauswahl per LIKE, Bewertung und Markierung wie im
+// MemoryStore. LIKE vergleicht nur ASCII ohne Rücksicht auf Groß- und Kleinschreibung, Begriffe mit Umlauten
+// werden daher erst bei der Bewertung geprüft.
+func (s *SQLStore) searchLike(userID int, terms []string, limit int) ([]models.SearchResult, error) {
+	query := "SELECT " + todoColumns + " FROM " + todoView + " WHERE " + visibleCondition
+	args := []interface{}{userID, userID}
+	for _, term := range terms {
+		if isASCII(term) {
+			query += " AND (title LIKE ? OR description LIKE ?)"
+			args = append(args, "%"+term+"%", "%"+term+"%")
+		}
+	}
+
+	rows, err := s.db.Query(s.q(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	scores := map[int]int{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		result, score := searchResult(todo, terms)
+		if score == 0 {
+			continue
+		}
+		scores[todo.ID] = score
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return rankResults(results, scores, limit), nil
+}
+
+func isASCII(text string) bool {
+	for _, r := range text {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Lädt die Tags des Benutzers an einer ToDo, alphabetisch sortiert
+func (s *SQLStore) tagsForTodo(q queryer, todoID, userID int) ([]string, error) {
+	rows, err := q.Query(s.q("SELECT tags.name FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id WHERE todo_tags.todo_id = ? AND tags.user_id = ? ORDER BY tags.name"), todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Lädt die Tags des Benutzers an den angegebenen ToDos in einer Abfrage, Schlüssel ist die TodoID
+func (s *SQLStore) tagsForTodos(userID int, todoIDs []int) (map[int][]string, error) {
+	tags := map[int][]string{}
+	if len(todoIDs) == 0 {
+		return tags, nil
+	}
+
+	args := []interface{}{userID}
+	for _, id := range todoIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.Query(s.q("SELECT todo_tags.todo_id, tags.name FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id "+
+		"WHERE tags.user_id = ? AND todo_tags.todo_id IN (?"+strings.Repeat(", ?", len(todoIDs)-1)+") ORDER BY tags.name"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var name string
+		if err := rows.Scan(&todoID, &name); err != nil {
+			return nil, err
+		}
+		tags[todoID] = append(tags[todoID], name)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLStore) AddTags(todoID, userID int, names []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM todos WHERE id = ?)"), todoID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	for _, name := range names {
+		// Tag des Benutzers anlegen falls noch nicht vorhanden, danach an die ToDo hängen
+		_, err = tx.Exec(s.q("INSERT INTO tags (user_id, name) VALUES (?, ?) ON CONFLICT (user_id, name) DO NOTHING"), userID, name)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.q("INSERT INTO todo_tags (todo_id, tag_id) SELECT ?, id FROM tags WHERE user_id = ? AND name = ? ON CONFLICT (todo_id, tag_id) DO NOTHING"), todoID, userID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) RemoveTag(todoID, userID int, name string) error {
+	result, err := s.db.Exec(s.q("DELETE FROM todo_tags WHERE todo_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ? AND name = ?)"), todoID, userID, name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) GetTagsByUser(userID int) ([]models.Tag, error) {
+	rows, err := s.db.Query(s.q("SELECT tags.id, tags.name, COUNT(todo_tags.todo_id) FROM tags LEFT JOIN todo_tags ON todo_tags.tag_id = tags.id WHERE tags.user_id = ? GROUP BY tags.id, tags.name ORDER BY tags.name"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLStore) RenameTag(userID int, oldName, newName string) (bool, error) {
+	if oldName == newName {
+		return false, ErrNoChanges
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var oldID int
+	err = tx.QueryRow(s.q("SELECT id FROM tags WHERE user_id = ? AND name = ?"), userID, oldName).Scan(&oldID)
+	if err == sql.ErrNoRows {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var newID int
+	err = tx.QueryRow(s.q("SELECT id FROM tags WHERE user_id = ? AND name = ?"), userID, newName).Scan(&newID)
+	if err == sql.ErrNoRows {
+		// Zielname noch frei -> einfach umbenennen
+		_, err = tx.Exec(s.q("UPDATE tags SET name = ? WHERE id = ?"), newName, oldID)
+		if err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Zielname existiert -> alle Zuordnungen übernehmen und den alten Tag löschen
+	_, err = tx.Exec(s.q("INSERT INTO todo_tags (todo_id, tag_id) SELECT todo_id, ? FROM todo_tags WHERE tag_id = ? ON CONFLICT (todo_id, tag_id) DO NOTHING"), newID, oldID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todo_tags WHERE tag_id = ?"), oldID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM tags WHERE id = ?"), oldID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,19 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Paul-frank/todo-api/internal/database"
+	"github.com/Paul-frank/todo-api/internal/store"
+	"github.com/Paul-frank/todo-api/internal/store/storetest"
+)
+
+// SQLStore auf einer frischen SQLite-Datei je Test
+func TestSQLStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		db := database.NewDatabase(filepath.Join(t.TempDir(), "todo_app_db.db"))
+		t.Cleanup(db.Close)
+		return store.NewSQLStore(db)
+	})
+}
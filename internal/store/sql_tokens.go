@@ -0,0 +1,67 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+func (s *SQLStore) CreateRefreshToken(keyID int, hash string, expiresAt time.Time) error {
+	_, err := s.db.Exec(s.q("INSERT INTO refresh_tokens (key_id, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?)"),
+		keyID, hash, time.Now().UTC(), expiresAt.UTC())
+	return err
+}
+
+func (s *SQLStore) UseRefreshToken(hash string) (Credential, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Credential{}, err
+	}
+	defer tx.Rollback()
+
+	// Nur gültige Tokens eines aktiven API Keys, ein widerrufener Key beendet auch seine Refresh Tokens
+	now := time.Now().UTC()
+	var tokenID int
+	var usedAt sql.NullTime
+	var scopes string
+	var c Credential
+	err = tx.QueryRow(s.q("SELECT refresh_tokens.id, refresh_tokens.used_at, api_keys.id, api_keys.user_id, api_keys.scopes "+
+		"FROM refresh_tokens JOIN api_keys ON api_keys.id = refresh_tokens.key_id "+
+		"WHERE refresh_tokens.token_hash = ? AND refresh_tokens.revoked_at IS NULL AND refresh_tokens.expires_at > ? "+
+		"AND api_keys.revoked_at IS NULL AND (api_keys.expires_at IS NULL OR api_keys.expires_at > ?)"), hash, now, now).
+		Scan(&tokenID, &usedAt, &c.KeyID, &c.UserID, &scopes)
+	if err == sql.ErrNoRows {
+		return Credential{}, ErrNotFound
+	}
+	if err != nil {
+		return Credential{}, err
+	}
+	c.Scopes = strings.Fields(scopes)
+
+	used := usedAt.Valid
+	if !used {
+		result, err := tx.Exec(s.q("UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL"), now, tokenID)
+		if err != nil {
+			return Credential{}, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return Credential{}, err
+		}
+		used = affected == 0 // gleichzeitig von einem anderen Request verwendet
+	}
+
+	// Ein bereits getauschter Token wurde vermutlich entwendet, daher alle Refresh Tokens des Keys widerrufen
+	if used {
+		_, err = tx.Exec(s.q("UPDATE refresh_tokens SET revoked_at = ? WHERE key_id = ? AND revoked_at IS NULL"), now, c.KeyID)
+		if err != nil {
+			return Credential{}, err
+		}
+		if err = tx.Commit(); err != nil {
+			return Credential{}, err
+		}
+		return Credential{}, ErrTokenReused
+	}
+
+	return c, tx.Commit()
+}
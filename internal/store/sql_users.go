@@ -0,0 +1,302 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Spaltenliste für das Profil eines Benutzers (Reihenfolge wie in scanUser)
+const userColumns = "id, display_name, COALESCE(email, ''), created_at"
+
+func scanUser(row scanner) (models.User, error) {
+	var user models.User
+	var createdAt sql.NullTime // NULL bei Benutzern, die von Hand in die Tabelle eingetragen wurden
+	err := row.Scan(&user.ID, &user.DisplayName, &user.Email, &createdAt)
+	user.CreatedAt = createdAt.Time
+	return user, err
+}
+
+// Wert für die Spalte email, leere E-Mail wird als NULL gespeichert (der eindeutige Index erlaubt mehrere NULL)
+func emailValue(email string) interface{} {
+	if email == "" {
+		return nil
+	}
+	return email
+}
+
+// Prüft ob die E-Mail bereits von einem anderen Benutzer verwendet wird
+func (s *SQLStore) emailTaken(q queryer, email string, exceptUserID int) (bool, error) {
+	var taken bool
+	err := q.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND id != ?)"), email, exceptUserID).Scan(&taken)
+	return taken, err
+}
+
+func (s *SQLStore) UserExists(id int) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(s.q("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)"), id).Scan(&exists)
+	return exists, err
+}
+
+// Legt den Benutzer an und setzt ID und CreatedAt, ErrEmailExists wenn die E-Mail bereits vergeben ist
+func (s *SQLStore) insertUser(tx *sql.Tx, user *models.User) error {
+	if user.Email != "" {
+		taken, err := s.emailTaken(tx, user.Email, 0)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrEmailExists
+		}
+	}
+
+	user.CreatedAt = time.Now().UTC()
+	return tx.QueryRow(s.q("INSERT INTO users (display_name, email, created_at) VALUES (?, ?, ?) RETURNING id"),
+		user.DisplayName, emailValue(user.Email), user.CreatedAt).Scan(&user.ID)
+}
+
+func (s *SQLStore) CreateUser(user *models.User, hash, lookup string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = s.insertUser(tx, user)
+	if err != nil {
+		return err
+	}
+
+	// Erster API Key des Benutzers
+	_, err = s.insertAPIKey(tx, user.ID, models.APIKey{Name: "default", Scopes: []string{auth.ScopeAdmin}, CreatedAt: user.CreatedAt}, hash, lookup)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) UserForIdentity(issuer, subject string) (int, error) {
+	var userID int
+	err := s.db.QueryRow(s.q("SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?"), issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return userID, err
+}
+
+func (s *SQLStore) CreateUserWithIdentity(user *models.User, issuer, subject string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = s.insertUser(tx, user)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(s.q("INSERT INTO user_identities (user_id, issuer, subject, created_at) VALUES (?, ?, ?, ?)"),
+		user.ID, issuer, subject, user.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetUser(id int) (models.User, error) {
+	user, err := scanUser(s.db.QueryRow(s.q("SELECT "+userColumns+" FROM users WHERE id = ?"), id))
+	if err == sql.ErrNoRows {
+		return user, ErrNotFound
+	}
+	return user, err
+}
+
+func (s *SQLStore) UpdateUser(id int, changes models.User) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Nur Felder übernehmen, die im Request gesetzt sind
+	assignments := []string{}
+	args := []interface{}{}
+	if changes.DisplayName != "" {
+		assignments = append(assignments, "display_name = ?")
+		args = append(args, changes.DisplayName)
+	}
+	if changes.Email != "" {
+		taken, err := s.emailTaken(tx, changes.Email, id)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrEmailExists
+		}
+		assignments = append(assignments, "email = ?")
+		args = append(args, changes.Email)
+	}
+	if len(assignments) == 0 {
+		return ErrNoChanges
+	}
+
+	args = append(args, id)
+	result, err := tx.Exec(s.q("UPDATE users SET "+strings.Join(assignments, ", ")+" WHERE id = ?"), args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) DeleteUser(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Mitgliedschaften, Einladungen (auch in Gruppen) und öffentliche Links des Benutzers beenden, auch die von ihm versendeten Einladungen
+	_, err = tx.Exec(s.q("DELETE FROM todo_members WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM share_invitations WHERE user_id = ? OR todo_id IN (SELECT id FROM todos WHERE user_id = ?)"), id, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_invitations WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM share_links WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	// Freigaben des Benutzers für Gruppen enden, deren Mitglieder verlieren den Zugriff auf seine ToDos
+	rows, err := tx.Query(s.q("SELECT todo_members.todo_id, todo_members.user_id FROM todo_members "+
+		"JOIN todos ON todos.id = todo_members.todo_id WHERE todos.user_id = ? AND todo_members.group_id IS NOT NULL"), id)
+	if err != nil {
+		return err
+	}
+	var groupMembers []struct{ todoID, userID int }
+	for rows.Next() {
+		var m struct{ todoID, userID int }
+		if err := rows.Scan(&m.todoID, &m.userID); err != nil {
+			rows.Close()
+			return err
+		}
+		groupMembers = append(groupMembers, m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for _, m := range groupMembers {
+		if err = s.removeMember(tx, m.todoID, m.userID); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE owner_id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	// Gruppen des Benutzers gehen an den ältesten Admin bzw. das älteste Mitglied über, Gruppen ohne weitere Mitglieder werden gelöscht
+	_, err = tx.Exec(s.q("UPDATE group_members SET role = 'owner' WHERE user_id = (SELECT heir.user_id FROM group_members heir WHERE heir.group_id = group_members.group_id AND heir.user_id != ? "+
+		"ORDER BY CASE heir.role WHEN 'admin' THEN 0 ELSE 1 END, heir.created_at, heir.user_id LIMIT 1) "+
+		"AND group_id IN (SELECT group_id FROM group_members WHERE user_id = ? AND role = 'owner')"), id, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_members WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_shares WHERE group_id NOT IN (SELECT group_id FROM group_members)"))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM group_invitations WHERE group_id NOT IN (SELECT group_id FROM group_members)"))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM groups WHERE id NOT IN (SELECT group_id FROM group_members)"))
+	if err != nil {
+		return err
+	}
+
+	// Geteilte ToDos des Benutzers gehen an das älteste (direkte) Mitglied über, mit dessen Kategorie, Liste und Position
+	const firstMember = "FROM todo_members WHERE todo_members.todo_id = todos.id ORDER BY todo_members.created_at, todo_members.user_id LIMIT 1"
+	_, err = tx.Exec(s.q("UPDATE todos SET user_id = (SELECT user_id "+firstMember+"), category = (SELECT category "+firstMember+"), list_id = (SELECT list_id "+firstMember+"), `order` = (SELECT `order` "+firstMember+") "+
+		"WHERE user_id = ? AND id IN (SELECT todo_id FROM todo_members)"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todo_members WHERE EXISTS (SELECT 1 FROM todos WHERE todos.id = todo_members.todo_id AND todos.user_id = todo_members.user_id)"))
+	if err != nil {
+		return err
+	}
+
+	// Eigene ToDos samt Checklisten, Listen, Tags, Identitäten, API Keys und Refresh Tokens löschen
+	_, err = tx.Exec(s.q("DELETE FROM todo_tags WHERE todo_id IN (SELECT id FROM todos WHERE user_id = ?) OR tag_id IN (SELECT id FROM tags WHERE user_id = ?)"), id, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM checklist_items WHERE todo_id IN (SELECT id FROM todos WHERE user_id = ?)"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM tags WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM lists WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM todos WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM user_identities WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM refresh_tokens WHERE key_id IN (SELECT id FROM api_keys WHERE user_id = ?)"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.q("DELETE FROM api_keys WHERE user_id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(s.q("DELETE FROM users WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/models"
+)
+
+// Fehler, die von allen Store-Implementierungen zurückgegeben werden
+var (
+	ErrNotFound        = errors.New("eintrag nicht gefunden")
+	ErrNoChanges       = errors.New("keine gültigen änderungen")
+	ErrOrderOutOfRange = errors.New("position außerhalb des gültigen bereichs")
+	ErrOrderUnchanged  = errors.New("position unverändert")
+	ErrEmailExists     = errors.New("e-mail bereits vergeben")
+	ErrLastAPIKey      = errors.New("letzter aktiver api key mit scope admin")
+	ErrTokenReused     = errors.New("refresh token bereits verwendet")
+	ErrAlreadyMember   = errors.New("benutzer ist bereits mitglied")
+	ErrInvitationOpen  = errors.New("einladung bereits offen")
+	ErrGroupAccess     = errors.New("zugriff besteht über eine gruppe")
+	ErrAlreadyShared   = errors.New("bereits mit der gruppe geteilt")
+)
+
+// Zugriff auf die ToDos
+type TodoStore interface {
+	GetTodo(id, userID int) (models.ToDo, error)                                     // Einzelne ToDo aus Sicht von userID (Kategorie, Position, Tags, Berechtigung), ErrNotFound wenn nicht vorhanden
+	GetTodosByUser(userID int, filter TodoFilter) ([]models.ToDo, error)             // Eigene und mit dem Benutzer geteilte ToDos, eingeschränkt durch filter
+	CreateTodo(todo *models.ToDo) error                                              // Legt die ToDo am Ende ihrer Liste (ListID) an, setzt ID, Order und Zeitstempel
+	UpdateTodo(id, userID int, changes models.ToDo) error                            // Übernimmt alle nicht leeren Felder aus changes, Kategorie, Liste und Position nur für userID, verschiebt bei Bedarf die Positionen beider Listen
+	DeleteTodo(id int) error                                                         // Löscht die ToDo samt Checkliste, Einladungen und Links für alle Mitglieder und schließt die Lücken in deren Reihenfolge
+	SetMemberPermission(todoID, userID int, permission models.SharePermission) error // Ändert die Berechtigung eines Mitglieds, ErrNotFound wenn userID kein Mitglied ist, ErrGroupAccess bei Zugriff über eine Gruppe
+	RemoveMember(todoID, userID int) error                                           // Beendet die Mitgliedschaft von userID, ErrNotFound wenn er kein Mitglied ist, ErrGroupAccess bei Zugriff über eine Gruppe
+	GetTodoMembers(todoID int) ([]models.TodoMember, error)                          // Mitglieder der ToDo (ohne Eigentümer), in der Reihenfolge, in der sie aufgenommen wurden
+	SetTodoStatus(id int, completed bool) (int, error)                               // Setzt den Status der ToDo, gilt für alle Mitglieder. Wird eine wiederkehrende ToDo erledigt, wird der nächste Termin angelegt und dessen ID geliefert, sonst 0
+	SearchTodos(userID int, query string, limit int) ([]models.SearchResult, error)  // Volltextsuche in Titel und Beschreibung, beste Treffer zuerst
+}
+
+// Checklisten der ToDos, gelten wie der Inhalt der ToDo für den Eigentümer und alle Mitglieder. Ist auto_complete
+// gesetzt, folgt der Status der ToDo nach jeder Änderung ihrer Checkliste: erledigt genau dann, wenn alle Punkte erledigt sind.
+type ChecklistStore interface {
+	GetChecklist(todoID int) ([]models.ChecklistItem, error)                    // Punkte der ToDo in ihrer Reihenfolge
+	AddChecklistItem(item *models.ChecklistItem) error                          // Hängt den Punkt offen am Ende an, setzt ID, Order und CreatedAt
+	UpdateChecklistItem(todoID, itemID int, changes models.ChecklistItem) error // Übernimmt Titel und Position (die übrigen Punkte rücken), ErrNotFound, ErrNoChanges, ErrOrderOutOfRange bzw. ErrOrderUnchanged
+	SetChecklistItemStatus(todoID, itemID int, completed bool) error            // ErrNotFound wenn der Punkt nicht zur ToDo gehört
+	DeleteChecklistItem(todoID, itemID int) error                               // Die nachfolgenden Punkte rücken auf, ErrNotFound wie oben
+}
+
+// Listen (Projekte) der Benutzer. Jede ToDo liegt aus Sicht jedes Benutzers in höchstens einer seiner Listen,
+// die Position (order) zählt je Benutzer und Liste, ToDos ohne Liste bilden eine eigene Reihenfolge.
+type ListStore interface {
+	CreateList(userID int, list *models.List) error   // Setzt ID und CreatedAt
+	GetList(id, userID int) (models.List, error)      // ErrNotFound wenn nicht vorhanden oder nicht von userID
+	GetListsByUser(userID int) ([]models.List, error) // Alle Listen des Benutzers mit Anzahl der ToDos, älteste zuerst
+	RenameList(id, userID int, name string) error     // ErrNotFound wie bei GetList
+	DeleteList(id, userID int) error                  // Die ToDos der Liste rücken in ihrer Reihenfolge ans Ende der ToDos ohne Liste, ihre Freigaben für Gruppen enden, ErrNotFound wie bei GetList
+}
+
+// Einladungen zu geteilten ToDos, mit der Annahme wird der Empfänger Mitglied
+type InvitationStore interface {
+	CreateInvitation(invitation *models.Invitation) error         // Setzt ID und CreatedAt, ErrAlreadyMember bzw. ErrInvitationOpen
+	GetInvitation(id int) (models.Invitation, error)              // ErrNotFound wenn nicht (mehr) offen
+	GetInvitationsByUser(userID int) ([]models.Invitation, error) // Offene Einladungen an den Benutzer, älteste zuerst
+	GetInvitationsByTodo(todoID int) ([]models.Invitation, error) // Offene Einladungen zu einer ToDo, älteste zuerst
+	AcceptInvitation(id int) (models.ToDo, error)                 // Nimmt den Empfänger am Ende seiner Liste als Mitglied auf, liefert die ToDo aus seiner Sicht
+	DeleteInvitation(id int) error                                // Ablehnen bzw. Zurückziehen, ErrNotFound wenn nicht (mehr) offen
+}
+
+// Gruppen von Benutzern und ihre Freigaben. Mitgliedschaften in ToDos, die über eine Gruppe bestehen, gleicht der Store
+// bei jeder Änderung an Gruppe, Mitgliedern, Freigaben an Listen sowie beim Verschieben einer ToDo in eine andere Liste selbst ab.
+type GroupStore interface {
+	CreateGroup(group *models.Group, ownerID int) error            // Legt die Gruppe mit ownerID als Eigentümer an, setzt ID, Role und CreatedAt
+	GetGroup(id int) (models.Group, error)                         // Gruppe mit allen Mitgliedern (Eigentümer zuerst), ErrNotFound wenn nicht vorhanden
+	GetGroupsByUser(userID int) ([]models.Group, error)            // Gruppen des Benutzers mit seiner Rolle, ohne Mitglieder
+	RenameGroup(id int, name string) error                         // ErrNotFound wenn nicht vorhanden
+	DeleteGroup(id int) error                                      // Löscht die Gruppe samt Freigaben und Einladungen, die Mitglieder verlieren den Zugriff über sie
+	GetGroupRole(groupID, userID int) (models.GroupRole, error)    // ErrNotFound wenn userID kein Mitglied ist
+	SetGroupRole(groupID, userID int, role models.GroupRole) error // ErrNotFound wenn userID kein Mitglied ist
+	RemoveGroupMember(groupID, userID int) error                   // Mitglied verliert den Zugriff über die Gruppe, seine Freigaben für die Gruppe enden, ErrNotFound wenn userID kein Mitglied ist
+	CreateGroupShare(share *models.GroupShare) error               // Setzt ID und CreatedAt, ErrAlreadyShared
+	GetGroupShare(id int) (models.GroupShare, error)               // ErrNotFound wenn nicht vorhanden
+	GetGroupShares(groupID int) ([]models.GroupShare, error)       // Freigaben der Gruppe, älteste zuerst
+	DeleteGroupShare(id int) error                                 // Beendet den Zugriff der Mitglieder über diese Freigabe, ErrNotFound wenn nicht vorhanden
+
+	// Einladungen in eine Gruppe, mit der Annahme wird der Empfänger Mitglied
+	CreateGroupInvitation(invitation *models.GroupInvitation) error           // Setzt ID und CreatedAt, ErrAlreadyMember bzw. ErrInvitationOpen
+	GetGroupInvitation(id int) (models.GroupInvitation, error)                // ErrNotFound wenn nicht (mehr) offen
+	GetGroupInvitationsByUser(userID int) ([]models.GroupInvitation, error)   // Offene Einladungen an den Benutzer, älteste zuerst
+	GetGroupInvitationsByGroup(groupID int) ([]models.GroupInvitation, error) // Offene Einladungen in eine Gruppe, älteste zuerst
+	AcceptGroupInvitation(id int) (models.Group, error)                       // Nimmt den Empfänger mit der Rolle der Einladung auf, er erhält Zugriff auf alle Freigaben der Gruppe. Liefert die Gruppe mit seiner Rolle, ohne Mitglieder
+	DeleteGroupInvitation(id int) error                                       // Ablehnen bzw. Zurückziehen, ErrNotFound wenn nicht (mehr) offen
+}
+
+// Öffentliche Links zum Lesen ohne Anmeldung, gespeichert wird nur der Hash ihres Tokens (auth.LookupKey)
+type LinkStore interface {
+	CreateShareLink(userID int, link *models.ShareLink, hash string) error // Setzt ID und CreatedAt
+	GetShareLinksByUser(userID int) ([]models.ShareLink, error)            // Alle Links eines Benutzers inkl. abgelaufener und widerrufener
+	RevokeShareLink(userID, linkID int) error                              // ErrNotFound wenn kein aktiver Link des Benutzers
+	ResolveShareLink(hash string) (models.ShareLink, int, error)           // Gültiger Link zum Hash mit seinem Ersteller, ErrNotFound wenn unbekannt, abgelaufen oder widerrufen
+}
+
+// Gespeicherter API Key eines Benutzers
+type Credential struct {
+	KeyID  int
+	UserID int
+	Hash   string   // bcrypt Hash, bei übernommenen Keys aus älteren Datenbanken Klartext
+	Lookup string   // auth.LookupKey des Keys, leer wenn noch nicht gesetzt
+	Scopes []string // Berechtigungen des Keys
+}
+
+// Zugriff auf die Benutzer
+type UserStore interface {
+	UserExists(id int) (bool, error)
+	CreateUser(user *models.User, hash, lookup string) error // Legt den Benutzer mit einem ersten API Key "default" an, setzt ID und CreatedAt, ErrEmailExists
+	GetUser(id int) (models.User, error)                     // Profil eines Benutzers, ErrNotFound wenn nicht vorhanden
+	UpdateUser(id int, changes models.User) error            // Übernimmt Anzeigename und E-Mail, sofern nicht leer, ErrNoChanges bzw. ErrEmailExists
+	DeleteUser(id int) error                                 // Löscht den Benutzer mit seinen ToDos, Listen, Tags, Einladungen (auch in Gruppen), Links, API Keys und Identitäten, geteilte ToDos gehen an das älteste direkte Mitglied, seine Gruppen an den ältesten Admin bzw. das älteste Mitglied über
+}
+
+// Zuordnung von Identitäten eines OpenID Connect Providers (issuer, sub) zu Benutzern
+type IdentityStore interface {
+	UserForIdentity(issuer, subject string) (int, error)                    // UserID zur Identität, ErrNotFound wenn noch nicht angelegt
+	CreateUserWithIdentity(user *models.User, issuer, subject string) error // Legt den Benutzer ohne API Key an, setzt ID und CreatedAt, ErrEmailExists
+}
+
+// Zugriff auf die API Keys der Benutzer
+type APIKeyStore interface {
+	FindCredentials(lookup, secretKey string) ([]Credential, error)         // Aktive Kandidaten zu einem Key: passender lookup oder noch ohne lookup gespeichert
+	UpdateAPIKeyHash(keyID int, hash, lookup string) error                  // Ersetzt Hash und Lookup eines Keys
+	MarkAPIKeyUsed(keyID int) error                                         // Setzt den Zeitpunkt des letzten Zugriffs
+	APIKeyActive(userID, keyID int) (bool, error)                           // Key des Benutzers vorhanden, nicht widerrufen und nicht abgelaufen
+	CreateAPIKey(userID int, key *models.APIKey, hash, lookup string) error // Legt einen weiteren Key an, setzt ID und CreatedAt
+	GetAPIKeysByUser(userID int) ([]models.APIKey, error)                   // Alle Keys eines Benutzers inkl. abgelaufener und widerrufener
+	RevokeAPIKey(userID, keyID int) error                                   // ErrNotFound wenn kein aktiver Key des Benutzers, ErrLastAPIKey für den letzten aktiven Key mit Scope admin (nicht bei Benutzern mit OIDC Identität)
+}
+
+// Refresh Tokens, gespeichert wird nur ihr Hash (auth.LookupKey)
+type TokenStore interface {
+	CreateRefreshToken(keyID int, hash string, expiresAt time.Time) error
+
+	// Markiert den Token als verwendet und liefert den zugehörigen API Key. ErrNotFound wenn unbekannt, abgelaufen
+	// oder der Key nicht mehr aktiv ist, ErrTokenReused wenn er bereits verwendet wurde, dann werden alle Refresh Tokens des Keys widerrufen.
+	UseRefreshToken(hash string) (Credential, error)
+}
+
+// Zugriff auf die Tags. Tags gehören dem Benutzer, der sie vergibt, und sind nur für ihn sichtbar, auch an geteilten ToDos.
+type TagStore interface {
+	AddTags(todoID, userID int, names []string) error            // Legt fehlende Tags von userID an und hängt sie an die ToDo
+	RemoveTag(todoID, userID int, name string) error             // ErrNotFound wenn die ToDo den Tag von userID nicht trägt
+	GetTagsByUser(userID int) ([]models.Tag, error)              // Alle Tags eines Benutzers mit Anzahl der ToDos
+	RenameTag(userID int, oldName, newName string) (bool, error) // Benennt um bzw. führt mit einem bestehenden Tag zusammen (true)
+}
+
+// Vollständiger Datenzugriff, wie ihn die Handler benötigen
+type Store interface {
+	TodoStore
+	ChecklistStore
+	ListStore
+	InvitationStore
+	GroupStore
+	LinkStore
+	UserStore
+	IdentityStore
+	APIKeyStore
+	TokenStore
+	TagStore
+}
@@ -0,0 +1,1073 @@
+// Gemeinsame Tests für alle Implementierungen von store.Store. Jede Implementierung ruft Run in ihrem eigenen
+// Test auf, so verhalten sich MemoryStore und SQLStore (SQLite und PostgreSQL) nachweislich gleich.
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Paul-frank/todo-api/internal/auth"
+	"github.com/Paul-frank/todo-api/internal/models"
+	"github.com/Paul-frank/todo-api/internal/store"
+)
+
+// Führt alle Tests gegen die Implementierung aus, newStore liefert für jeden Test einen leeren Store
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s store.Store)
+	}{
+		{"TodoOrder", testTodoOrder},
+		{"DueDates", testDueDates},
+		{"Priority", testPriority},
+		{"Tags", testTags},
+		{"TodoFilter", testTodoFilter},
+		{"Search", testSearch},
+		{"LegacyKeys", testLegacyKeys},
+		{"Users", testUsers},
+		{"APIKeys", testAPIKeys},
+		{"RefreshTokens", testRefreshTokens},
+		{"Members", testMembers},
+		{"Collaborators", testCollaborators},
+		{"Invitations", testInvitations},
+		{"ShareLinks", testShareLinks},
+		{"Groups", testGroups},
+		{"GroupLists", testGroupLists},
+		{"Lists", testLists},
+		{"Checklist", testChecklist},
+		{"Recurrence", testRecurrence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+// Legt einen Benutzer mit einem API Key "default" (Scope admin) an
+func createUser(t *testing.T, s store.Store, name string) int {
+	t.Helper()
+	user := models.User{DisplayName: name}
+	if err := s.CreateUser(&user, "hash-"+name, auth.LookupKey(name)); err != nil {
+		t.Fatalf("CreateUser(%s): %v", name, err)
+	}
+	return user.ID
+}
+
+func createTodo(t *testing.T, s store.Store, userID int, title string) models.ToDo {
+	t.Helper()
+	todo := models.ToDo{UserID: userID, Title: title, Category: "no category"}
+	if err := s.CreateTodo(&todo); err != nil {
+		t.Fatalf("CreateTodo(%s): %v", title, err)
+	}
+	return todo
+}
+
+func getTodo(t *testing.T, s store.Store, id, userID int) models.ToDo {
+	t.Helper()
+	todo, err := s.GetTodo(id, userID)
+	if err != nil {
+		t.Fatalf("GetTodo(%d, %d): %v", id, userID, err)
+	}
+	return todo
+}
+
+// Titel der ToDos in der gelieferten Reihenfolge
+func titles(t *testing.T, s store.Store, userID int, filter store.TodoFilter) []string {
+	t.Helper()
+	todos, err := s.GetTodosByUser(userID, filter)
+	if err != nil {
+		t.Fatalf("GetTodosByUser(%d): %v", userID, err)
+	}
+	names := []string{}
+	for _, todo := range todos {
+		names = append(names, todo.Title)
+	}
+	return names
+}
+
+func expectTitles(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("ToDos %q, erwartet %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ToDos %q, erwartet %q", got, want)
+		}
+	}
+}
+
+// Teilt die ToDo über eine angenommene Einladung mit userID
+func shareTodo(t *testing.T, s store.Store, todoID, ownerID, userID int, permission models.SharePermission) {
+	t.Helper()
+	invitation := models.Invitation{TodoID: todoID, OwnerID: ownerID, UserID: userID, Permission: permission}
+	if err := s.CreateInvitation(&invitation); err != nil {
+		t.Fatalf("CreateInvitation(%d, %d): %v", todoID, userID, err)
+	}
+	if _, err := s.AcceptInvitation(invitation.ID); err != nil {
+		t.Fatalf("AcceptInvitation(%d): %v", invitation.ID, err)
+	}
+}
+
+// Lädt den Benutzer in die Gruppe ein und nimmt die Einladung an
+func joinGroup(t *testing.T, s store.Store, groupID, userID int, role models.GroupRole) {
+	t.Helper()
+	invitation := models.GroupInvitation{GroupID: groupID, UserID: userID, Role: role}
+	if err := s.CreateGroupInvitation(&invitation); err != nil {
+		t.Fatalf("CreateGroupInvitation(%d, %d): %v", groupID, userID, err)
+	}
+	if _, err := s.AcceptGroupInvitation(invitation.ID); err != nil {
+		t.Fatalf("AcceptGroupInvitation(%d): %v", invitation.ID, err)
+	}
+}
+
+func expectErr(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if err != want {
+		t.Fatalf("%s: Fehler %v, erwartet %v", what, err, want)
+	}
+}
+
+func testTodoOrder(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	for _, title := range []string{"A", "B", "C", "D"} {
+		createTodo(t, s, userID, title)
+	}
+	d := createTodo(t, s, userID, "E")
+	if d.Order != 5 {
+		t.Fatalf("Order der fünften ToDo = %d", d.Order)
+	}
+
+	// E nach vorne, die übrigen rücken nach hinten
+	if err := s.UpdateTodo(d.ID, userID, models.ToDo{Order: 1}); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{}), "E", "A", "B", "C", "D")
+	expectErr(t, "UpdateTodo auf gleiche Position", s.UpdateTodo(d.ID, userID, models.ToDo{Order: 1}), store.ErrOrderUnchanged)
+	expectErr(t, "UpdateTodo außerhalb der Liste", s.UpdateTodo(d.ID, userID, models.ToDo{Order: 6}), store.ErrOrderOutOfRange)
+	expectErr(t, "UpdateTodo unbekannte ToDo", s.UpdateTodo(999, userID, models.ToDo{Title: "x"}), store.ErrNotFound)
+
+	// Löschen schließt die Lücke
+	todos, err := s.GetTodosByUser(userID, store.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTodo(todos[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	todos, err = s.GetTodosByUser(userID, store.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, todo := range todos {
+		if todo.Order != i+1 {
+			t.Fatalf("Order nach DeleteTodo: %s hat %d, erwartet %d", todo.Title, todo.Order, i+1)
+		}
+	}
+	if _, err := s.GetTodo(todos[0].ID+100, userID); err != store.ErrNotFound {
+		t.Fatalf("GetTodo unbekannte ToDo: %v", err)
+	}
+}
+
+func testDueDates(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	now := time.Now().Truncate(time.Second)
+	for _, tt := range []struct {
+		title string
+		due   time.Duration
+	}{
+		{"Gestern", -24 * time.Hour},
+		{"Morgen", 24 * time.Hour},
+		{"Nächste Woche", 7 * 24 * time.Hour},
+	} {
+		todo := models.ToDo{UserID: userID, Title: tt.title, Category: "no category", DueAt: &models.DueTime{Time: now.Add(tt.due)}}
+		if err := s.CreateTodo(&todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	undated := createTodo(t, s, userID, "Irgendwann")
+	if undated.DueAt != nil {
+		t.Fatalf("Fälligkeit ohne Angabe = %v", undated.DueAt)
+	}
+
+	// Zeiträume schließen ToDos ohne Fälligkeit aus, der Beginn zählt dazu, das Ende nicht
+	until := now.Add(24 * time.Hour)
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{DueUntil: &now}), "Gestern")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{DueFrom: &now}), "Morgen", "Nächste Woche")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{DueFrom: &now, DueUntil: &until}))
+	until = until.Add(time.Second)
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{DueFrom: &now, DueUntil: &until}), "Morgen")
+
+	// Setzen und Entfernen im Update
+	if err := s.UpdateTodo(undated.ID, userID, models.ToDo{DueAt: &models.DueTime{Time: now.Add(-time.Hour)}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, undated.ID, userID); got.DueAt == nil || !got.DueAt.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("Fälligkeit nach UpdateTodo = %v", got.DueAt)
+	}
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{DueUntil: &now}), "Gestern", "Irgendwann")
+	if err := s.UpdateTodo(undated.ID, userID, models.ToDo{DueAt: &models.DueTime{}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, undated.ID, userID); got.DueAt != nil {
+		t.Fatalf("Fälligkeit nach Entfernen = %v", got.DueAt)
+	}
+}
+
+func testPriority(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	a := createTodo(t, s, userID, "A")
+	b := createTodo(t, s, userID, "B")
+	createTodo(t, s, userID, "C")
+	urgent := models.PriorityUrgent
+	d := models.ToDo{UserID: userID, Title: "D", Category: "no category", Priority: &urgent}
+	if err := s.CreateTodo(&d); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, a.ID, userID); got.Priority == nil || *got.Priority != models.PriorityNone {
+		t.Fatalf("Priorität ohne Angabe = %v", got.Priority)
+	}
+
+	// Höchste Priorität zuerst, bei gleicher Priorität entscheidet die Reihenfolge
+	low := models.PriorityLow
+	if err := s.UpdateTodo(b.ID, userID, models.ToDo{Priority: &low}); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Sort: store.SortByPriority}), "D", "B", "A", "C")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Sort: store.SortByPriority, Descending: true}), "C", "A", "B", "D")
+	if err := s.UpdateTodo(a.ID, userID, models.ToDo{Priority: &urgent}); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Sort: store.SortByPriority}), "A", "D", "B", "C")
+}
+
+func testTags(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	otherID := createUser(t, s, "Ben")
+	a := createTodo(t, s, userID, "A")
+	b := createTodo(t, s, userID, "B")
+	createTodo(t, s, userID, "C")
+
+	if err := s.AddTags(a.ID, userID, []string{"privat", "arbeit"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddTags(b.ID, userID, []string{"arbeit"}); err != nil {
+		t.Fatal(err)
+	}
+	if tags := getTodo(t, s, a.ID, userID).Tags; len(tags) != 2 || tags[0] != "arbeit" || tags[1] != "privat" {
+		t.Fatalf("Tags von A = %q", tags)
+	}
+	expectErr(t, "AddTags unbekannte ToDo", s.AddTags(999, userID, []string{"x"}), store.ErrNotFound)
+
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Tags: []string{"arbeit", "privat"}}), "A")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Tags: []string{"arbeit", "privat"}, TagMode: store.TagModeAny}), "A", "B")
+
+	// Tags sind nur für ihren Benutzer sichtbar, auch auf einer Seite der Liste
+	todos, err := s.GetTodosByUser(userID, store.TodoFilter{Limit: 1})
+	if err != nil || len(todos) != 1 || len(todos[0].Tags) != 2 {
+		t.Fatalf("erste Seite = %+v, %v", todos, err)
+	}
+	tags, err := s.GetTagsByUser(otherID)
+	if err != nil || len(tags) != 0 {
+		t.Fatalf("Tags von Ben = %+v, %v", tags, err)
+	}
+
+	// Umbenennen in einen bestehenden Tag führt beide zusammen
+	merged, err := s.RenameTag(userID, "privat", "arbeit")
+	if err != nil || !merged {
+		t.Fatalf("RenameTag = %v, %v", merged, err)
+	}
+	tags, err = s.GetTagsByUser(userID)
+	if err != nil || len(tags) != 1 || tags[0].Name != "arbeit" || tags[0].Count != 2 {
+		t.Fatalf("Tags nach RenameTag = %+v, %v", tags, err)
+	}
+	expectErr(t, "RemoveTag nicht vergeben", s.RemoveTag(b.ID, userID, "privat"), store.ErrNotFound)
+}
+
+func testTodoFilter(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	otherID := createUser(t, s, "Ben")
+	a := createTodo(t, s, userID, "A")
+	createTodo(t, s, userID, "B")
+	c := createTodo(t, s, userID, "C")
+	createTodo(t, s, otherID, "Fremd")
+
+	if _, err := s.SetTodoStatus(a.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateTodo(c.ID, userID, models.ToDo{Category: "Arbeit"}); err != nil {
+		t.Fatal(err)
+	}
+
+	open, done := false, true
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Completed: &open}), "B", "C")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Completed: &done}), "A")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Category: "Arbeit"}), "C")
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Sort: store.SortByTitle, Descending: true}), "C", "B", "A")
+
+	// Keyset-Pagination über alle Seiten
+	first, err := s.GetTodosByUser(userID, store.TodoFilter{Sort: store.SortByTitle, Limit: 2})
+	if err != nil || len(first) != 2 {
+		t.Fatalf("erste Seite = %d ToDos, %v", len(first), err)
+	}
+	cursor := store.CursorFor(first[1])
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{Sort: store.SortByTitle, Limit: 2, After: &cursor}), "C")
+}
+
+func testSearch(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	otherID := createUser(t, s, "Ben")
+	createTodo(t, s, userID, "Steuererklärung abgeben")
+	description := models.ToDo{UserID: userID, Title: "Belege", Description: "für die Steuer sammeln", Category: "no category"}
+	if err := s.CreateTodo(&description); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddTags(description.ID, userID, []string{"finanzen"}); err != nil {
+		t.Fatal(err)
+	}
+	createTodo(t, s, userID, "Einkaufen")
+	createTodo(t, s, otherID, "Steuer von Ben")
+
+	results, err := s.SearchTodos(userID, "steuer", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Todo.Title != "Steuererklärung abgeben" {
+		t.Fatalf("SearchTodos = %+v", results)
+	}
+	if results[0].Title != "<mark>Steuererklärung</mark> abgeben" || len(results[1].Todo.Tags) != 1 {
+		t.Fatalf("Treffer = %q, Tags %q", results[0].Title, results[1].Todo.Tags)
+	}
+
+	results, err = s.SearchTodos(userID, "steuer belege", 1)
+	if err != nil || len(results) != 1 || results[0].Todo.ID != description.ID {
+		t.Fatalf("SearchTodos mit zwei Begriffen = %+v, %v", results, err)
+	}
+}
+
+// Keys aus älteren Datenbanken sind im Klartext und ohne Lookup gespeichert, bis sie beim ersten Zugriff gehasht werden
+func testLegacyKeys(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	legacy := models.APIKey{Name: "alt", Scopes: []string{auth.ScopeAdmin}}
+	if err := s.CreateAPIKey(userID, &legacy, "klartext", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, err := s.FindCredentials(auth.LookupKey("klartext"), "klartext")
+	if err != nil || len(credentials) != 1 || credentials[0].KeyID != legacy.ID || credentials[0].Hash != "klartext" || credentials[0].Lookup != "" {
+		t.Fatalf("FindCredentials Klartext = %+v, %v", credentials, err)
+	}
+	credentials, err = s.FindCredentials(auth.LookupKey("anders"), "anders")
+	if err != nil || len(credentials) != 0 {
+		t.Fatalf("FindCredentials anderer Key = %+v, %v", credentials, err)
+	}
+
+	// Nach dem Hashen wird der Key nur noch über seinen Lookup gefunden
+	if err := s.UpdateAPIKeyHash(legacy.ID, "$2a$10$gehasht", auth.LookupKey("klartext")); err != nil {
+		t.Fatal(err)
+	}
+	credentials, err = s.FindCredentials(auth.LookupKey("klartext"), "klartext")
+	if err != nil || len(credentials) != 1 || credentials[0].Hash != "$2a$10$gehasht" || credentials[0].Lookup != auth.LookupKey("klartext") {
+		t.Fatalf("FindCredentials nach UpdateAPIKeyHash = %+v, %v", credentials, err)
+	}
+	credentials, err = s.FindCredentials(auth.LookupKey("Anna"), "Anna")
+	if err != nil || len(credentials) != 1 || credentials[0].Hash != "hash-Anna" {
+		t.Fatalf("FindCredentials Key mit Lookup = %+v, %v", credentials, err)
+	}
+
+	// Gehashte Keys ohne Lookup sind Kandidaten für jeden Key, geprüft wird mit auth.VerifySecret
+	hashed := models.APIKey{Name: "ohne Lookup", Scopes: []string{auth.ScopeRead}}
+	if err := s.CreateAPIKey(userID, &hashed, "$2a$10$ohnelookup", ""); err != nil {
+		t.Fatal(err)
+	}
+	credentials, err = s.FindCredentials(auth.LookupKey("anders"), "anders")
+	if err != nil || len(credentials) != 1 || credentials[0].KeyID != hashed.ID {
+		t.Fatalf("FindCredentials Hash ohne Lookup = %+v, %v", credentials, err)
+	}
+
+	if err := s.MarkAPIKeyUsed(legacy.ID); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := s.GetAPIKeysByUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if (key.LastUsedAt != nil) != (key.ID == legacy.ID) {
+			t.Fatalf("Key %d zuletzt verwendet %v", key.ID, key.LastUsedAt)
+		}
+	}
+}
+
+func testUsers(t *testing.T, s store.Store) {
+	anna := models.User{DisplayName: "Anna", Email: "anna@example.org"}
+	if err := s.CreateUser(&anna, "hash", auth.LookupKey("anna")); err != nil {
+		t.Fatal(err)
+	}
+	ben := models.User{DisplayName: "Ben", Email: "anna@example.org"}
+	expectErr(t, "CreateUser mit vergebener E-Mail", s.CreateUser(&ben, "hash", auth.LookupKey("ben")), store.ErrEmailExists)
+	benID := createUser(t, s, "Ben")
+
+	exists, err := s.UserExists(anna.ID)
+	if err != nil || !exists {
+		t.Fatalf("UserExists(%d) = %v, %v", anna.ID, exists, err)
+	}
+	expectErr(t, "UpdateUser ohne Änderungen", s.UpdateUser(anna.ID, models.User{}), store.ErrNoChanges)
+	expectErr(t, "UpdateUser mit vergebener E-Mail", s.UpdateUser(benID, models.User{Email: "anna@example.org"}), store.ErrEmailExists)
+	if err := s.UpdateUser(anna.ID, models.User{DisplayName: "Anna B."}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.GetUser(anna.ID)
+	if err != nil || user.DisplayName != "Anna B." || user.Email != "anna@example.org" {
+		t.Fatalf("GetUser nach UpdateUser = %+v, %v", user, err)
+	}
+
+	// Mit dem Benutzer verschwinden seine ToDos und Keys, andere Benutzer bleiben unberührt
+	todo := createTodo(t, s, anna.ID, "Eigene")
+	createTodo(t, s, benID, "Fremde")
+	if err := s.DeleteUser(anna.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetUser(anna.ID); err != store.ErrNotFound {
+		t.Fatalf("GetUser nach DeleteUser: %v", err)
+	}
+	if exists, err := s.UserExists(anna.ID); err != nil || exists {
+		t.Fatalf("UserExists nach DeleteUser = %v, %v", exists, err)
+	}
+	if _, err := s.GetTodo(todo.ID, anna.ID); err != store.ErrNotFound {
+		t.Fatalf("GetTodo nach DeleteUser: %v", err)
+	}
+	credentials, err := s.FindCredentials(auth.LookupKey("anna"), "anna")
+	if err != nil || len(credentials) != 0 {
+		t.Fatalf("FindCredentials nach DeleteUser = %+v, %v", credentials, err)
+	}
+	expectTitles(t, titles(t, s, benID, store.TodoFilter{}), "Fremde")
+}
+
+func testAPIKeys(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	keys, err := s.GetAPIKeysByUser(userID)
+	if err != nil || len(keys) != 1 || keys[0].Name != "default" {
+		t.Fatalf("GetAPIKeysByUser = %+v, %v", keys, err)
+	}
+	defaultKey := keys[0]
+
+	// Der letzte aktive Key mit Scope admin bleibt
+	expectErr(t, "RevokeAPIKey letzter Admin-Key", s.RevokeAPIKey(userID, defaultKey.ID), store.ErrLastAPIKey)
+	readKey := models.APIKey{Name: "dash", Scopes: []string{auth.ScopeRead}}
+	if err := s.CreateAPIKey(userID, &readKey, "hash-dash", auth.LookupKey("dash")); err != nil {
+		t.Fatal(err)
+	}
+	expectErr(t, "RevokeAPIKey neben Key ohne admin", s.RevokeAPIKey(userID, defaultKey.ID), store.ErrLastAPIKey)
+	adminKey := models.APIKey{Name: "ci", Scopes: []string{auth.ScopeAdmin}}
+	if err := s.CreateAPIKey(userID, &adminKey, "hash-ci", auth.LookupKey("ci")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeAPIKey(userID, defaultKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectErr(t, "RevokeAPIKey doppelt", s.RevokeAPIKey(userID, defaultKey.ID), store.ErrNotFound)
+
+	// Widerrufene Keys melden nicht mehr an
+	credentials, err := s.FindCredentials(auth.LookupKey("Anna"), "Anna")
+	if err != nil || len(credentials) != 0 {
+		t.Fatalf("FindCredentials widerrufener Key = %+v, %v", credentials, err)
+	}
+	credentials, err = s.FindCredentials(auth.LookupKey("ci"), "ci")
+	if err != nil || len(credentials) != 1 || credentials[0].KeyID != adminKey.ID || credentials[0].UserID != userID {
+		t.Fatalf("FindCredentials = %+v, %v", credentials, err)
+	}
+	// Aktiv nur für den eigenen Benutzer und solange weder widerrufen, abgelaufen noch gelöscht
+	expired := time.Now().Add(-time.Hour)
+	expiredKey := models.APIKey{Name: "alt", Scopes: []string{auth.ScopeRead}, ExpiresAt: &expired}
+	if err := s.CreateAPIKey(userID, &expiredKey, "hash-alt", auth.LookupKey("alt")); err != nil {
+		t.Fatal(err)
+	}
+	otherID := createUser(t, s, "Ben")
+	for _, tt := range []struct {
+		name          string
+		userID, keyID int
+		want          bool
+	}{
+		{"aktiv", userID, adminKey.ID, true},
+		{"widerrufen", userID, defaultKey.ID, false},
+		{"abgelaufen", userID, expiredKey.ID, false},
+		{"fremder Benutzer", otherID, adminKey.ID, false},
+		{"unbekannt", userID, 9999, false},
+	} {
+		active, err := s.APIKeyActive(tt.userID, tt.keyID)
+		if err != nil || active != tt.want {
+			t.Errorf("APIKeyActive %s = %v, %v, erwartet %v", tt.name, active, err, tt.want)
+		}
+	}
+	if err := s.DeleteUser(userID); err != nil {
+		t.Fatal(err)
+	}
+	if active, err := s.APIKeyActive(userID, adminKey.ID); err != nil || active {
+		t.Errorf("APIKeyActive nach DeleteUser = %v, %v", active, err)
+	}
+	// Mit Identität des OIDC Providers darf auch der letzte Key mit Scope admin widerrufen werden
+	linked := models.User{DisplayName: "Carla"}
+	if err := s.CreateUserWithIdentity(&linked, "https://idp.example.com", "carla"); err != nil {
+		t.Fatal(err)
+	}
+	linkedKey := models.APIKey{Name: "skript", Scopes: []string{auth.ScopeAdmin}}
+	if err := s.CreateAPIKey(linked.ID, &linkedKey, "hash-skript", auth.LookupKey("skript")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeAPIKey(linked.ID, linkedKey.ID); err != nil {
+		t.Fatalf("RevokeAPIKey mit Identität = %v", err)
+	}
+}
+
+func testRefreshTokens(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	keys, err := s.GetAPIKeysByUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := keys[0].ID
+
+	if err := s.CreateRefreshToken(keyID, "erster", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateRefreshToken(keyID, "zweiter", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateRefreshToken(keyID, "abgelaufen", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := s.UseRefreshToken("erster")
+	if err != nil || credential.KeyID != keyID || credential.UserID != userID {
+		t.Fatalf("UseRefreshToken = %+v, %v", credential, err)
+	}
+	_, err = s.UseRefreshToken("abgelaufen")
+	expectErr(t, "UseRefreshToken abgelaufen", err, store.ErrNotFound)
+
+	// Wiederverwendung widerruft alle Tokens des Keys
+	_, err = s.UseRefreshToken("erster")
+	expectErr(t, "UseRefreshToken doppelt", err, store.ErrTokenReused)
+	_, err = s.UseRefreshToken("zweiter")
+	if err == nil {
+		t.Fatal("UseRefreshToken nach Wiederverwendung erfolgreich")
+	}
+}
+
+// Geteilt wird ein einziger Datensatz, Kategorie, Liste und Position gelten je Mitglied
+func testMembers(t *testing.T, s store.Store) {
+	ownerID := createUser(t, s, "Anna")
+	memberID := createUser(t, s, "Ben")
+	own := createTodo(t, s, memberID, "Eigene")
+	todo := createTodo(t, s, ownerID, "Geteilt")
+	shareTodo(t, s, todo.ID, ownerID, memberID, models.PermissionEdit)
+	if got := getTodo(t, s, todo.ID, memberID); got.Order != 2 || got.Permission != models.PermissionEdit {
+		t.Fatalf("ToDo des Mitglieds = Order %d, Berechtigung %q", got.Order, got.Permission)
+	}
+
+	// Inhalt und Status gelten für alle
+	if err := s.UpdateTodo(todo.ID, ownerID, models.ToDo{Title: "Umzug"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetTodoStatus(todo.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, todo.ID, memberID); got.Title != "Umzug" || !got.Completed {
+		t.Fatalf("ToDo des Mitglieds = %+v", got)
+	}
+
+	// Kategorie und Position nur für den Benutzer selbst
+	if err := s.UpdateTodo(todo.ID, memberID, models.ToDo{Category: "Team", Order: 1}); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Umzug", "Eigene")
+	if got := getTodo(t, s, todo.ID, ownerID); got.Category != "no category" || got.Order != 1 {
+		t.Fatalf("ToDo des Eigentümers = Kategorie %q, Order %d", got.Category, got.Order)
+	}
+	open, shared := false, true
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{Shared: &shared}), "Umzug")
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{Completed: &open}), "Eigene")
+
+	// Geteilte ToDos gehen beim Löschen des Eigentümers an das älteste direkte Mitglied über
+	carlaID := createUser(t, s, "Carla")
+	shareTodo(t, s, todo.ID, ownerID, carlaID, models.PermissionView)
+	if err := s.DeleteUser(ownerID); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, todo.ID, memberID); got.UserID != memberID || got.Permission != "" {
+		t.Fatalf("ToDo nach DeleteUser = Eigentümer %d, Berechtigung %q", got.UserID, got.Permission)
+	}
+	if got := getTodo(t, s, todo.ID, carlaID); got.Permission != models.PermissionView {
+		t.Fatalf("Berechtigung des übrigen Mitglieds = %q", got.Permission)
+	}
+
+	// Löscht der Eigentümer die ToDo, verschwindet sie für alle Mitglieder
+	if err := s.DeleteTodo(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, carlaID, store.TodoFilter{}))
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Eigene")
+	if got := getTodo(t, s, own.ID, memberID); got.Order != 1 {
+		t.Fatalf("Order der eigenen ToDo nach DeleteTodo = %d", got.Order)
+	}
+}
+
+func testCollaborators(t *testing.T, s store.Store) {
+	ownerID := createUser(t, s, "Anna")
+	benID := createUser(t, s, "Ben")
+	carlaID := createUser(t, s, "Carla")
+	createTodo(t, s, benID, "Eigene")
+	todo := createTodo(t, s, ownerID, "Geteilt")
+	after := createTodo(t, s, benID, "Danach")
+	shareTodo(t, s, todo.ID, ownerID, benID, models.PermissionView)
+	shareTodo(t, s, todo.ID, ownerID, carlaID, models.PermissionEdit)
+
+	// Mitglieder ohne Eigentümer, in der Reihenfolge ihrer Aufnahme
+	members, err := s.GetTodoMembers(todo.ID)
+	if err != nil || len(members) != 2 || members[0].UserID != benID || members[0].DisplayName != "Ben" || members[1].Permission != models.PermissionEdit {
+		t.Fatalf("GetTodoMembers = %+v, %v", members, err)
+	}
+	if members[0].SharedAt.IsZero() || members[0].GroupID != nil {
+		t.Fatalf("Mitglied = %+v", members[0])
+	}
+
+	if err := s.SetMemberPermission(todo.ID, benID, models.PermissionComplete); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, todo.ID, benID); got.Permission != models.PermissionComplete {
+		t.Fatalf("Berechtigung nach SetMemberPermission = %q", got.Permission)
+	}
+	expectErr(t, "SetMemberPermission kein Mitglied", s.SetMemberPermission(todo.ID, ownerID, models.PermissionEdit), store.ErrNotFound)
+
+	// Nach dem Entfernen schließt sich die Lücke in der Liste des Mitglieds
+	if err := s.UpdateTodo(todo.ID, benID, models.ToDo{Order: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveMember(todo.ID, benID); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, benID, store.TodoFilter{}), "Eigene", "Danach")
+	if got := getTodo(t, s, after.ID, benID); got.Order != 2 {
+		t.Fatalf("Order nach RemoveMember = %d", got.Order)
+	}
+	expectErr(t, "RemoveMember doppelt", s.RemoveMember(todo.ID, benID), store.ErrNotFound)
+	members, err = s.GetTodoMembers(todo.ID)
+	if err != nil || len(members) != 1 || members[0].UserID != carlaID {
+		t.Fatalf("GetTodoMembers nach RemoveMember = %+v, %v", members, err)
+	}
+}
+
+func testInvitations(t *testing.T, s store.Store) {
+	ownerID := createUser(t, s, "Anna")
+	memberID := createUser(t, s, "Ben")
+	createTodo(t, s, memberID, "Eigene")
+	todo := createTodo(t, s, ownerID, "Geteilt")
+
+	invitation := models.Invitation{TodoID: todo.ID, OwnerID: ownerID, UserID: memberID, Permission: models.PermissionComplete}
+	if err := s.CreateInvitation(&invitation); err != nil {
+		t.Fatal(err)
+	}
+	again := invitation
+	expectErr(t, "CreateInvitation doppelt", s.CreateInvitation(&again), store.ErrInvitationOpen)
+
+	// Vor der Annahme ist die ToDo für den Empfänger nicht sichtbar
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Eigene")
+	invitations, err := s.GetInvitationsByUser(memberID)
+	if err != nil || len(invitations) != 1 || invitations[0].TodoTitle != "Geteilt" || invitations[0].OwnerName != "Anna" {
+		t.Fatalf("GetInvitationsByUser = %+v, %v", invitations, err)
+	}
+	invitations, err = s.GetInvitationsByTodo(todo.ID)
+	if err != nil || len(invitations) != 1 || invitations[0].ID != invitation.ID {
+		t.Fatalf("GetInvitationsByTodo = %+v, %v", invitations, err)
+	}
+
+	// Abgelehnte Einladungen können erneut ausgesprochen werden
+	if err := s.DeleteInvitation(invitation.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectErr(t, "DeleteInvitation doppelt", s.DeleteInvitation(invitation.ID), store.ErrNotFound)
+	invitation.ID = 0
+	if err := s.CreateInvitation(&invitation); err != nil {
+		t.Fatal(err)
+	}
+
+	shared, err := s.AcceptInvitation(invitation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.Permission != models.PermissionComplete || shared.Order != 2 {
+		t.Fatalf("angenommene ToDo = Berechtigung %q, Order %d", shared.Permission, shared.Order)
+	}
+	if _, err := s.GetInvitation(invitation.ID); err != store.ErrNotFound {
+		t.Fatalf("GetInvitation nach Annahme: %v", err)
+	}
+	if _, err := s.AcceptInvitation(invitation.ID); err != store.ErrNotFound {
+		t.Fatalf("AcceptInvitation doppelt: %v", err)
+	}
+	again = invitation
+	expectErr(t, "CreateInvitation für Mitglied", s.CreateInvitation(&again), store.ErrAlreadyMember)
+
+	// Mit der ToDo verschwinden ihre offenen Einladungen
+	carlaID := createUser(t, s, "Carla")
+	open := models.Invitation{TodoID: todo.ID, OwnerID: ownerID, UserID: carlaID, Permission: models.PermissionView}
+	if err := s.CreateInvitation(&open); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTodo(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	invitations, err = s.GetInvitationsByUser(carlaID)
+	if err != nil || len(invitations) != 0 {
+		t.Fatalf("GetInvitationsByUser nach DeleteTodo = %+v, %v", invitations, err)
+	}
+}
+
+func testShareLinks(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	todo := createTodo(t, s, userID, "A")
+
+	link := models.ShareLink{TodoID: &todo.ID}
+	if err := s.CreateShareLink(userID, &link, "hash-a"); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	old := models.ShareLink{Filter: "completed=false", ExpiresAt: &expired}
+	if err := s.CreateShareLink(userID, &old, "hash-alt"); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, ownerID, err := s.ResolveShareLink("hash-a")
+	if err != nil || ownerID != userID || resolved.ID != link.ID {
+		t.Fatalf("ResolveShareLink = %+v, %d, %v", resolved, ownerID, err)
+	}
+	_, _, err = s.ResolveShareLink("hash-alt")
+	expectErr(t, "ResolveShareLink abgelaufen", err, store.ErrNotFound)
+
+	if err := s.RevokeShareLink(userID, link.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.ResolveShareLink("hash-a")
+	expectErr(t, "ResolveShareLink widerrufen", err, store.ErrNotFound)
+
+	// Mit der ToDo verschwinden auch ihre Links
+	if err := s.DeleteTodo(todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	links, err := s.GetShareLinksByUser(userID)
+	if err != nil || len(links) != 1 || links[0].ID != old.ID {
+		t.Fatalf("GetShareLinksByUser = %+v, %v", links, err)
+	}
+}
+
+func testGroups(t *testing.T, s store.Store) {
+	ownerID := createUser(t, s, "Anna")
+	memberID := createUser(t, s, "Ben")
+	group := models.Group{Name: "Team"}
+	if err := s.CreateGroup(&group, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	if group.Role != models.GroupRoleOwner {
+		t.Fatalf("Rolle nach CreateGroup = %q", group.Role)
+	}
+
+	shared := createTodo(t, s, ownerID, "Gemeinsam")
+	share := models.GroupShare{GroupID: group.ID, OwnerID: ownerID, TodoID: &shared.ID, Permission: models.PermissionEdit}
+	if err := s.CreateGroupShare(&share); err != nil {
+		t.Fatal(err)
+	}
+	again := share
+	expectErr(t, "CreateGroupShare doppelt", s.CreateGroupShare(&again), store.ErrAlreadyShared)
+
+	// Eingeladene sehen die Freigaben der Gruppe erst nach der Annahme
+	invitation := models.GroupInvitation{GroupID: group.ID, UserID: memberID, Role: models.GroupRoleMember}
+	if err := s.CreateGroupInvitation(&invitation); err != nil {
+		t.Fatal(err)
+	}
+	twice := invitation
+	expectErr(t, "CreateGroupInvitation doppelt", s.CreateGroupInvitation(&twice), store.ErrInvitationOpen)
+	invitations, err := s.GetGroupInvitationsByUser(memberID)
+	if err != nil || len(invitations) != 1 || invitations[0].GroupName != "Team" || invitations[0].Role != models.GroupRoleMember {
+		t.Fatalf("GetGroupInvitationsByUser = %+v, %v", invitations, err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}))
+	if _, err := s.GetGroupRole(group.ID, memberID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupRole vor der Annahme: %v", err)
+	}
+
+	// Mit der Annahme erhalten neue Mitglieder den Zugriff über die Gruppe automatisch
+	joined, err := s.AcceptGroupInvitation(invitation.ID)
+	if err != nil || joined.ID != group.ID || joined.Name != "Team" || joined.Role != models.GroupRoleMember {
+		t.Fatalf("AcceptGroupInvitation = %+v, %v", joined, err)
+	}
+	if _, err := s.GetGroupInvitation(invitation.ID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupInvitation nach der Annahme: %v", err)
+	}
+	expectErr(t, "CreateGroupInvitation für Mitglied", s.CreateGroupInvitation(&models.GroupInvitation{GroupID: group.ID, UserID: memberID, Role: models.GroupRoleMember}), store.ErrAlreadyMember)
+	if got := getTodo(t, s, shared.ID, memberID); got.Permission != models.PermissionEdit {
+		t.Fatalf("Berechtigung über die Gruppe = %q", got.Permission)
+	}
+	expectErr(t, "RemoveMember bei Zugriff über Gruppe", s.RemoveMember(shared.ID, memberID), store.ErrGroupAccess)
+
+	members, err := s.GetTodoMembers(shared.ID)
+	if err != nil || len(members) != 1 || members[0].GroupID == nil || *members[0].GroupID != group.ID {
+		t.Fatalf("GetTodoMembers = %+v, %v", members, err)
+	}
+
+	// Auch ein Mitglied teilt seine ToDos mit der Gruppe
+	ownTodo := createTodo(t, s, memberID, "Von Ben")
+	memberShare := models.GroupShare{GroupID: group.ID, OwnerID: memberID, TodoID: &ownTodo.ID, Permission: models.PermissionView}
+	if err := s.CreateGroupShare(&memberShare); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, ownerID, store.TodoFilter{}), "Gemeinsam", "Von Ben")
+
+	// Ausscheidende Mitglieder verlieren den Zugriff, ihre Freigaben für die Gruppe enden
+	if err := s.RemoveGroupMember(group.ID, memberID); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Von Ben")
+	expectTitles(t, titles(t, s, ownerID, store.TodoFilter{}), "Gemeinsam")
+	if _, err := s.GetGroupShare(memberShare.ID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupShare des ausgeschiedenen Mitglieds: %v", err)
+	}
+	if _, err := s.GetGroupShare(share.ID); err != nil {
+		t.Fatalf("GetGroupShare des Eigentümers nach RemoveGroupMember: %v", err)
+	}
+	if _, err := s.GetGroupRole(group.ID, memberID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupRole nach RemoveGroupMember: %v", err)
+	}
+
+	// Eine abgelehnte Einladung kann erneut ausgesprochen werden, mit der Gruppe enden auch offene Einladungen
+	declined := models.GroupInvitation{GroupID: group.ID, UserID: memberID, Role: models.GroupRoleAdmin}
+	if err := s.CreateGroupInvitation(&declined); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteGroupInvitation(declined.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectErr(t, "DeleteGroupInvitation doppelt", s.DeleteGroupInvitation(declined.ID), store.ErrNotFound)
+	open := models.GroupInvitation{GroupID: group.ID, UserID: memberID, Role: models.GroupRoleMember}
+	if err := s.CreateGroupInvitation(&open); err != nil {
+		t.Fatal(err)
+	}
+	if invitations, err := s.GetGroupInvitationsByGroup(group.ID); err != nil || len(invitations) != 1 || invitations[0].ID != open.ID {
+		t.Fatalf("GetGroupInvitationsByGroup = %+v, %v", invitations, err)
+	}
+
+	if err := s.DeleteGroup(group.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetGroupShare(share.ID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupShare nach DeleteGroup: %v", err)
+	}
+	if _, err := s.GetGroupInvitation(open.ID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupInvitation nach DeleteGroup: %v", err)
+	}
+}
+
+// Mit einer Liste geteilt sind alle ToDos, die gerade in der Liste des Eigentümers liegen
+func testGroupLists(t *testing.T, s store.Store) {
+	ownerID := createUser(t, s, "Anna")
+	memberID := createUser(t, s, "Ben")
+	group := models.Group{Name: "Team"}
+	if err := s.CreateGroup(&group, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	joinGroup(t, s, group.ID, memberID, models.GroupRoleMember)
+	list := models.List{Name: "Baustelle"}
+	if err := s.CreateList(ownerID, &list); err != nil {
+		t.Fatal(err)
+	}
+
+	inList := models.ToDo{UserID: ownerID, Title: "Estrich", Category: "no category", ListID: &list.ID}
+	if err := s.CreateTodo(&inList); err != nil {
+		t.Fatal(err)
+	}
+	createTodo(t, s, ownerID, "Privat")
+	share := models.GroupShare{GroupID: group.ID, OwnerID: ownerID, ListID: &list.ID, Permission: models.PermissionView}
+	if err := s.CreateGroupShare(&share); err != nil {
+		t.Fatal(err)
+	}
+	again := share
+	expectErr(t, "CreateGroupShare Liste doppelt", s.CreateGroupShare(&again), store.ErrAlreadyShared)
+	if got, err := s.GetGroupShare(share.ID); err != nil || got.ListID == nil || *got.ListID != list.ID || got.TodoID != nil {
+		t.Fatalf("GetGroupShare = %+v, %v", got, err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Estrich")
+
+	// Später in die Liste gelegte ToDos folgen, herausgenommene nicht mehr
+	later := models.ToDo{UserID: ownerID, Title: "Fliesen", Category: "no category", ListID: &list.ID}
+	if err := s.CreateTodo(&later); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Estrich", "Fliesen")
+	noList := 0
+	if err := s.UpdateTodo(inList.ID, ownerID, models.ToDo{ListID: &noList}); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}), "Fliesen")
+
+	// Mit der Liste endet auch ihre Freigabe
+	if err := s.DeleteList(list.ID, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	expectTitles(t, titles(t, s, memberID, store.TodoFilter{}))
+	if _, err := s.GetGroupShare(share.ID); err != store.ErrNotFound {
+		t.Fatalf("GetGroupShare nach DeleteList: %v", err)
+	}
+}
+
+func testLists(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	otherID := createUser(t, s, "Ben")
+	list := models.List{Name: "Garten"}
+	if err := s.CreateList(userID, &list); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetList(list.ID, otherID); err != store.ErrNotFound {
+		t.Fatalf("GetList fremde Liste: %v", err)
+	}
+
+	createTodo(t, s, userID, "A")
+	inList := models.ToDo{UserID: userID, Title: "Rasen", Category: "no category", ListID: &list.ID}
+	if err := s.CreateTodo(&inList); err != nil {
+		t.Fatal(err)
+	}
+	if inList.Order != 1 {
+		t.Fatalf("Order in neuer Liste = %d", inList.Order)
+	}
+	expectTitles(t, titles(t, s, userID, store.TodoFilter{ListID: &list.ID}), "Rasen")
+
+	lists, err := s.GetListsByUser(userID)
+	if err != nil || len(lists) != 1 || lists[0].Count != 1 {
+		t.Fatalf("GetListsByUser = %+v, %v", lists, err)
+	}
+
+	// Beim Löschen rücken die ToDos ans Ende der ToDos ohne Liste
+	if err := s.DeleteList(list.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTodo(t, s, inList.ID, userID); got.ListID != nil || got.Order != 2 {
+		t.Fatalf("ToDo nach DeleteList = Liste %v, Order %d", got.ListID, got.Order)
+	}
+	expectErr(t, "RenameList gelöscht", s.RenameList(list.ID, userID, "x"), store.ErrNotFound)
+}
+
+func testChecklist(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	autoComplete := true
+	todo := models.ToDo{UserID: userID, Title: "Umzug", Category: "no category", AutoComplete: &autoComplete}
+	if err := s.CreateTodo(&todo); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []models.ChecklistItem
+	for _, title := range []string{"Kisten", "Transporter", "Helfer"} {
+		item := models.ChecklistItem{TodoID: todo.ID, Title: title}
+		if err := s.AddChecklistItem(&item); err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	if err := s.UpdateChecklistItem(todo.ID, items[2].ID, models.ChecklistItem{Order: 1}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.GetChecklist(todo.ID)
+	if err != nil || len(list) != 3 || list[0].Title != "Helfer" || list[1].Order != 2 {
+		t.Fatalf("GetChecklist = %+v, %v", list, err)
+	}
+	expectErr(t, "SetChecklistItemStatus fremde ToDo", s.SetChecklistItemStatus(todo.ID+1, items[0].ID, true), store.ErrNotFound)
+
+	// Mit auto_complete ist die ToDo erledigt, sobald alle Punkte erledigt sind
+	for _, item := range items[:2] {
+		if err := s.SetChecklistItemStatus(todo.ID, item.ID, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := getTodo(t, s, todo.ID, userID)
+	if got.Completed || got.Progress == nil || got.Progress.Done != 2 || got.Progress.Total != 3 {
+		t.Fatalf("ToDo mit 2 von 3 Punkten = erledigt %v, Fortschritt %+v", got.Completed, got.Progress)
+	}
+	if err := s.DeleteChecklistItem(todo.ID, items[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if !getTodo(t, s, todo.ID, userID).Completed {
+		t.Fatal("ToDo nach Löschen des letzten offenen Punkts nicht erledigt")
+	}
+	item := models.ChecklistItem{TodoID: todo.ID, Title: "Schlüssel"}
+	if err := s.AddChecklistItem(&item); err != nil {
+		t.Fatal(err)
+	}
+	if getTodo(t, s, todo.ID, userID).Completed {
+		t.Fatal("ToDo nach neuem offenen Punkt noch erledigt")
+	}
+}
+
+func testRecurrence(t *testing.T, s store.Store) {
+	userID := createUser(t, s, "Anna")
+	rule, err := models.ParseRecurrence("FREQ=WEEKLY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	todo := models.ToDo{UserID: userID, Title: "Müll", Category: "no category", DueAt: &models.DueTime{Time: due}, Recurrence: &rule}
+	if err := s.CreateTodo(&todo); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddTags(todo.ID, userID, []string{"haushalt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	nextID, err := s.SetTodoStatus(todo.ID, true)
+	if err != nil || nextID == 0 {
+		t.Fatalf("SetTodoStatus = %d, %v", nextID, err)
+	}
+	next := getTodo(t, s, nextID, userID)
+	if next.Completed || next.DueAt == nil || !next.DueAt.Equal(due.AddDate(0, 0, 7)) {
+		t.Fatalf("nächster Termin = erledigt %v, fällig %v", next.Completed, next.DueAt)
+	}
+	if next.Recurrence == nil || next.Recurrence.String() != "FREQ=WEEKLY" || len(next.Tags) != 1 || next.Order != 2 {
+		t.Fatalf("nächster Termin = %+v", next)
+	}
+
+	// Die Wiederholung wandert zum neuen Termin, erneutes Öffnen und Erledigen legt keinen weiteren an
+	if done := getTodo(t, s, todo.ID, userID); done.Recurrence != nil && !done.Recurrence.IsZero() {
+		t.Fatalf("Wiederholung der erledigten ToDo = %v", done.Recurrence)
+	}
+	if _, err := s.SetTodoStatus(todo.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if nextID, err = s.SetTodoStatus(todo.ID, true); err != nil || nextID != 0 {
+		t.Fatalf("SetTodoStatus erneut = %d, %v", nextID, err)
+	}
+	// Erledigt die Checkliste einen Termin mit auto_complete, folgt der nächste ebenso
+	autoComplete := true
+	watering := models.ToDo{UserID: userID, Title: "Blumen", Category: "no category", AutoComplete: &autoComplete, Recurrence: &rule}
+	if err := s.CreateTodo(&watering); err != nil {
+		t.Fatal(err)
+	}
+	item := models.ChecklistItem{TodoID: watering.ID, Title: "Balkon"}
+	if err := s.AddChecklistItem(&item); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetChecklistItemStatus(watering.ID, item.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if done := getTodo(t, s, watering.ID, userID); !done.Completed || done.UpdatedAt.Before(watering.UpdatedAt) {
+		t.Fatalf("Termin nach erledigter Checkliste = erledigt %v, geändert %v", done.Completed, done.UpdatedAt)
+	}
+	todos, err := s.GetTodosByUser(userID, store.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var following []models.ToDo
+	for _, todo := range todos {
+		if todo.Title == "Blumen" && todo.ID != watering.ID {
+			following = append(following, todo)
+		}
+	}
+	if len(following) != 1 || following[0].Completed || following[0].Recurrence == nil || following[0].Progress == nil || following[0].Progress.Done != 0 {
+		t.Fatalf("nächste Termine nach erledigter Checkliste = %+v", following)
+	}
+}